@@ -3,68 +3,694 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"image/color"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/lepinkainen/obsidian-tmdb-cover/internal/app"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/config"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/content"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
 	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tui"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/util"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/version"
+)
+
+// exitUnauthorized is the exit status when TMDB rejects the API key.
+const exitUnauthorized = 3
+
+// The TMDB API key is looked up in the OS keyring under this service and
+// account when --keyring is given.
+const (
+	keyringService = "obsidian-tmdb-cover"
+	keyringAccount = "tmdb_api_key"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "version" {
+		printVersion()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "discover" {
+		runDiscover(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		runCheck(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "clean-orphans" {
+		runCleanOrphans(os.Args[2:])
+		return
+	}
+	importMode := len(os.Args) > 1 && os.Args[1] == "import"
+	watchMode := len(os.Args) > 1 && os.Args[1] == "watch"
+	if importMode || watchMode {
+		// the import and watch subcommands take the same options as a normal run
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	var (
 		force           bool
 		generateContent bool
 		contentSections string
+		strictSections  bool
+		coverFormat     string
+		configPath      string
+		showVersion     bool
+		apiKeyFile      string
+		useKeyring      bool
+		keywordTags     bool
+		studioTags      string
+		region          string
+		only            string
+		noProgress      bool
+		verbose         bool
+		updateContent   bool
+		imageFormat     string
+		imageQuality    int
+		forceCover      bool
+		cacheDir        string
+		cacheTTL        time.Duration
+		timeout         time.Duration
+		downloadTimeout time.Duration
+		mediaType       string
+		results         int
+		overviewLines   int
+		placement       string
+		people          bool
+		maxWidth        int
+		background      string
+		backdrop        bool
+		reprocessCovers bool
+		autoSelect      string
+		includeAdult    bool
+		proxy           string
+		attachmentsDir  string
+		asciiFilenames  bool
+		minVotes        int
+		altTitles       bool
+		noSearchCache   bool
+		templateFolder  string
+		reportPath      string
+		overviewStyle   string
+		infoStyle       string
+		noEmoji         bool
+		replaceTags     bool
+		showChanges     bool
+		refreshMetadata bool
+		researchMissing bool
+		coverEncoding   string
+		shortMoney      bool
+		currencySymbol  string
+		insecure        bool
+		since           string
 	)
 
 	flag.BoolVar(&force, "force", false, "Force re-search even if TMDB ID is already stored")
 	flag.BoolVar(&force, "f", false, "Force re-search even if TMDB ID is already stored (shorthand)")
+	flag.BoolVar(&replaceTags, "replace-tags", false, "Replace existing movie/ and tv/ genre tags (and network/ and studio/ tags) instead of merging (other tags are kept)")
+	flag.BoolVar(&refreshMetadata, "force-refresh-metadata", false, "Re-fetch metadata for notes with a stored TMDB ID without searching or touching covers and content")
+	flag.BoolVar(&researchMissing, "research-missing", false, "Clear stored TMDB IDs that no longer exist on TMDB and search for those notes again")
+	flag.BoolVar(&forceCover, "force-cover", false, "Re-download covers even if the TMDB poster has not changed")
 	flag.BoolVar(&generateContent, "generate-content", false, "Generate TMDB content sections in note body")
 	flag.BoolVar(&generateContent, "g", false, "Generate TMDB content sections in note body (shorthand)")
-	flag.StringVar(&contentSections, "content-sections", "overview,info,seasons", "Comma-separated list of sections to generate")
+	flag.BoolVar(&updateContent, "update-content", false, "Regenerate content in notes that already have a TMDB content block")
+	flag.BoolVar(&updateContent, "reparse", false, "Regenerate existing TMDB content blocks (alias for --update-content)")
+	flag.StringVar(&contentSections, "content-sections", "", "Comma-separated list of sections to generate (default depends on type: overview,info,seasons for TV; overview,info for movies; overview,filmography for people; also available: title, collection, recommendations, seasons:episodes)")
+	flag.BoolVar(&strictSections, "strict-sections", false, "Exit with an error instead of a warning when --content-sections names an unknown section")
+	flag.StringVar(&placement, "content-placement", "bottom", "Where to insert a new content block: bottom, top, or after-h1")
+	flag.StringVar(&placement, "append-mode", "bottom", "Where to insert a new content block (alias for --content-placement)")
+	flag.StringVar(&overviewStyle, "overview-style", "plain", "How to render the overview section: plain, callout (collapsed Obsidian callout), or details (HTML <details>)")
+	flag.StringVar(&infoStyle, "info-style", "", "How to render the info section: table, list, or dataview (inline fields); default: info_style from the config file, else table")
+	flag.BoolVar(&noEmoji, "no-emoji", false, "Leave emoji (rating stars, country flags, status marks) out of generated content")
+	flag.BoolVar(&shortMoney, "short-money", false, "Abbreviate budget and revenue (e.g. $165M instead of $165,000,000)")
+	flag.StringVar(&currencySymbol, "currency-symbol", "$", "Symbol written before budget and revenue (TMDB reports US dollars; amounts are not converted)")
+	flag.BoolVar(&keywordTags, "keyword-tags", false, "Add TMDB keywords as keyword/... tags")
+	flag.StringVar(&studioTags, "studio-tags", "off", "Add the production company of movies as studio/... tags and the network of TV shows as network/... tags: off, first, or all")
+	flag.BoolVar(&includeAdult, "include-adult", false, "Include adult titles in search results")
+	flag.BoolVar(&people, "people", false, "Add movie directors / TV creators to a directors frontmatter list")
+	flag.StringVar(&region, "region", "US", "Country code (ISO 3166-1) used for content ratings in frontmatter and generated content")
+	flag.StringVar(&only, "only", "", "Comma-separated list of operations to apply: cover, metadata, tags, content (default: all)")
+	flag.BoolVar(&noProgress, "no-progress", false, "Disable the [n/total] progress counter")
+	flag.BoolVar(&verbose, "verbose", false, "Show per-note detail lines even when the progress counter is shown")
+	flag.BoolVar(&verbose, "v", false, "Show per-note detail lines (shorthand)")
+	flag.BoolVar(&showChanges, "diff", false, "Show the frontmatter keys added, changed, or removed in each note (implies --verbose)")
+	flag.StringVar(&imageFormat, "image-format", "jpeg", "Cover image format: jpeg, png, or webp (webp falls back to jpeg)")
+	flag.IntVar(&maxWidth, "max-width", 1000, "Maximum cover width in pixels; smaller posters are never enlarged (0 keeps the original size)")
+	flag.BoolVar(&backdrop, "backdrop", false, "Also download the wide backdrop image as a banner (frontmatter key: banner)")
+	flag.BoolVar(&reprocessCovers, "reprocess-covers", false, "Re-resize existing local covers to --max-width/--image-format without contacting TMDB")
+	flag.StringVar(&background, "poster-background", "#ffffff", "Background color (#rrggbb) for transparent posters saved as JPEG")
+	flag.IntVar(&imageQuality, "image-quality", 85, "JPEG quality for cover images (1-100)")
+	flag.StringVar(&cacheDir, "cache-dir", "", "Directory for caching TMDB detail responses (disabled when empty)")
+	flag.DurationVar(&cacheTTL, "cache-ttl", 24*time.Hour, "How long cached TMDB responses stay valid")
+	flag.StringVar(&proxy, "proxy", "", "Proxy URL for TMDB requests (default: HTTP_PROXY/HTTPS_PROXY environment variables)")
+	flag.BoolVar(&insecure, "insecure-skip-verify", false, "Skip TLS certificate verification (for proxies that intercept TLS)")
+	flag.DurationVar(&timeout, "timeout", 10*time.Second, "Timeout for each TMDB API request")
+	flag.DurationVar(&downloadTimeout, "download-timeout", 60*time.Second, "Timeout for each cover image download")
+	flag.IntVar(&results, "results", app.DefaultResults, fmt.Sprintf("Number of search results to fetch and show in the selector (1-%d)", app.MaxResults))
+	flag.IntVar(&overviewLines, "overview-lines", 1, fmt.Sprintf("Number of lines each overview may wrap across in the selector (1-%d)", tui.MaxOverviewLines))
+	flag.StringVar(&autoSelect, "auto", "", "Choose among several results without the selector: first, best (most popular), or skip")
+	flag.IntVar(&minVotes, "min-votes", 0, "Confirm a single search result in the selector (or skip it with --auto) when it has fewer TMDB votes than this")
+	flag.BoolVar(&altTitles, "alt-titles", false, "Retry searches without results using the note's aliases, and show alternative titles in the selector")
+	flag.BoolVar(&noSearchCache, "no-search-cache", false, "Repeat identical searches instead of reusing results from earlier in the run")
+	flag.StringVar(&reportPath, "report", "", "Write a JSON report of the run (per-note status, updates, errors, and match confidence) to this file")
+	flag.StringVar(&templateFolder, "template-folder", "Templates", "Skip notes in folders with this name (Obsidian templates); empty to process them. Notes with template: true, a template tag, or Templater <% %> syntax are always skipped")
+	flag.StringVar(&since, "since", "", "Only process notes modified within this duration (e.g. 36h, 7d) or since this date (2006-01-02)")
+	flag.StringVar(&mediaType, "media-type", "", "Only search for this media type: movie or tv (default: note's tmdb_type, else both)")
+	flag.StringVar(&attachmentsDir, "attachments-dir", "", "Directory for downloaded covers and banners (default: <vault>/attachments)")
+	flag.BoolVar(&asciiFilenames, "ascii-filenames", false, "Transliterate new cover and imported note file names to ASCII (e.g. Amélie -> Amelie; titles with no ASCII letters use the TMDB ID)")
+	flag.BoolVar(&showVersion, "version", false, "Print the version, commit, and build date and exit")
+	flag.StringVar(&configPath, "config", "", "Path to YAML config file (default: user config dir)")
+	flag.StringVar(&apiKeyFile, "api-key-file", "", "Read the TMDB API key from the first line of this file instead of TMDB_API_KEY")
+	flag.BoolVar(&useKeyring, "keyring", false, "Read the TMDB API key from the OS keyring (service "+keyringService+", account "+keyringAccount+") before falling back to TMDB_API_KEY")
+	flag.StringVar(&coverFormat, "cover-format", "path", "How to store the cover in frontmatter: path, wikilink, or filename")
+	flag.StringVar(&coverEncoding, "cover-encoding", "none", "How to write cover paths for picky sync tools: none, percent (URL-encode spaces and special characters), or quoted (YAML double quotes)")
 
 	flag.Usage = func() {
 		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] <path>\n", os.Args[0])
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "       %s import [options] <titles.csv|titles.json> <dir>\n", os.Args[0])
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "       %s watch [options] <vault>\n", os.Args[0])
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "       %s discover [options] <dir>\n", os.Args[0])
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "       %s check [--json] <path>\n", os.Args[0])
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "       %s clean-orphans [--delete] <vault>\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 
 	flag.Parse()
 
+	if showVersion {
+		printVersion()
+		return
+	}
+
 	args := flag.Args()
-	if len(args) == 0 {
+	if len(args) == 0 || (importMode && len(args) < 2) {
 		flag.Usage()
 		os.Exit(1)
 	}
 	inputPath := args[0]
+	if importMode {
+		inputPath = args[1]
+	}
 
-	apiKey := strings.TrimSpace(os.Getenv("TMDB_API_KEY"))
-	if apiKey == "" {
-		fmt.Println("Error: TMDB_API_KEY environment variable is not set")
-		fmt.Println("Please set your TMDB API key as an environment variable, e.g.:")
-		fmt.Println("  export TMDB_API_KEY=your_api_key_here")
+	fileCfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
 		os.Exit(1)
 	}
 
-	client := tmdb.NewClient(apiKey)
+	format, err := note.ParseCoverFormat(coverFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	encoding, err := note.ParseCoverEncoding(coverEncoding)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if maxWidth < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-width must be 0 or a positive number of pixels")
+		os.Exit(1)
+	}
+	if maxWidth == 0 {
+		maxWidth = tmdb.NoResize
+	}
+
+	bgColor, err := parseHexColor(background)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	contentPlacement, err := note.ParseContentPlacement(placement)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	overview, err := content.ParseOverviewStyle(overviewStyle)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if infoStyle == "" {
+		infoStyle = fileCfg.InfoStyle
+	}
+	companyTags, err := tmdb.ParseCompanyTags(studioTags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	info, err := content.ParseInfoStyle(infoStyle)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	imgFormat, err := tmdb.ParseImageFormat(imageFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if resolved, fallback := imgFormat.Resolve(); fallback {
+		fmt.Fprintf(os.Stderr, "Warning: %s encoding is not supported, saving covers as %s\n", imgFormat, resolved)
+	}
+
+	if reprocessCovers {
+		err := app.ReprocessCovers(app.ReprocessConfig{
+			Path:           inputPath,
+			AttachmentsDir: attachmentsDir,
+			KeyMap:         fileCfg.Keys,
+			Image: tmdb.ImageOptions{
+				MaxWidth:   maxWidth,
+				Format:     imgFormat,
+				Quality:    imageQuality,
+				Background: bgColor,
+			},
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	apiKey := requireAPIKey(apiKeyFile, useKeyring)
+
+	client := tmdb.NewClient(
+		apiKey,
+		tmdb.WithKeywordTags(keywordTags),
+		tmdb.WithCompanyTags(companyTags),
+		tmdb.WithPeople(people),
+		tmdb.WithIncludeAdult(includeAdult),
+		tmdb.WithRegion(region),
+		tmdb.WithResponseCache(cacheDir, cacheTTL),
+		tmdb.WithTimeout(timeout),
+		tmdb.WithDownloadTimeout(downloadTimeout),
+		tmdb.WithUserAgent(tmdb.DefaultUserAgent+"/"+version.Get().Version),
+		tmdb.WithPosterBackground(bgColor),
+		tmdb.WithProxy(proxy),
+		tmdb.WithInsecureSkipVerify(insecure),
+	)
+	if insecure {
+		fmt.Fprintln(os.Stderr, "Warning: TLS certificate verification is disabled")
+	}
 	cfg := app.Config{
-		Path:            inputPath,
-		Force:           force,
-		GenerateContent: generateContent,
+		Path:              inputPath,
+		Force:             force,
+		ForceCover:        forceCover,
+		ReplaceTags:       replaceTags,
+		ShowChanges:       showChanges,
+		RefreshMetadata:   refreshMetadata,
+		ResearchMissing:   researchMissing,
+		GenerateContent:   generateContent,
+		UpdateContent:     updateContent,
+		CoverFormat:       format,
+		CoverEncoding:     encoding,
+		AttachmentsDir:    attachmentsDir,
+		ASCIIFilenames:    asciiFilenames,
+		MinVotes:          minVotes,
+		AlternativeTitles: altTitles,
+		NoSearchCache:     noSearchCache,
+		TemplateFolder:    templateFolder,
+		OverviewStyle:     overview,
+		InfoStyle:         info,
+		NoEmoji:           noEmoji,
+		ShortMoney:        shortMoney,
+		CurrencySymbol:    currencySymbol,
+		Region:            region,
+		Image: tmdb.ImageOptions{
+			MaxWidth: maxWidth,
+			Format:   imgFormat,
+			Quality:  imageQuality,
+		},
+		KeyMap:              fileCfg.Keys,
+		ContentMarkerPrefix: fileCfg.ContentMarkerPrefix,
+		InlineFields:        fileCfg.InlineFields,
+		ContentPlacement:    contentPlacement,
+		Progress:            !noProgress && util.IsTerminal(os.Stdout),
+		Verbose:             verbose,
+		Backdrop:            backdrop,
+		Output:              os.Stdout,
+	}
+
+	if results < 1 || results > app.MaxResults {
+		fmt.Fprintf(os.Stderr, "Error: --results must be between 1 and %d\n", app.MaxResults)
+		os.Exit(1)
+	}
+	cfg.Results = results
+
+	if overviewLines < 1 || overviewLines > tui.MaxOverviewLines {
+		fmt.Fprintf(os.Stderr, "Error: --overview-lines must be between 1 and %d\n", tui.MaxOverviewLines)
+		os.Exit(1)
 	}
+	cfg.OverviewLines = overviewLines
 
-	if generateContent && strings.TrimSpace(contentSections) != "" {
+	cfg.AutoSelect, err = app.ParseAutoSelect(autoSelect)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if since != "" {
+		cfg.Since, err = parseSince(since, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	switch mediaType {
+	case "", "movie", "tv":
+		cfg.MediaType = mediaType
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid --media-type %q (expected movie or tv)\n", mediaType)
+		os.Exit(1)
+	}
+
+	if strings.TrimSpace(only) != "" {
+		cfg.Only = splitSections(only)
+		for _, op := range cfg.Only {
+			if !app.ValidOperation(op) {
+				fmt.Fprintf(os.Stderr, "Error: unknown operation in --only: %s\n", op)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if refreshMetadata {
+		switch {
+		case force:
+			fmt.Fprintln(os.Stderr, "Error: --force-refresh-metadata uses the stored TMDB ID and can't be combined with --force")
+			os.Exit(1)
+		case len(cfg.Only) > 0 && !slices.Contains(cfg.Only, app.OpMetadata) && !slices.Contains(cfg.Only, app.OpTags):
+			fmt.Fprintln(os.Stderr, "Error: --force-refresh-metadata needs --only to include metadata or tags")
+			os.Exit(1)
+		}
+	}
+
+	if strings.TrimSpace(contentSections) != "" {
 		cfg.ContentSections = splitSections(contentSections)
+		if unknown := content.UnknownSections(cfg.ContentSections); len(unknown) > 0 {
+			msg := fmt.Sprintf("unknown content section(s): %s (available: %s)",
+				strings.Join(unknown, ", "), strings.Join(content.Sections, ", "))
+			if strictSections {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+		}
 	}
 
 	runner := app.NewRunner(client, cfg)
-	if err := runner.Run(context.Background()); err != nil {
+	// Ctrl-C cancels the context so the current note finishes cleanly and
+	// the summary is still printed.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	run := runner.Run
+	switch {
+	case importMode:
+		run = func(ctx context.Context) (app.RunSummary, error) {
+			return runner.Import(ctx, args[0], inputPath)
+		}
+	case watchMode:
+		run = func(ctx context.Context) (app.RunSummary, error) {
+			return app.RunSummary{}, runner.Watch(ctx)
+		}
+	}
+	summary, err := run(ctx)
+	if !watchMode {
+		printSummary(summary, cfg.Since)
+	}
+	if reportPath != "" {
+		if reportErr := writeReport(reportPath, summary); reportErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write report: %v\n", reportErr)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			stop()
+			os.Exit(130)
+		}
+		if errors.Is(err, app.ErrUnauthorized) {
+			stop()
+			fmt.Fprintln(os.Stderr, "Error: TMDB rejected the API key; check TMDB_API_KEY, --api-key-file, or --keyring")
+			os.Exit(exitUnauthorized)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runDiscover implements the discover subcommand, which browses TMDB by
+// genre and year and writes stub notes for the chosen titles.
+func runDiscover(args []string) {
+	fs := flag.NewFlagSet("discover", flag.ExitOnError)
+	mediaType := fs.String("type", "movie", "Media type to browse: movie or tv")
+	genre := fs.String("genre", "", "Genre name, e.g. \"Science Fiction\" or science-fiction")
+	year := fs.Int("year", 0, "Release (movie) or first air (tv) year")
+	sortBy := fs.String("sort", "popularity.desc", "TMDB sort order, e.g. popularity.desc, vote_average.desc, primary_release_date.desc")
+	includeAdult := fs.Bool("include-adult", false, "Include adult titles in the results")
+	configPath := fs.String("config", "", "Path to YAML config file (default: user config dir)")
+	apiKeyFile := fs.String("api-key-file", "", "Read the TMDB API key from the first line of this file instead of TMDB_API_KEY")
+	useKeyring := fs.Bool("keyring", false, "Read the TMDB API key from the OS keyring before falling back to TMDB_API_KEY")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "Usage: %s discover [options] <dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *mediaType != "movie" && *mediaType != "tv" {
+		fmt.Fprintf(os.Stderr, "Error: invalid --type %q (expected movie or tv)\n", *mediaType)
+		os.Exit(1)
+	}
+
+	fileCfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := tmdb.NewClient(
+		requireAPIKey(*apiKeyFile, *useKeyring),
+		tmdb.WithUserAgent(tmdb.DefaultUserAgent+"/"+version.Get().Version),
+		tmdb.WithIncludeAdult(*includeAdult),
+	)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = app.Discover(ctx, client, app.DiscoverConfig{
+		Dir:       fs.Arg(0),
+		MediaType: *mediaType,
+		Genre:     *genre,
+		Year:      *year,
+		SortBy:    *sortBy,
+		KeyMap:    fileCfg.Keys,
+	})
+	if err != nil {
+		stop()
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseHexColor parses a "#rrggbb" color.
+func parseHexColor(value string) (color.Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("invalid color %q (expected #rrggbb)", value)
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color %q (expected #rrggbb)", value)
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}, nil
+}
+
+// printSummary prints the counts of a run.
+func printSummary(summary app.RunSummary, since time.Time) {
+	fmt.Println("\n=== Summary ===")
+	fmt.Printf("Processed: %d\n", summary.Processed)
+	fmt.Printf("Skipped: %d\n", summary.Skipped)
+	if summary.Unmodified > 0 {
+		fmt.Printf("Not modified since %s: %d\n", since.Format(time.DateTime), summary.Unmodified)
+	}
+	fmt.Printf("Failed: %d\n", summary.Failed)
+
+	if low := summary.LowConfidenceMatches(); len(low) > 0 {
+		fmt.Printf("\nLow-confidence matches to review (%d):\n", len(low))
+		for _, outcome := range low {
+			match := outcome.Match
+			fmt.Printf("  %s: %q → %s (%s, %s %d)\n", filepath.Base(outcome.Path), match.Query,
+				match.Title, match.Confidence, match.MediaType, match.TMDBID)
+		}
+	}
+}
+
+// writeReport writes the run summary to path as indented JSON.
+func writeReport(path string, summary app.RunSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// parseSince converts a --since value into a cutoff time. It accepts Go
+// durations, whole days such as "7d", and dates or RFC 3339 timestamps.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (expected a duration like 36h or 7d, or a date like 2006-01-02)", value)
+}
+
+// runCheck implements the check subcommand, which reports incomplete notes
+// without network access and exits 1 if any are found.
+func runCheck(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "Write the report as JSON")
+	templateFolder := fs.String("template-folder", "Templates", "Skip notes in folders with this name (Obsidian templates); empty to check them")
+	attachmentsDir := fs.String("attachments-dir", "", "Directory holding downloaded covers (default: <vault>/attachments)")
+	configPath := fs.String("config", "", "Path to YAML config file (default: user config dir)")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "Usage: %s check [options] <path>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	fileCfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	incomplete, err := app.Check(app.CheckConfig{
+		Path:           fs.Arg(0),
+		KeyMap:         fileCfg.Keys,
+		JSON:           *jsonOutput,
+		AttachmentsDir: *attachmentsDir,
+		TemplateFolder: *templateFolder,
+	}, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if incomplete > 0 {
+		os.Exit(1)
+	}
+}
+
+// runCleanOrphans implements the clean-orphans subcommand, which lists (or
+// with --delete removes) downloaded covers that no note references.
+func runCleanOrphans(args []string) {
+	fs := flag.NewFlagSet("clean-orphans", flag.ExitOnError)
+	deleteFiles := fs.Bool("delete", false, "Remove the orphaned images instead of only listing them")
+	attachmentsDir := fs.String("attachments-dir", "", "Directory holding downloaded covers (default: <vault>/attachments)")
+	configPath := fs.String("config", "", "Path to YAML config file (default: user config dir)")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "Usage: %s clean-orphans [options] <vault>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	fileCfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	_, err = app.CleanOrphans(app.CleanConfig{
+		Path:           fs.Arg(0),
+		KeyMap:         fileCfg.Keys,
+		AttachmentsDir: *attachmentsDir,
+		Delete:         *deleteFiles,
+	}, os.Stdout)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// printVersion implements the version subcommand and --version.
+func printVersion() {
+	fmt.Printf("obsidian-tmdb-cover %s\n", version.Get())
+}
+
+// requireAPIKey returns the TMDB API key from keyFile, the OS keyring (when
+// useKeyring is set), or the TMDB_API_KEY environment variable, in that
+// order, and exits when none has one.
+func requireAPIKey(keyFile string, useKeyring bool) string {
+	if keyFile != "" {
+		apiKey, err := util.ReadSecretFile(keyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to read --api-key-file: %v\n", err)
+			os.Exit(1)
+		}
+		return apiKey
+	}
+	if useKeyring {
+		apiKey, err := util.KeyringSecret(keyringService, keyringAccount)
+		if err == nil {
+			return apiKey
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v; falling back to TMDB_API_KEY\n", err)
+	}
+	apiKey := strings.TrimSpace(os.Getenv("TMDB_API_KEY"))
+	if apiKey == "" {
+		fmt.Println("Error: TMDB_API_KEY environment variable is not set")
+		fmt.Println("Please set your TMDB API key as an environment variable, e.g.:")
+		fmt.Println("  export TMDB_API_KEY=your_api_key_here")
+		fmt.Println("or keep it out of the environment with --api-key-file or --keyring")
+		os.Exit(1)
+	}
+	return apiKey
+}
+
 func splitSections(value string) []string {
 	parts := strings.Split(value, ",")
 	sections := make([]string, 0, len(parts))
@@ -75,3 +701,14 @@ func splitSections(value string) []string {
 	}
 	return sections
 }
+
+func loadConfig(path string) (config.File, error) {
+	if path != "" {
+		return config.Load(path, false)
+	}
+	defaultPath, err := config.DefaultPath()
+	if err != nil {
+		return config.Default(), nil
+	}
+	return config.Load(defaultPath, true)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"7d", time.Date(2024, time.March, 3, 12, 0, 0, 0, time.UTC), false},
+		{" 0d ", now, false},
+		{"36h", now.Add(-36 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"2024-01-31", time.Date(2024, time.January, 31, 0, 0, 0, 0, time.Local), false},
+		{"2024-01-31T08:30:00Z", time.Date(2024, time.January, 31, 8, 30, 0, 0, time.UTC), false},
+		{"-7d", time.Time{}, true},
+		{"-2h", time.Time{}, true},
+		{"xd", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseSince(%q): error = %v, want error %v", tt.value, err, tt.wantErr)
+		}
+		if !got.Equal(tt.want) {
+			t.Fatalf("parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lepinkainen/obsidian-tmdb-cover/internal/content"
 	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
@@ -16,44 +20,279 @@ import (
 	"github.com/lepinkainen/obsidian-tmdb-cover/internal/util"
 )
 
+const (
+	// recommendationLimit caps how many titles the recommendations section lists.
+	recommendationLimit = 10
+	// maxSeasonFetches caps the per-season requests made for episode lists.
+	maxSeasonFetches = 20
+)
+
+// Search result limits for Config.Results.
+const (
+	// DefaultResults is the number of search candidates shown by default.
+	DefaultResults = 10
+	// MaxResults is the most results a single TMDB search page returns.
+	MaxResults = 20
+)
+
+// Operations that can be selected with Config.Only.
+const (
+	OpCover    = "cover"
+	OpMetadata = "metadata"
+	OpTags     = "tags"
+	OpContent  = "content"
+)
+
+// ValidOperation reports whether op is a known operation name.
+func ValidOperation(op string) bool {
+	switch op {
+	case OpCover, OpMetadata, OpTags, OpContent:
+		return true
+	default:
+		return false
+	}
+}
+
 // ErrStopProcessing is returned when the user requests to stop processing via the TUI.
 var ErrStopProcessing = errors.New("processing stopped by user")
 
+// ErrUnauthorized is returned when TMDB rejects the API key. The run is
+// aborted at the first occurrence since no note could succeed.
+var ErrUnauthorized = errors.New("invalid or expired TMDB API key")
+
+// interactive and selectResult reach the terminal selector; tests replace
+// them. selectResult highlights the first result of preferType, the media
+// type the user picked last, when it is set.
+var (
+	interactive  = tui.Interactive
+	selectResult = func(title string, results []tmdb.SearchResult, preferType string, opts ...tui.Option) (tui.SelectionResult, error) {
+		if preferType != "" {
+			opts = append(opts, tui.WithPreferredType(preferType))
+		}
+		return tui.Select(title, results, opts...)
+	}
+)
+
 // Config holds the application configuration.
 type Config struct {
-	Path            string
-	Force           bool
+	Path  string
+	Force bool
+	// ForceCover re-downloads covers even when the stored cover_source
+	// matches the current TMDB poster.
+	ForceCover bool
+	// RefreshMetadata re-fetches and rewrites the metadata of notes with a
+	// stored TMDB ID, leaving covers and content alone. Notes without an ID
+	// are skipped rather than searched. Only still limits it to metadata or
+	// tags.
+	RefreshMetadata bool
+	// ResearchMissing clears a stored TMDB ID that TMDB no longer knows
+	// (deleted or merged entries) and searches for the note again. Without
+	// it, such notes are reported.
+	ResearchMissing bool
+	// ShowChanges prints the frontmatter keys each note's update added,
+	// changed, or removed. It implies Verbose.
+	ShowChanges bool
+	// ReplaceTags drops existing movie/ and tv/ genre tags before adding
+	// the current ones, e.g. after fixing a wrong match with Force.
+	ReplaceTags     bool
 	GenerateContent bool
+	// UpdateContent regenerates content for notes that already have a TMDB
+	// content block, using their stored TMDB ID.
+	UpdateContent   bool
 	ContentSections []string
+	CoverFormat     note.CoverFormat
+	// CoverEncoding percent-encodes or quotes stored cover and banner paths.
+	CoverEncoding note.CoverEncoding
+	// Image controls the size, format, and quality of downloaded covers.
+	Image  tmdb.ImageOptions
+	KeyMap note.KeyMap
+	// ContentMarkerPrefix names the content block markers, e.g. "TMDB_DATA"
+	// produces <!-- TMDB_DATA_START --> and <!-- TMDB_DATA_END -->.
+	ContentMarkerPrefix string
+	// InlineFields selects metadata written as Dataview inline fields in
+	// the content block.
+	InlineFields note.InlineFields
+	// ContentPlacement controls where a new content block is inserted.
+	ContentPlacement note.ContentPlacement
+	// OverviewStyle controls how the overview section is rendered.
+	OverviewStyle content.OverviewStyle
+	// InfoStyle controls how the info section is rendered.
+	InfoStyle content.InfoStyle
+	// NoEmoji leaves emoji out of generated content.
+	NoEmoji bool
+	// ShortMoney abbreviates budget and revenue ("$165M").
+	ShortMoney bool
+	// CurrencySymbol is written before budget and revenue; "$" when empty.
+	CurrencySymbol string
+	// Region is the ISO 3166-1 country whose content rating is written to
+	// generated content; "US" when empty.
+	Region string
+	// Progress prints a compact "[n/total]" counter per file. Unless Verbose
+	// is also set, per-note detail lines are hidden while it is enabled.
+	Progress bool
+	Verbose  bool
+	// Only restricts processing to the named operations (see Op* constants).
+	// An empty list allows all operations.
+	Only []string
+	// Results is the number of search candidates fetched and shown in the
+	// selector (1-20). Zero uses DefaultResults.
+	Results int
+	// OverviewLines is how many lines an overview may wrap across in the
+	// selector. Zero keeps the one-line default.
+	OverviewLines int
+	// MediaType restricts searches to "movie" or "tv". When empty, a note's
+	// tmdb_type frontmatter constrains the search instead.
+	MediaType string
+	// Backdrop also downloads the wide backdrop image as a banner.
+	Backdrop bool
+	// AutoSelect picks among several search results without showing the
+	// selector. The zero value keeps the interactive selector.
+	AutoSelect AutoSelect
+	// Since skips notes last modified before it. The zero value processes
+	// every note.
+	Since time.Time
+	// AttachmentsDir is where covers and banners are stored. When empty,
+	// the attachments folder inside the vault is used.
+	AttachmentsDir string
+	// ASCIIFilenames transliterates new cover, banner, and imported note
+	// file names to ASCII. Existing files keep their names.
+	ASCIIFilenames bool
+	// NoSearchCache repeats identical searches instead of reusing the
+	// results from earlier in the run.
+	NoSearchCache bool
+	// TemplateFolder names the vault folder holding Obsidian templates;
+	// notes in a folder of that name (at any depth below Path) are skipped.
+	// Empty turns the folder check off.
+	TemplateFolder string
+	// AlternativeTitles retries a search without results using the note's
+	// aliases and shows TMDB's alternative titles in the selector.
+	AlternativeTitles bool
+	// MinVotes is the vote count a lone movie or TV result needs to be
+	// accepted without confirmation. Zero accepts every lone result.
+	MinVotes int
+	// Output receives progress and per-note messages. Nil keeps the
+	// runner silent; the results are in the returned RunSummary either way.
+	Output io.Writer
+}
+
+// AutoSelect is a policy for choosing a search result non-interactively.
+type AutoSelect string
+
+const (
+	// AutoSelectFirst picks TMDB's top-ranked result.
+	AutoSelectFirst AutoSelect = "first"
+	// AutoSelectBest picks the most popular result, breaking ties by vote
+	// average.
+	AutoSelectBest AutoSelect = "best"
+	// AutoSelectSkip skips notes with more than one result.
+	AutoSelectSkip AutoSelect = "skip"
+)
+
+// ParseAutoSelect converts a string into an AutoSelect policy. An empty
+// string disables automatic selection.
+func ParseAutoSelect(value string) (AutoSelect, error) {
+	switch policy := AutoSelect(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "", AutoSelectFirst, AutoSelectBest, AutoSelectSkip:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown auto-select policy: %q (expected first, best, or skip)", value)
+	}
 }
 
 // Runner coordinates the note processing workflow.
 type Runner struct {
 	client *tmdb.Client
 	cfg    Config
+	out    io.Writer
+	// lastMediaType is the media type the user picked most recently; the
+	// selector highlights the first result of that type.
+	lastMediaType string
+	// searches caches search results for the run; nil when disabled.
+	searches *searchCache
+	// inapplicable holds the "section/media type" pairs already reported
+	// as not applicable, so each is only warned about once per run.
+	inapplicable map[string]bool
+}
+
+// RunSummary collects the results of a run.
+type RunSummary struct {
+	Processed int `json:"processed"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+	// Unmodified counts notes left out by Config.Since.
+	Unmodified int `json:"unmodified"`
+	// Outcomes holds one entry per note that was looked at, in order.
+	Outcomes []Outcome `json:"outcomes"`
 }
 
 // NewRunner creates a new Runner with the given TMDB client and configuration.
 func NewRunner(client *tmdb.Client, cfg Config) *Runner {
-	return &Runner{
-		client: client,
-		cfg:    cfg,
+	runner := &Runner{
+		client:       client,
+		cfg:          cfg,
+		out:          cfg.Output,
+		inapplicable: make(map[string]bool),
+	}
+	if runner.out == nil {
+		runner.out = io.Discard
 	}
+	if !cfg.NoSearchCache {
+		runner.searches = newSearchCache()
+	}
+	return runner
 }
 
-// Run executes the main application logic.
-func (r *Runner) Run(ctx context.Context) error {
-	info, err := os.Stat(r.cfg.Path)
+// Run processes every note under Config.Path and returns what happened to
+// them. The summary is valid even when an error ends the run early.
+func (r *Runner) Run(ctx context.Context) (RunSummary, error) {
+	files, vaultPath, err := collectNotes(r.cfg.Path)
 	if err != nil {
-		return err
+		return RunSummary{}, err
+	}
+	if vaultPath == r.cfg.Path {
+		r.printf("Found %d markdown files\n", len(files))
+	} else {
+		r.printf("Processing single file: %s\n", filepath.Base(r.cfg.Path))
+	}
+	unmodified := 0
+	if !r.cfg.Since.IsZero() {
+		files, unmodified = modifiedSince(files, r.cfg.Since)
+		r.printf("%d modified since %s\n", len(files), r.cfg.Since.Format(time.DateTime))
+	}
+	summary, err := r.processFiles(ctx, files, vaultPath)
+	summary.Unmodified = unmodified
+	return summary, err
+}
+
+// modifiedSince keeps the files modified at or after since and returns how
+// many were left out. Files that can't be stated are kept so the error
+// surfaces when they are loaded.
+func modifiedSince(files []string, since time.Time) ([]string, int) {
+	kept := files[:0]
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err == nil && info.ModTime().Before(since) {
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept, len(files) - len(kept)
+}
+
+// collectNotes returns the markdown files under path (or path itself when it
+// is a single note) and the vault directory covers are stored relative to.
+func collectNotes(root string) ([]string, string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, "", err
 	}
 
 	var files []string
 	var vaultPath string
 
 	if info.IsDir() {
-		vaultPath = r.cfg.Path
-		err = filepath.WalkDir(r.cfg.Path, func(path string, d os.DirEntry, err error) error {
+		vaultPath = root
+		err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
@@ -66,128 +305,354 @@ func (r *Runner) Run(ctx context.Context) error {
 			return nil
 		})
 		if err != nil {
-			return err
+			return nil, "", err
 		}
-		fmt.Printf("Found %d markdown files\n", len(files))
 		if len(files) == 0 {
-			return errors.New("no markdown files found in the directory")
+			return nil, "", errors.New("no markdown files found in the directory")
 		}
 	} else {
-		if !strings.EqualFold(filepath.Ext(r.cfg.Path), ".md") {
-			return fmt.Errorf("file is not a markdown file: %s", r.cfg.Path)
+		if !strings.EqualFold(filepath.Ext(root), ".md") {
+			return nil, "", fmt.Errorf("file is not a markdown file: %s", root)
+		}
+		files = []string{root}
+		vaultPath = filepath.Dir(root)
+	}
+
+	return files, vaultPath, nil
+}
+
+// templateReason reports why n looks like an Obsidian template, or "" for a
+// regular note. Notes in a folder named templateFolder below root count as
+// templates. Searching for a template's title only turns up garbage.
+func templateReason(n *note.Note, root, templateFolder string) string {
+	if templateFolder != "" {
+		if rel, err := filepath.Rel(root, filepath.Dir(n.Path)); err == nil {
+			for _, dir := range strings.Split(filepath.ToSlash(rel), "/") {
+				if strings.EqualFold(dir, templateFolder) {
+					return "in the " + dir + " folder"
+				}
+			}
 		}
-		files = []string{r.cfg.Path}
-		vaultPath = filepath.Dir(r.cfg.Path)
-		fmt.Printf("Processing single file: %s\n", filepath.Base(r.cfg.Path))
 	}
+	return n.TemplateReason()
+}
 
-	attachmentsDir := filepath.Join(vaultPath, "attachments")
+// resolveAttachmentsDir returns the configured attachments directory, or the
+// attachments folder inside vaultPath.
+func resolveAttachmentsDir(vaultPath, configured string) string {
+	if configured != "" {
+		return configured
+	}
+	return filepath.Join(vaultPath, "attachments")
+}
+
+// processFiles runs the cover, metadata, and content pipeline over files,
+// storing covers in the attachments directory.
+func (r *Runner) processFiles(ctx context.Context, files []string, vaultPath string) (RunSummary, error) {
+	var summary RunSummary
+	attachmentsDir := resolveAttachmentsDir(vaultPath, r.cfg.AttachmentsDir)
 	if err := util.EnsureDir(attachmentsDir); err != nil {
-		return fmt.Errorf("create attachments dir: %w", err)
+		return summary, fmt.Errorf("create attachments dir: %w", err)
 	}
 
-	var (
-		processed int
-		skipped   int
-		failed    int
-	)
+	// abortErr ends the run early with an error, e.g. on a 401
+	var abortErr error
 
-	for _, file := range files {
-		fmt.Printf("\nProcessing: %s\n", filepath.Base(file))
-		n, err := note.Load(file)
-		if err != nil {
-			fmt.Printf("  ✗ Failed to read note: %v\n", err)
-			failed++
-			continue
+loop:
+	for i, file := range files {
+		if ctx.Err() != nil {
+			r.printf("\n⚠️  Processing interrupted\n")
+			break
+		}
+		if r.cfg.Progress {
+			r.printf("[%d/%d] %s\n", i+1, len(files), filepath.Base(file))
+		} else {
+			r.printf("\nProcessing: %s\n", filepath.Base(file))
 		}
-		title := n.GetTitle()
-		fmt.Printf("  Title: %s\n", title)
-
-		needsCover := n.NeedsCover()
-		needsMetadata := n.NeedsMetadata()
-		needsTMDB := n.NeedsTMDB()
 
-		if !needsCover && !needsMetadata && !needsTMDB && !r.cfg.Force && !r.cfg.GenerateContent {
-			fmt.Println("  Already has cover, metadata, and TMDB ID, skipping...")
-			skipped++
+		outcome, err := r.ProcessFile(ctx, file, attachmentsDir)
+		r.printOutcome(outcome)
+		summary.Outcomes = append(summary.Outcomes, outcome)
+		switch outcome.Status {
+		case OutcomeProcessed:
+			summary.Processed++
+		case OutcomeSkipped:
+			summary.Skipped++
+		case OutcomeFailed:
+			summary.Failed++
+		}
+		switch {
+		case err == nil:
 			continue
+		case errors.Is(err, ErrStopProcessing):
+			r.printf("\n⚠️  Processing stopped by user\n")
+		case errors.Is(err, ErrUnauthorized):
+			abortErr = err
+			r.printf("\n✗ TMDB rejected the API key, aborting\n")
+		default:
+			r.printf("\n⚠️  Processing interrupted\n")
 		}
+		break loop
+	}
 
-		coverURL, meta, err := r.fetchRequiredData(ctx, n, title, needsCover, needsMetadata, needsTMDB)
-		if err != nil {
-			if errors.Is(err, ErrStopProcessing) {
-				fmt.Println("\n⚠️  Processing stopped by user")
-				break
+	if abortErr != nil {
+		return summary, abortErr
+	}
+	return summary, ctx.Err()
+}
+
+// OutcomeStatus is how a note counts in the run summary.
+type OutcomeStatus string
+
+const (
+	// OutcomeNone is not counted, e.g. when the run was stopped mid-note.
+	OutcomeNone      OutcomeStatus = ""
+	OutcomeProcessed OutcomeStatus = "processed"
+	OutcomeSkipped   OutcomeStatus = "skipped"
+	OutcomeFailed    OutcomeStatus = "failed"
+)
+
+// Outcome describes what processing did to a single note.
+type Outcome struct {
+	Path   string        `json:"path"`
+	Status OutcomeStatus `json:"status"`
+	// Updated lists the parts of the note that were written: "cover",
+	// "metadata", "banner", and/or "content".
+	Updated []string `json:"updated,omitempty"`
+	// Errors holds per-note problems. A note can have errors and still
+	// count as processed when another part was updated.
+	Errors []string `json:"errors,omitempty"`
+	// Warnings holds non-fatal notices such as unknown directives.
+	Warnings []string `json:"warnings,omitempty"`
+	// Match is the search result the note was matched to; nil when the
+	// note wasn't searched.
+	Match *Match `json:"match,omitempty"`
+}
+
+func (o *Outcome) errorf(format string, args ...any) {
+	o.Errors = append(o.Errors, fmt.Sprintf(format, args...))
+}
+
+// ProcessFile runs the cover, metadata, and content pipeline on one note,
+// storing covers in attachmentsDir. Per-note problems are reported in the
+// Outcome; the error is reserved for conditions that should end a longer
+// run: ErrStopProcessing, ErrUnauthorized, and context cancellation.
+func (r *Runner) ProcessFile(ctx context.Context, file, attachmentsDir string) (Outcome, error) {
+	outcome := Outcome{Path: file, Status: OutcomeFailed}
+	n, err := note.LoadWithKeyMap(file, r.cfg.KeyMap)
+	if errors.Is(err, note.ErrMalformedFrontmatter) {
+		outcome.errorf("Skipping: %v", err)
+		return outcome, nil
+	}
+	if err != nil {
+		outcome.errorf("Failed to read note: %v", err)
+		return outcome, nil
+	}
+	if reason := templateReason(n, r.cfg.Path, r.cfg.TemplateFolder); reason != "" {
+		r.detailf("  Skipping template note (%s)\n", reason)
+		outcome.Status = OutcomeSkipped
+		return outcome, nil
+	}
+	for _, field := range n.Overrides().Unknown {
+		outcome.Warnings = append(outcome.Warnings, "Ignoring unknown tmdb directive: "+field)
+	}
+	for _, section := range content.UnknownSections(n.Overrides().Sections) {
+		outcome.Warnings = append(outcome.Warnings, "Ignoring unknown content section: "+section)
+	}
+	n.SetMarkers(note.MarkersWithPrefix(r.cfg.ContentMarkerPrefix))
+	n.SetPlacement(r.cfg.ContentPlacement)
+	n.SetInlineFields(r.cfg.InlineFields)
+	n.SetCoverEncoding(r.cfg.CoverEncoding)
+	n.SetASCIIFilenames(r.cfg.ASCIIFilenames)
+	if r.cfg.ShowChanges {
+		before := n.Snapshot()
+		defer func() {
+			for _, change := range note.DiffFrontmatter(before, n.Frontmatter()) {
+				r.detailf("  %s\n", change)
 			}
-			fmt.Printf("  ✗ Error fetching TMDB data: %v\n", err)
-			failed++
-			continue
+		}()
+	}
+	title := n.GetTitle()
+	r.detailf("  Title: %s\n", title)
+	query := n.GetSearchQuery()
+	if query != title {
+		r.detailf("  Search query: %s\n", query)
+	}
+
+	needsCover := n.NeedsCover()
+	if !needsCover {
+		if _, ok := n.ResolveLocalCover(filepath.Dir(file), attachmentsDir); !ok {
+			r.detailf("  Local cover file is missing, will download again\n")
+			needsCover = true
 		}
+	}
+	needsMetadata := n.NeedsMetadata()
+	needsTMDB := n.NeedsTMDB()
+	needsBanner := r.cfg.Backdrop && r.allows(OpCover) && (!n.HasBanner(filepath.Dir(file), attachmentsDir) || r.cfg.ForceCover)
 
-		success := false
+	if r.cfg.ForceCover {
+		needsCover = true
+	}
+	needsCover = needsCover && r.allows(OpCover)
+	needsMetadata = needsMetadata && (r.allows(OpMetadata) || r.allows(OpTags))
+	needsTMDB = needsTMDB && r.allows(OpMetadata)
+	generate := r.shouldGenerateContent()
+	if !generate && r.cfg.UpdateContent && r.allows(OpContent) && n.HasTMDBContentMarkers() {
+		r.detailf("  Refreshing existing content block\n")
+		generate = true
+	}
+	if r.cfg.RefreshMetadata {
+		// metadata only, looked up by the stored ID; never search
+		_, hasID := n.GetTMDBID()
+		_, hasType := n.GetTMDBType()
+		if !hasID || !hasType {
+			r.detailf("  No stored TMDB ID, skipping metadata refresh\n")
+			outcome.Status = OutcomeSkipped
+			return outcome, nil
+		}
+		r.detailf("  Refreshing metadata\n")
+		needsCover, needsBanner, needsTMDB, generate = false, false, false, false
+		needsMetadata = r.wantsMetadata()
+	}
 
-		if coverURL != "" {
-			if err := r.updateCover(ctx, n, coverURL, attachmentsDir); err != nil {
-				fmt.Printf("  ✗ %v\n", err)
-			} else {
-				success = true
-			}
-		} else if needsCover {
-			fmt.Println("  ✗ No cover image found")
+	if !needsCover && !needsMetadata && !needsTMDB && !needsBanner && !r.cfg.Force && !generate {
+		r.detailf("  Already has cover, metadata, and TMDB ID, skipping...\n")
+		outcome.Status = OutcomeSkipped
+		return outcome, nil
+	}
+
+	coverURL, meta, err := r.fetchRequiredData(ctx, n, &outcome, query, needsCover, needsMetadata, needsTMDB)
+	if staleID, ok := r.staleTMDBID(n, err); ok {
+		if !r.cfg.ResearchMissing || r.cfg.RefreshMetadata {
+			outcome.errorf("Stored TMDB ID %d no longer exists on TMDB (use --research-missing to search again)", staleID)
+			return outcome, nil
+		}
+		r.detailf("  Stored TMDB ID %d no longer exists on TMDB, searching again\n", staleID)
+		if err := n.ClearTMDBID(); err != nil {
+			outcome.errorf("Failed to clear stale TMDB ID: %v", err)
+			return outcome, nil
+		}
+		coverURL, meta, err = r.fetchRequiredData(ctx, n, &outcome, query, needsCover, needsMetadata, true)
+	}
+	if err != nil {
+		if errors.Is(err, ErrStopProcessing) {
+			outcome.Status = OutcomeNone
+			return outcome, err
+		}
+		if ctx.Err() != nil {
+			outcome.Status = OutcomeNone
+			return outcome, ctx.Err()
 		}
+		if tmdb.IsUnauthorized(err) {
+			return unauthorized(outcome, err)
+		}
+		outcome.errorf("Error fetching TMDB data: %v", err)
+		return outcome, nil
+	}
 
-		if meta != nil {
-			if err := n.UpdateMetadata(r.toNoteMetadata(meta)); err != nil {
-				fmt.Printf("  ✗ Failed to update metadata: %v\n", err)
-			} else {
-				if meta.Runtime != nil {
-					fmt.Printf("  ✓ Added runtime: %d minutes\n", *meta.Runtime)
-				}
-				if meta.TotalEpisodes != nil {
-					fmt.Printf("  ✓ Added total episodes: %d\n", *meta.TotalEpisodes)
-				}
-				if len(meta.GenreTags) > 0 {
-					fmt.Printf("  ✓ Added genres: %s\n", strings.Join(meta.GenreTags, ", "))
-				}
-				if !needsCover {
-					success = true
-				}
-			}
-		} else if needsMetadata {
-			fmt.Println("  ✗ No metadata found")
+	success := false
+
+	if coverURL != "" && !r.allows(OpCover) {
+		coverURL = ""
+	}
+	if coverURL != "" && !needsCover && r.coverUnchanged(n, coverURL, attachmentsDir) {
+		r.detailf("  Cover unchanged since last download, skipping\n")
+		coverURL = ""
+	}
+
+	if coverURL != "" {
+		if err := r.updateCover(ctx, n, coverURL, attachmentsDir, r.coverID(n, meta)); err != nil {
+			outcome.errorf("%v", err)
+		} else {
+			success = true
+			outcome.Updated = append(outcome.Updated, "cover")
 		}
+	} else if needsCover {
+		outcome.errorf("No cover image found")
+	}
 
-		if r.cfg.GenerateContent {
-			if err := r.generateContent(ctx, n); err != nil {
-				fmt.Printf("  ✗ Failed to generate content: %v\n", err)
-			} else {
+	if meta != nil {
+		noteMeta := r.toNoteMetadata(meta)
+		if err := n.UpdateMetadata(noteMeta); err != nil {
+			outcome.errorf("Failed to update metadata: %v", err)
+		} else {
+			outcome.Updated = append(outcome.Updated, "metadata")
+			if noteMeta.Runtime != nil {
+				r.detailf("  ✓ Added runtime: %d minutes\n", *noteMeta.Runtime)
+			}
+			if noteMeta.TotalEpisodes != nil {
+				r.detailf("  ✓ Added total episodes: %d\n", *noteMeta.TotalEpisodes)
+			}
+			if len(noteMeta.GenreTags) > 0 {
+				r.detailf("  ✓ Added genres: %s\n", strings.Join(noteMeta.GenreTags, ", "))
+			}
+			if noteMeta.ContentRating != nil {
+				r.detailf("  ✓ Added content rating: %s\n", *noteMeta.ContentRating)
+			}
+			if noteMeta.Status != nil {
+				r.detailf("  ✓ Added status: %s\n", *noteMeta.Status)
+			}
+			if !needsCover {
 				success = true
 			}
 		}
+	} else if needsMetadata {
+		outcome.errorf("No metadata found")
+	}
 
+	if needsBanner {
+		updated, err := r.updateBanner(ctx, n, attachmentsDir)
 		switch {
-		case success:
-			processed++
-		case coverURL != "" && !needsMetadata:
-			processed++
-		case meta != nil && !needsCover:
-			processed++
+		case tmdb.IsUnauthorized(err):
+			return unauthorized(outcome, err)
+		case err != nil:
+			outcome.errorf("%v", err)
 		default:
-			failed++
+			success = true
+			if updated {
+				outcome.Updated = append(outcome.Updated, "banner")
+			}
+		}
+	}
+
+	if generate {
+		if err := r.generateContent(ctx, n, &outcome); tmdb.IsUnauthorized(err) {
+			return unauthorized(outcome, err)
+		} else if err != nil {
+			outcome.errorf("Failed to generate content: %v", err)
+		} else {
+			success = true
+			outcome.Updated = append(outcome.Updated, "content")
 		}
 	}
 
-	fmt.Println("\n=== Summary ===")
-	fmt.Printf("Processed: %d\n", processed)
-	fmt.Printf("Skipped: %d\n", skipped)
-	fmt.Printf("Failed: %d\n", failed)
+	switch {
+	case success:
+		outcome.Status = OutcomeProcessed
+	case coverURL != "" && !needsMetadata:
+		outcome.Status = OutcomeProcessed
+	case meta != nil && !needsCover:
+		outcome.Status = OutcomeProcessed
+	}
+	return outcome, nil
+}
 
-	return nil
+// unauthorized ends processing of a note after TMDB rejected the API key.
+// The note counts as failed no matter which step hit the 401, even when
+// earlier steps already updated it.
+func unauthorized(outcome Outcome, err error) (Outcome, error) {
+	outcome.Status = OutcomeFailed
+	outcome.errorf("%v", err)
+	return outcome, fmt.Errorf("%w: %v", ErrUnauthorized, err)
 }
 
+// fetchRequiredData returns the cover URL and metadata for n, from its
+// stored TMDB ID or by searching for title. A search match is recorded in
+// outcome.
 func (r *Runner) fetchRequiredData(
 	ctx context.Context,
 	n *note.Note,
+	outcome *Outcome,
 	title string,
 	needsCover, needsMetadata, needsTMDB bool,
 ) (string, *tmdb.Metadata, error) {
@@ -199,16 +664,33 @@ func (r *Runner) fetchRequiredData(
 	}
 
 	if hasStoredID && !r.cfg.Force {
-		fmt.Printf("  Using stored TMDB ID: %d (%s)\n", tmdbID, tmdbType)
+		r.detailf("  Using stored TMDB ID: %d (%s)\n", tmdbID, tmdbType)
 		if !needsCover && !needsMetadata && !needsTMDB {
 			return "", nil, nil
 		}
+		if season, episode, ok := n.GetEpisode(); ok {
+			return r.fetchEpisodeData(ctx, n, tmdbID, season, episode, needsCover, needsMetadata || needsTMDB)
+		}
+		if needsCover && tmdbType == "tv" && !n.HasExternalCover() {
+			posterPath, err := r.seasonPosterPath(ctx, n, tmdbID)
+			if err != nil {
+				return "", nil, err
+			}
+			if posterPath != "" {
+				cover := r.client.ImageURL(posterPath)
+				if !needsMetadata && !needsTMDB {
+					return cover, nil, nil
+				}
+				meta, err := r.client.GetMetadataByID(ctx, tmdbID, tmdbType)
+				return cover, meta, err
+			}
+		}
 
 		switch {
 		case needsCover && needsMetadata:
 			if n.HasExternalCover() {
 				if existing, ok := n.GetExistingCoverURL(); ok {
-					fmt.Println("  Found external cover URL, will download locally")
+					r.detailf("  Found external cover URL, will download locally\n")
 					meta, err := r.client.GetMetadataByID(ctx, tmdbID, tmdbType)
 					return existing, meta, err
 				}
@@ -217,7 +699,10 @@ func (r *Runner) fetchRequiredData(
 		case needsCover:
 			if n.HasExternalCover() {
 				if existing, ok := n.GetExistingCoverURL(); ok {
-					fmt.Println("  Found external cover URL, will download locally")
+					r.detailf("  Found external cover URL, will download locally\n")
+					if !r.wantsMetadata() {
+						return existing, nil, nil
+					}
 					meta, err := r.client.GetMetadataByID(ctx, tmdbID, tmdbType)
 					return existing, meta, err
 				}
@@ -226,6 +711,9 @@ func (r *Runner) fetchRequiredData(
 			if err != nil {
 				return "", nil, err
 			}
+			if !r.wantsMetadata() {
+				return cover, nil, nil
+			}
 			meta, err := r.client.GetMetadataByID(ctx, tmdbID, tmdbType)
 			return cover, meta, err
 		case needsMetadata, needsTMDB:
@@ -237,32 +725,87 @@ func (r *Runner) fetchRequiredData(
 	}
 
 	if r.cfg.Force && hasStoredID {
-		fmt.Printf("  Force mode: ignoring stored TMDB ID %d (%s)\n", tmdbID, tmdbType)
+		r.detailf("  Force mode: ignoring stored TMDB ID %d (%s)\n", tmdbID, tmdbType)
+	}
+
+	searchType := r.cfg.MediaType
+	if searchType == "" && hasType {
+		searchType = tmdbType
 	}
 
-	results, err := r.client.SearchMulti(ctx, title, 10)
+	if searchType != "" && searchType != "person" {
+		r.detailf("  Searching %s only\n", mapMediaType(searchType))
+	}
+	results, hasMore, pager, err := r.search(ctx, title, searchType)
 	if err != nil {
 		return "", nil, err
 	}
+	if len(results) == 0 && r.cfg.AlternativeTitles {
+		// a note titled in the user's language may be listed under its
+		// aliases on TMDB
+		for _, alias := range n.Aliases() {
+			if strings.EqualFold(alias, title) {
+				continue
+			}
+			r.detailf("  No results, trying alias %q\n", alias)
+			results, hasMore, pager, err = r.search(ctx, alias, searchType)
+			if err != nil {
+				return "", nil, err
+			}
+			if len(results) > 0 {
+				title = alias
+				break
+			}
+		}
+	}
 	if len(results) == 0 {
-		fmt.Println("  No results found")
+		r.printf("  No results found\n")
 		return "", nil, nil
 	}
 
 	var chosen tmdb.SearchResult
-	if len(results) == 1 {
+	lone := len(results) == 1 && !hasMore
+	if lone && r.confident(results[0]) {
 		chosen = results[0]
 		mediaLabel := mapMediaType(results[0].MediaType)
-		fmt.Printf("  Found %s: %s\n", mediaLabel, results[0].DisplayTitle())
+		r.detailf("  Found %s: %s\n", mediaLabel, results[0].DisplayTitle())
+	} else if lone && r.cfg.AutoSelect != "" {
+		outcome.Warnings = append(outcome.Warnings, fmt.Sprintf("Only match %s has %d votes (--min-votes %d), skipping",
+			results[0].DisplayTitle(), results[0].VoteCount, r.cfg.MinVotes))
+		return "", nil, nil
+	} else if r.cfg.AutoSelect != "" {
+		result, ok := autoSelect(r.cfg.AutoSelect, results)
+		if !ok {
+			outcome.Warnings = append(outcome.Warnings, fmt.Sprintf("Found %d results, skipping ambiguous note", len(results)))
+			return "", nil, nil
+		}
+		chosen = result
+		r.detailf("  Auto-selected %s: %s\n", mapMediaType(chosen.MediaType), chosen.DisplayTitle())
+	} else if !interactive() {
+		outcome.Warnings = append(outcome.Warnings,
+			fmt.Sprintf("Found %d results but no terminal is attached, skipping (use --auto to choose)", len(results)))
+		return "", nil, nil
 	} else {
-		fmt.Printf("  Found %d results, showing selector...\n", len(results))
-		selection, err := tui.Select(title, results)
+		r.detailf("  Found %d results, showing selector...\n", len(results))
+		if r.cfg.AlternativeTitles {
+			r.attachAlternativeTitles(ctx, results)
+		}
+		opts := []tui.Option{tui.WithPosters(func(posterPath string) ([]byte, error) {
+			return r.client.FetchImage(ctx, r.client.ThumbnailURL(posterPath))
+		})}
+		if pager != nil {
+			opts = append(opts, pager)
+		}
+		if r.cfg.OverviewLines > 0 {
+			opts = append(opts, tui.WithOverviewLines(r.cfg.OverviewLines))
+		}
+		selection, err := selectResult(title, results, r.lastMediaType, opts...)
 		if err != nil {
 			return "", nil, err
 		}
 		switch selection.Action {
 		case tui.ActionSkipped:
-			fmt.Println("  Selection skipped by user")
+			r.detailf("  Selection skipped by user\n")
 			return "", nil, nil
 		case tui.ActionStopped:
 			return "", nil, ErrStopProcessing
@@ -271,46 +814,306 @@ func (r *Runner) fetchRequiredData(
 				return "", nil, errors.New("selection missing result")
 			}
 			chosen = *selection.Selection
+			r.lastMediaType = chosen.MediaType
 			mediaLabel := mapMediaType(chosen.MediaType)
-			fmt.Printf("  Selected %s: %s\n", mediaLabel, chosen.DisplayTitle())
+			r.detailf("  Selected %s: %s\n", mediaLabel, chosen.DisplayTitle())
 		default:
 			return "", nil, errors.New("unknown selection action")
 		}
 	}
 
+	outcome.Match = newMatch(title, chosen, len(results))
+	if outcome.Match.Confidence.Low() {
+		r.detailf("  Low-confidence match (%s): %q for %q\n", outcome.Match.Confidence, chosen.DisplayTitle(), title)
+	}
+
+	// remember the match before anything else can fail or return early
+	if err := r.rememberMatch(n, chosen); err != nil {
+		return "", nil, fmt.Errorf("failed to store TMDB ID: %w", err)
+	}
+
 	if chosen.PosterPath == "" {
-		fmt.Println("  Selected result has no poster")
-		return "", nil, nil
+		r.printf("  Selected result has no poster\n")
+		if !r.wantsMetadata() {
+			return "", nil, nil
+		}
+		// the title's runtime and genres are still worth storing
+		meta, err := r.client.GetMetadataByResult(ctx, chosen)
+		return "", meta, err
 	}
 
 	if needsCover && n.HasExternalCover() {
 		if existing, ok := n.GetExistingCoverURL(); ok {
-			fmt.Println("  Found external cover URL, will download locally")
+			r.detailf("  Found external cover URL, will download locally\n")
+			if !r.wantsMetadata() {
+				return existing, nil, nil
+			}
 			meta, err := r.client.GetMetadataByResult(ctx, chosen)
 			return existing, meta, err
 		}
 	}
 
+	if needsCover && chosen.MediaType == "tv" {
+		posterPath, err := r.seasonPosterPath(ctx, n, chosen.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		if posterPath != "" {
+			chosen.PosterPath = posterPath
+		}
+	}
+
+	if !r.wantsMetadata() {
+		if !needsCover {
+			return "", nil, nil
+		}
+		return r.client.ImageURL(chosen.PosterPath), nil, nil
+	}
+
 	return r.client.GetCoverAndMetadataByResult(ctx, chosen)
 }
 
-func (r *Runner) updateCover(ctx context.Context, n *note.Note, imageURL, attachmentsDir string) error {
-	localPath := n.GenerateLocalCoverPath(attachmentsDir)
-	if err := r.client.DownloadAndResizeImage(ctx, imageURL, localPath, 1000); err != nil {
+// staleTMDBID returns the note's stored TMDB ID when err is TMDB reporting
+// that the ID doesn't exist (anymore).
+func (r *Runner) staleTMDBID(n *note.Note, err error) (int, bool) {
+	if !tmdb.IsNotFound(err) || r.cfg.Force {
+		return 0, false
+	}
+	if _, _, ok := n.GetEpisode(); ok {
+		// a missing episode doesn't mean the show is gone
+		return 0, false
+	}
+	return n.GetTMDBID()
+}
+
+// rememberMatch stores the TMDB ID and type of a chosen search result right
+// away, so later runs use them instead of searching again, even when this
+// run writes no metadata (e.g. --only cover).
+func (r *Runner) rememberMatch(n *note.Note, chosen tmdb.SearchResult) error {
+	storedID, hasID := n.GetTMDBID()
+	storedType, hasType := n.GetTMDBType()
+	if hasID && hasType && storedID == chosen.ID && storedType == chosen.MediaType {
+		return nil
+	}
+	id, mediaType := chosen.ID, chosen.MediaType
+	return n.UpdateMetadata(note.Metadata{TMDBID: &id, TMDBType: &mediaType})
+}
+
+// search returns the first page of results for query along with a pager for
+// the selector. People are searched separately and have no further pages.
+func (r *Runner) search(ctx context.Context, query, searchType string) ([]tmdb.SearchResult, bool, tui.Option, error) {
+	limit := r.resultLimit()
+	if searchType == "person" {
+		response, err := r.searches.fetch(searchKey(query, searchType, 1, limit), func() (tmdb.SearchResponse, error) {
+			results, err := r.client.SearchPerson(ctx, query, limit)
+			return tmdb.SearchResponse{Results: results}, err
+		})
+		return response.Results, false, nil, err
+	}
+	// the selector pages through all of TMDB's results, limit at a time
+	pages := newResultPager(limit, func(tmdbPage int) (tmdb.SearchResponse, error) {
+		return r.searches.fetch(searchKey(query, searchType, tmdbPage, MaxResults), func() (tmdb.SearchResponse, error) {
+			return r.client.SearchByType(ctx, query, searchType, tmdbPage, MaxResults)
+		})
+	})
+	searchPage := pages.page
+	response, err := searchPage(1)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	pager := tui.WithPagination(response.Page, response.TotalPages, searchPage)
+	return response.Results, response.HasMore(), pager, nil
+}
+
+// alsoKnownAsLimit caps the alternative titles shown per selector entry.
+const alsoKnownAsLimit = 3
+
+// attachAlternativeTitles fills in AlsoKnownAs for the selector. The titles
+// are only hints, so lookup failures are ignored.
+func (r *Runner) attachAlternativeTitles(ctx context.Context, results []tmdb.SearchResult) {
+	for i := range results {
+		if results[i].MediaType == "person" {
+			continue
+		}
+		titles, err := r.client.GetAlternativeTitles(ctx, results[i].ID, results[i].MediaType)
+		if err != nil {
+			continue
+		}
+		seen := map[string]bool{strings.ToLower(results[i].DisplayTitle()): true}
+		for _, alt := range titles {
+			key := strings.ToLower(alt.Title)
+			if alt.Title == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			results[i].AlsoKnownAs = append(results[i].AlsoKnownAs, alt.Title)
+			if len(results[i].AlsoKnownAs) == alsoKnownAsLimit {
+				break
+			}
+		}
+	}
+}
+
+// fetchEpisodeData returns the cover and metadata of a single TV episode.
+// The episode's still is used as the cover, falling back to the show's
+// poster when it has none.
+func (r *Runner) fetchEpisodeData(
+	ctx context.Context,
+	n *note.Note,
+	tvID, season, episode int,
+	needsCover, needsMetadata bool,
+) (string, *tmdb.Metadata, error) {
+	r.detailf("  Episode S%02dE%02d\n", season, episode)
+
+	var coverURL string
+	if needsCover {
+		if existing, ok := n.GetExistingCoverURL(); ok && n.HasExternalCover() {
+			r.detailf("  Found external cover URL, will download locally\n")
+			coverURL = existing
+		} else {
+			var err error
+			coverURL, err = r.client.GetEpisodeStillURL(ctx, tvID, season, episode)
+			if errors.Is(err, tmdb.ErrNoStill) {
+				r.detailf("  No episode still, using the show's poster\n")
+				coverURL, err = r.client.GetCoverURLByID(ctx, tvID, "tv")
+			}
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	if !needsMetadata || !r.wantsMetadata() {
+		return coverURL, nil, nil
+	}
+	meta, err := r.client.GetEpisodeMetadata(ctx, tvID, season, episode)
+	return coverURL, meta, err
+}
+
+// seasonPosterPath returns the poster path of the season a season note is
+// about, or "" when the note isn't a season note or the season has no poster
+// of its own; the show's poster is used then.
+func (r *Runner) seasonPosterPath(ctx context.Context, n *note.Note, tvID int) (string, error) {
+	season, ok := n.GetSeason()
+	if !ok {
+		return "", nil
+	}
+	r.detailf("  Season %d\n", season)
+	posterPath, err := r.client.GetSeasonPosterPath(ctx, tvID, season)
+	if errors.Is(err, tmdb.ErrNoPoster) || tmdb.IsNotFound(err) {
+		r.detailf("  No poster for season %d, using the show's poster\n", season)
+		return "", nil
+	}
+	return posterPath, err
+}
+
+// confident reports whether a lone search result has enough votes to be
+// accepted without confirmation. People have no votes and always pass.
+func (r *Runner) confident(result tmdb.SearchResult) bool {
+	return result.MediaType == "person" || result.VoteCount >= r.cfg.MinVotes
+}
+
+// coverID returns the TMDB ID the cover is downloaded for, or 0 when it is
+// not known yet.
+func (r *Runner) coverID(n *note.Note, meta *tmdb.Metadata) int {
+	if meta != nil {
+		return meta.TMDBID
+	}
+	if id, ok := n.GetTMDBID(); ok && !r.cfg.Force {
+		return id
+	}
+	return 0
+}
+
+func (r *Runner) updateCover(ctx context.Context, n *note.Note, imageURL, attachmentsDir string, tmdbID int) error {
+	opts := r.imageOptions(n)
+	ext := opts.Format.Extension()
+	localPath := n.GenerateLocalCoverPath(attachmentsDir, ext, tmdbID)
+	// keep writing to a cover named before TMDB IDs were part of the file
+	// name, so existing links don't change
+	legacyPath := n.GenerateLocalCoverPath(attachmentsDir, ext, 0)
+	if current, ok := n.ResolveLocalCover(filepath.Dir(n.Path), attachmentsDir); ok && sameFile(current, legacyPath) {
+		localPath = legacyPath
+	}
+	if err := r.client.DownloadAndResizeImage(ctx, imageURL, localPath, opts); err != nil {
 		return fmt.Errorf("failed to download image: %w", err)
 	}
 	relative, err := n.GetRelativeCoverPath(localPath)
 	if err != nil {
 		return fmt.Errorf("failed to get relative cover path: %w", err)
 	}
-	if err := n.UpdateCover(relative); err != nil {
+	if err := n.UpdateCover(relative, r.cfg.CoverFormat, r.client.PosterPath(imageURL)); err != nil {
 		return fmt.Errorf("failed to update cover: %w", err)
 	}
-	fmt.Printf("  ✓ Downloaded and updated cover: %s\n", relative)
+	r.detailf("  ✓ Downloaded and updated cover: %s\n", relative)
 	return nil
 }
 
-func (r *Runner) generateContent(ctx context.Context, n *note.Note) error {
+// updateBanner downloads the backdrop of the note's TMDB entry as a banner
+// and reports whether the note was updated. Titles without a backdrop are
+// skipped without an error.
+func (r *Runner) updateBanner(ctx context.Context, n *note.Note, attachmentsDir string) (bool, error) {
+	tmdbID, okID := n.GetTMDBID()
+	tmdbType, okType := n.GetTMDBType()
+	if !okID || !okType {
+		r.detailf("  No TMDB ID, skipping banner\n")
+		return false, nil
+	}
+	backdropURL, err := r.client.GetBackdropURLByID(ctx, tmdbID, tmdbType)
+	if errors.Is(err, tmdb.ErrNoBackdrop) || errors.Is(err, tmdb.ErrInvalidMediaType) {
+		r.detailf("  No backdrop available, skipping banner\n")
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch backdrop: %w", err)
+	}
+
+	opts := r.cfg.Image
+	if opts.MaxWidth != tmdb.NoResize {
+		opts.MaxWidth = tmdb.BackdropMaxWidth
+	}
+	ext := opts.Format.Extension()
+	localPath := n.GenerateLocalBannerPath(attachmentsDir, ext, tmdbID)
+	// like covers, keep writing to a banner named before TMDB IDs were part
+	// of the file name
+	legacyPath := n.GenerateLocalBannerPath(attachmentsDir, ext, 0)
+	if current, ok := n.ResolveLocalBanner(filepath.Dir(n.Path), attachmentsDir); ok && sameFile(current, legacyPath) {
+		localPath = legacyPath
+	}
+	if err := r.client.DownloadAndResizeImage(ctx, backdropURL, localPath, opts); err != nil {
+		return false, fmt.Errorf("failed to download backdrop: %w", err)
+	}
+	relative, err := n.GetRelativeCoverPath(localPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get relative banner path: %w", err)
+	}
+	if err := n.UpdateBanner(relative, r.cfg.CoverFormat); err != nil {
+		return false, fmt.Errorf("failed to update banner: %w", err)
+	}
+	r.detailf("  ✓ Downloaded and updated banner: %s\n", relative)
+	return true, nil
+}
+
+// contentOptions translates the content settings of the run into options
+// for content.BuildTMDBContent.
+func (r *Runner) contentOptions() []content.Option {
+	opts := []content.Option{
+		content.WithOverviewStyle(r.cfg.OverviewStyle),
+		content.WithInfoStyle(r.cfg.InfoStyle),
+		content.WithEmoji(!r.cfg.NoEmoji),
+		content.WithShortMoney(r.cfg.ShortMoney),
+	}
+	if r.cfg.CurrencySymbol != "" {
+		opts = append(opts, content.WithCurrencySymbol(r.cfg.CurrencySymbol))
+	}
+	if r.cfg.Region != "" {
+		opts = append(opts, content.WithRegion(r.cfg.Region))
+	}
+	return opts
+}
+
+// generateContent writes the note's content block. Requested sections that
+// don't apply to the note's type are reported once per run in outcome.
+func (r *Runner) generateContent(ctx context.Context, n *note.Note, outcome *Outcome) error {
 	tmdbID, ok := n.GetTMDBID()
 	if !ok {
 		return errors.New("no TMDB ID found, cannot generate content")
@@ -320,16 +1123,48 @@ func (r *Runner) generateContent(ctx context.Context, n *note.Note) error {
 		return errors.New("no TMDB type found, cannot generate content")
 	}
 
+	season, episode, isEpisode := n.GetEpisode()
+	contentType := tmdbType
+	if isEpisode {
+		contentType = content.MediaTypeEpisode
+	}
+
+	sections := r.cfg.ContentSections
+	if overrides := n.Overrides(); len(overrides.Sections) > 0 {
+		sections = overrides.Sections
+	}
+	if len(sections) == 0 {
+		sections = content.DefaultSections(contentType)
+	}
+	for _, section := range sections {
+		if key := section + "/" + contentType; !content.SectionApplies(section, contentType) && !r.inapplicable[key] {
+			r.inapplicable[key] = true
+			outcome.Warnings = append(outcome.Warnings,
+				fmt.Sprintf("Section '%s' is not applicable to %s", section, mediaTypePlural(contentType)))
+		}
+	}
+
+	// one details request fetches everything the sections need
+	appendTo := content.AppendToResponse(contentType, sections)
+	if appendTo == "" {
+		// an empty value would request the default appendages
+		appendTo = "external_ids"
+	}
+
 	var (
 		details map[string]any
 		err     error
 	)
 
-	switch tmdbType {
-	case "tv":
-		details, err = r.client.GetFullTVDetails(ctx, tmdbID)
-	case "movie":
-		details, err = r.client.GetFullMovieDetails(ctx, tmdbID)
+	switch {
+	case isEpisode:
+		details, err = r.client.GetEpisodeDetails(ctx, tmdbID, season, episode, appendTo)
+	case tmdbType == "tv":
+		details, err = r.client.GetFullTVDetails(ctx, tmdbID, appendTo)
+	case tmdbType == "movie":
+		details, err = r.client.GetFullMovieDetails(ctx, tmdbID, appendTo)
+	case tmdbType == "person":
+		details, err = r.client.GetFullPersonDetails(ctx, tmdbID, appendTo)
 	default:
 		return fmt.Errorf("unsupported TMDB type: %s", tmdbType)
 	}
@@ -340,16 +1175,35 @@ func (r *Runner) generateContent(ctx context.Context, n *note.Note) error {
 		return errors.New("empty TMDB details")
 	}
 
-	sections := r.cfg.ContentSections
-	if len(sections) == 0 {
-		if tmdbType == "tv" {
-			sections = []string{"overview", "info", "seasons"}
-		} else {
-			sections = []string{"overview", "info"}
+	if slices.Contains(sections, content.SectionTitle) && n.HasHeading() {
+		sections = slices.DeleteFunc(slices.Clone(sections), func(section string) bool {
+			return section == content.SectionTitle
+		})
+	}
+
+	if contentType == "tv" && slices.Contains(sections, content.SectionSeasonEpisodes) {
+		if err := r.attachSeasonEpisodes(ctx, tmdbID, details, outcome); err != nil {
+			return err
+		}
+	}
+
+	if tmdbType == "movie" && slices.Contains(sections, "collection") {
+		// the collection is optional extra content; the rest is still
+		// generated when it fails to load
+		if err := r.client.AttachCollection(ctx, details); err != nil && (tmdb.IsUnauthorized(err) || ctx.Err() != nil) {
+			return err
+		} else if err != nil {
+			outcome.Warnings = append(outcome.Warnings, fmt.Sprintf("Failed to fetch the collection: %v", err))
+		}
+	}
+
+	if recommendations, ok := details["recommendations"].(map[string]any); ok {
+		if results, ok := recommendations["results"].([]any); ok && len(results) > recommendationLimit {
+			recommendations["results"] = results[:recommendationLimit]
 		}
 	}
 
-	contentText := content.BuildTMDBContent(details, tmdbType, sections)
+	contentText := content.BuildTMDBContent(details, contentType, sections, r.contentOptions()...)
 	if strings.TrimSpace(contentText) == "" {
 		return errors.New("no content generated")
 	}
@@ -357,32 +1211,236 @@ func (r *Runner) generateContent(ctx context.Context, n *note.Note) error {
 	if err := n.UpdateBodyContent(contentText); err != nil {
 		return err
 	}
-	fmt.Printf("  ✓ Generated content sections: %s\n", strings.Join(sections, ", "))
+	r.detailf("  ✓ Generated content sections: %s\n", strings.Join(sections, ", "))
 	return nil
 }
 
+// attachSeasonEpisodes fetches episode lists for up to maxSeasonFetches
+// regular seasons and stores them under each season's "episodes" key.
+// Specials (season 0) are skipped to save requests. A season that fails to
+// load is reported in outcome and left without episodes; only a rejected
+// API key or a cancelled context is returned.
+func (r *Runner) attachSeasonEpisodes(ctx context.Context, tvID int, details map[string]any, outcome *Outcome) error {
+	seasons, ok := details["seasons"].([]any)
+	if !ok {
+		return nil
+	}
+	fetched := 0
+	for _, raw := range seasons {
+		season, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		number, ok := season["season_number"].(float64)
+		if !ok || number == 0 {
+			continue
+		}
+		if fetched >= maxSeasonFetches {
+			r.detailf("  Episode lists capped at %d seasons\n", maxSeasonFetches)
+			return nil
+		}
+		fetched++
+		seasonDetails, err := r.client.GetSeasonDetails(ctx, tvID, int(number))
+		if err != nil && (tmdb.IsUnauthorized(err) || ctx.Err() != nil) {
+			return err
+		}
+		if err != nil {
+			outcome.errorf("Failed to fetch season %d episodes: %v", int(number), err)
+			continue
+		}
+		if episodes, ok := seasonDetails["episodes"].([]any); ok {
+			season["episodes"] = episodes
+		}
+	}
+	return nil
+}
+
+// mediaTypePlural names a content type in warnings, e.g. "movies".
+func mediaTypePlural(mediaType string) string {
+	switch mediaType {
+	case "tv":
+		return "TV shows"
+	case "person":
+		return "people"
+	default:
+		return mediaType + "s"
+	}
+}
+
 func (r *Runner) toNoteMetadata(meta *tmdb.Metadata) note.Metadata {
 	result := note.Metadata{}
+	if r.allows(OpTags) && len(meta.GenreTags) > 0 {
+		result.GenreTags = append([]string(nil), meta.GenreTags...)
+		result.ReplaceGenreTags = r.cfg.ReplaceTags
+	}
+	if !r.allows(OpMetadata) {
+		return result
+	}
 	if meta.Runtime != nil {
 		result.Runtime = meta.Runtime
 	}
+	if meta.EpisodeRuntime != nil {
+		result.EpisodeRuntime = meta.EpisodeRuntime
+	}
 	if meta.TotalEpisodes != nil {
 		result.TotalEpisodes = meta.TotalEpisodes
 	}
-	if len(meta.GenreTags) > 0 {
-		result.GenreTags = append([]string(nil), meta.GenreTags...)
+	if meta.Year != nil {
+		result.Year = meta.Year
+	}
+	if len(meta.Directors) > 0 {
+		result.Directors = append([]string(nil), meta.Directors...)
+	}
+	if meta.ContentRating != "" {
+		rating := meta.ContentRating
+		result.ContentRating = &rating
+	}
+	if meta.Status != "" {
+		result.Status = &meta.Status
+	}
+	result.InProduction = meta.InProduction
+	if meta.EpisodeTitle != "" {
+		result.EpisodeTitle = &meta.EpisodeTitle
+	}
+	if meta.AirDate != "" {
+		result.AirDate = &meta.AirDate
 	}
 	result.TMDBID = &meta.TMDBID
 	result.TMDBType = &meta.TMDBType
 	return result
 }
 
+// coverUnchanged reports whether the note's local cover was downloaded from
+// the same poster as imageURL and still exists on disk.
+func (r *Runner) coverUnchanged(n *note.Note, imageURL, attachmentsDir string) bool {
+	if r.cfg.ForceCover {
+		return false
+	}
+	source, ok := n.GetCoverSource()
+	if !ok || source != r.client.PosterPath(imageURL) {
+		return false
+	}
+	_, ok = n.ResolveLocalCover(filepath.Dir(n.Path), attachmentsDir)
+	return ok
+}
+
+// sameFile reports whether the paths a and b refer to the same location.
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// sanitizeFilename makes name safe to use as a file name, transliterating it
+// to ASCII when ASCIIFilenames is set. A name with nothing left becomes the
+// TMDB ID, or is kept as is when the ID isn't known.
+func (r *Runner) sanitizeFilename(name string, tmdbID int) string {
+	if r.cfg.ASCIIFilenames {
+		fallback := name
+		if tmdbID > 0 {
+			fallback = strconv.Itoa(tmdbID)
+		}
+		return util.ASCIIFilename(name, fallback)
+	}
+	return util.SanitizeFilename(name)
+}
+
+// imageOptions returns the cover options for n, applying its size override.
+func (r *Runner) imageOptions(n *note.Note) tmdb.ImageOptions {
+	opts := r.cfg.Image
+	switch width := n.Overrides().MaxWidth; {
+	case width < 0:
+		opts.MaxWidth = tmdb.NoResize
+	case width > 0:
+		opts.MaxWidth = width
+	}
+	return opts
+}
+
+// printf writes a progress message to Config.Output.
+func (r *Runner) printf(format string, args ...any) {
+	fmt.Fprintf(r.out, format, args...)
+}
+
+// printOutcome prints the warnings and errors collected for a note.
+func (r *Runner) printOutcome(outcome Outcome) {
+	for _, warning := range outcome.Warnings {
+		r.printf("  ⚠️  %s\n", warning)
+	}
+	for _, message := range outcome.Errors {
+		r.printf("  ✗ %s\n", message)
+	}
+}
+
+// detailf prints a per-note detail line, hidden in compact progress mode
+// unless Verbose or ShowChanges is set.
+func (r *Runner) detailf(format string, args ...any) {
+	if r.cfg.Progress && !r.cfg.Verbose && !r.cfg.ShowChanges {
+		return
+	}
+	r.printf(format, args...)
+}
+
+// allows reports whether op is enabled by Config.Only.
+func (r *Runner) allows(op string) bool {
+	return len(r.cfg.Only) == 0 || slices.Contains(r.cfg.Only, op)
+}
+
+// wantsMetadata reports whether any frontmatter metadata may be written.
+func (r *Runner) wantsMetadata() bool {
+	return r.allows(OpMetadata) || r.allows(OpTags)
+}
+
+// shouldGenerateContent reports whether content sections are generated,
+// either via GenerateContent or by naming the content operation in Only.
+func (r *Runner) shouldGenerateContent() bool {
+	if len(r.cfg.Only) > 0 {
+		return slices.Contains(r.cfg.Only, OpContent)
+	}
+	return r.cfg.GenerateContent
+}
+
+// autoSelect chooses a result according to policy. It returns false when
+// the policy declines to choose.
+func autoSelect(policy AutoSelect, results []tmdb.SearchResult) (tmdb.SearchResult, bool) {
+	switch policy {
+	case AutoSelectFirst:
+		return results[0], true
+	case AutoSelectBest:
+		best := results[0]
+		for _, result := range results[1:] {
+			if result.Popularity > best.Popularity ||
+				(result.Popularity == best.Popularity && result.VoteAverage > best.VoteAverage) {
+				best = result
+			}
+		}
+		return best, true
+	default:
+		return tmdb.SearchResult{}, false
+	}
+}
+
+// resultLimit returns the configured number of search results, clamped to
+// what a single TMDB search page can return.
+func (r *Runner) resultLimit() int {
+	switch {
+	case r.cfg.Results <= 0:
+		return DefaultResults
+	case r.cfg.Results > MaxResults:
+		return MaxResults
+	default:
+		return r.cfg.Results
+	}
+}
+
 func mapMediaType(mediaType string) string {
 	switch mediaType {
 	case "movie":
 		return "movie"
 	case "tv":
 		return "TV show"
+	case "person":
+		return "person"
 	default:
 		return mediaType
 	}
@@ -0,0 +1,1906 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/content"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tui"
+)
+
+func writeNote(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+	return path
+}
+
+func TestProcessFileOutcomes(t *testing.T) {
+	dir := t.TempDir()
+	attachmentsDir := filepath.Join(dir, "attachments")
+	if err := os.MkdirAll(attachmentsDir, 0o755); err != nil {
+		t.Fatalf("create attachments: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(attachmentsDir, "Heat - cover.jpg"), []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("write cover: %v", err)
+	}
+
+	complete := writeNote(t, dir, "Heat.md", `---
+title: Heat
+cover: attachments/Heat - cover.jpg
+runtime: 170
+tags:
+  - movie/Crime
+tmdb_id: 949
+tmdb_type: movie
+---
+<!-- tmdb: colour=blue -->
+`)
+	malformed := writeNote(t, dir, "Broken.md", "---\ntitle: [unclosed\n---\n")
+
+	// no request may reach TMDB for these notes
+	client := tmdb.NewClient("key", tmdb.WithBaseURL("http://127.0.0.1:0"))
+	runner := NewRunner(client, Config{Path: dir})
+
+	outcome, err := runner.ProcessFile(context.Background(), complete, attachmentsDir)
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if outcome.Status != OutcomeSkipped || len(outcome.Errors) != 0 {
+		t.Fatalf("expected a skipped outcome without errors, got %+v", outcome)
+	}
+	if len(outcome.Warnings) != 1 {
+		t.Fatalf("expected a warning for the unknown directive, got %v", outcome.Warnings)
+	}
+
+	outcome, err = runner.ProcessFile(context.Background(), malformed, attachmentsDir)
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if outcome.Status != OutcomeFailed || len(outcome.Errors) != 1 {
+		t.Fatalf("expected a failed outcome with one error, got %+v", outcome)
+	}
+	if outcome.Path != malformed {
+		t.Fatalf("expected outcome path %s, got %s", malformed, outcome.Path)
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	dir := t.TempDir()
+	writeNote(t, dir, "Broken.md", "---\ntitle: [unclosed\n---\n")
+	writeNote(t, dir, "Person.md", "---\ntitle: Someone\ncover: portrait.jpg\ntmdb_id: 1\ntmdb_type: person\n---\n")
+	if err := os.WriteFile(filepath.Join(dir, "portrait.jpg"), []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("write cover: %v", err)
+	}
+
+	client := tmdb.NewClient("key", tmdb.WithBaseURL("http://127.0.0.1:0"))
+	summary, err := NewRunner(client, Config{Path: dir}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.Processed != 0 || summary.Skipped != 1 || summary.Failed != 1 {
+		t.Fatalf("unexpected counts: %+v", summary)
+	}
+	if len(summary.Outcomes) != 2 {
+		t.Fatalf("expected 2 outcomes, got %d", len(summary.Outcomes))
+	}
+}
+
+// newStubTMDB returns a client for a TMDB stub that finds only Heat (949),
+// answers every other API request with its details, and serves its poster
+// as a PNG. The counter reports how many searches reached the stub.
+func newStubTMDB(t *testing.T) (*tmdb.Client, *atomic.Int32) {
+	t.Helper()
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, image.NewRGBA(image.Rect(0, 0, 4, 6))); err != nil {
+		t.Fatalf("encode poster: %v", err)
+	}
+	var searches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, ".png") {
+			_, _ = w.Write(poster.Bytes())
+			return
+		}
+		if strings.HasPrefix(req.URL.Path, "/search/") {
+			searches.Add(1)
+			_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [{"id": 949, "media_type": "movie", "title": "Heat", "poster_path": "/heat.png"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": 949, "title": "Heat", "poster_path": "/heat.png"}`))
+	}))
+	t.Cleanup(server.Close)
+	return tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL)), &searches
+}
+
+func TestRunAttachmentsDirOutsideVault(t *testing.T) {
+	root := t.TempDir()
+	vault := filepath.Join(root, "vault", "Movies")
+	media := filepath.Join(root, "media")
+	if err := os.MkdirAll(vault, 0o755); err != nil {
+		t.Fatalf("create vault: %v", err)
+	}
+	path := writeNote(t, vault, "Heat.md", "---\ntitle: Heat\ntmdb_id: 949\ntmdb_type: movie\n---\n")
+
+	client, _ := newStubTMDB(t)
+	summary, err := NewRunner(client, Config{
+		Path:           vault,
+		Only:           []string{OpCover},
+		AttachmentsDir: media,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.Processed != 1 {
+		t.Fatalf("expected one processed note, got %+v", summary)
+	}
+
+	if _, err := os.Stat(filepath.Join(media, "Heat - 949 - cover.jpg")); err != nil {
+		t.Fatalf("expected the cover in the attachments dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(vault, "attachments")); !os.IsNotExist(err) {
+		t.Fatalf("expected no attachments folder inside the vault")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	if !strings.Contains(string(data), "cover: ../../media/Heat - 949 - cover.jpg") {
+		t.Fatalf("expected a cover path relative to the note, got:\n%s", data)
+	}
+}
+
+func TestRunRemembersMatchWithoutMetadata(t *testing.T) {
+	dir := t.TempDir()
+	path := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\n---\n")
+
+	client, searches := newStubTMDB(t)
+	runner := NewRunner(client, Config{Path: dir, Only: []string{OpCover}, ForceCover: true, NoSearchCache: true})
+	for run := 1; run <= 2; run++ {
+		if _, err := runner.ProcessFile(context.Background(), path, filepath.Join(dir, "attachments")); err != nil {
+			t.Fatalf("run %d: ProcessFile failed: %v", run, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	for _, want := range []string{"tmdb_id: 949", "tmdb_type: movie"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in the note, got:\n%s", want, data)
+		}
+	}
+	if got := searches.Load(); got != 1 {
+		t.Fatalf("expected the second run to use the stored ID, got %d searches", got)
+	}
+}
+
+func TestRunPosterlessSelection(t *testing.T) {
+	var searches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/search/") {
+			searches.Add(1)
+			_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [{"id": 1234, "name": "Jane Doe", "known_for_department": "Directing"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": 1234, "name": "Jane Doe"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL))
+
+	dir := t.TempDir()
+	path := writeNote(t, dir, "Jane Doe.md", "---\ntitle: Jane Doe\ntmdb_type: person\n---\n")
+	runner := NewRunner(client, Config{Path: dir, NoSearchCache: true})
+	outcome, err := runner.ProcessFile(context.Background(), path, filepath.Join(dir, "attachments"))
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if !slices.Contains(outcome.Updated, "metadata") {
+		t.Fatalf("expected the match to be stored as metadata, got %+v", outcome)
+	}
+	if _, err := runner.ProcessFile(context.Background(), path, filepath.Join(dir, "attachments")); err != nil {
+		t.Fatalf("second ProcessFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	if !strings.Contains(string(data), "tmdb_id: 1234") {
+		t.Fatalf("expected the TMDB ID in the note, got:\n%s", data)
+	}
+	if got := searches.Load(); got != 1 {
+		t.Fatalf("expected the posterless match to be remembered, got %d searches", got)
+	}
+}
+
+func TestRefreshMetadata(t *testing.T) {
+	dir := t.TempDir()
+	stored := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\nruntime: 170\ntags: [movie/Crime]\ntmdb_id: 949\ntmdb_type: movie\n---\n")
+	unmatched := writeNote(t, dir, "Ronin.md", "---\ntitle: Ronin\n---\n")
+
+	client, searches := newStubTMDB(t)
+	runner := NewRunner(client, Config{Path: dir, RefreshMetadata: true, GenerateContent: true})
+	attachmentsDir := filepath.Join(dir, "attachments")
+
+	outcome, err := runner.ProcessFile(context.Background(), stored, attachmentsDir)
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if strings.Join(outcome.Updated, ",") != "metadata" {
+		t.Fatalf("expected only metadata to be updated, got %+v", outcome)
+	}
+	outcome, err = runner.ProcessFile(context.Background(), unmatched, attachmentsDir)
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if outcome.Status != OutcomeSkipped {
+		t.Fatalf("expected the note without an ID to be skipped, got %+v", outcome)
+	}
+
+	if got := searches.Load(); got != 0 {
+		t.Fatalf("expected no searches, got %d", got)
+	}
+	if _, err := os.Stat(attachmentsDir); !os.IsNotExist(err) {
+		t.Fatalf("expected no cover to be downloaded")
+	}
+}
+
+func TestStaleTMDBID(t *testing.T) {
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, image.NewRGBA(image.Rect(0, 0, 4, 6))); err != nil {
+		t.Fatalf("encode poster: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasSuffix(req.URL.Path, ".png"):
+			_, _ = w.Write(poster.Bytes())
+		case req.URL.Path == "/movie/1":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status_code": 34, "status_message": "The resource you requested could not be found."}`))
+		case strings.HasPrefix(req.URL.Path, "/search/"):
+			_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [{"id": 949, "media_type": "movie", "title": "Heat", "poster_path": "/heat.png"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"id": 949, "title": "Heat", "poster_path": "/heat.png"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL), tmdb.WithRateLimit(0, 0))
+
+	for _, research := range []bool{false, true} {
+		dir := t.TempDir()
+		path := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\ntmdb_id: 1\ntmdb_type: movie\n---\n")
+		// compact progress output hides the retry notice
+		var output bytes.Buffer
+		runner := NewRunner(client, Config{Path: dir, ResearchMissing: research, Progress: true, Output: &output})
+		outcome, err := runner.ProcessFile(context.Background(), path, filepath.Join(dir, "attachments"))
+		if err != nil {
+			t.Fatalf("ProcessFile failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read note: %v", err)
+		}
+
+		if !research {
+			if len(outcome.Errors) != 1 || !strings.Contains(outcome.Errors[0], "Stored TMDB ID 1 no longer exists") {
+				t.Fatalf("expected the stale ID to be reported, got %+v", outcome)
+			}
+			if !strings.Contains(string(data), "tmdb_id: 1\n") {
+				t.Fatalf("expected the note to be left alone, got:\n%s", data)
+			}
+			continue
+		}
+		if outcome.Status != OutcomeProcessed || !strings.Contains(string(data), "tmdb_id: 949") {
+			t.Fatalf("expected the note to be matched again, got %+v:\n%s", outcome, data)
+		}
+		if output.Len() != 0 {
+			t.Fatalf("expected no detail lines in compact progress mode, got:\n%s", output.String())
+		}
+	}
+}
+
+func TestSeasonNoteCover(t *testing.T) {
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, image.NewRGBA(image.Rect(0, 0, 4, 6))); err != nil {
+		t.Fatalf("encode poster: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasSuffix(req.URL.Path, ".png"):
+			_, _ = w.Write(poster.Bytes())
+		case req.URL.Path == "/tv/1396/season/2":
+			_, _ = w.Write([]byte(`{"season_number": 2, "poster_path": "/season2.png"}`))
+		case req.URL.Path == "/tv/1396/season/3":
+			_, _ = w.Write([]byte(`{"season_number": 3, "poster_path": null}`))
+		default:
+			_, _ = w.Write([]byte(`{"id": 1396, "name": "Breaking Bad", "poster_path": "/show.png"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL))
+
+	dir := t.TempDir()
+	runner := NewRunner(client, Config{Path: dir, Only: []string{OpCover}})
+	for season, want := range map[int]string{2: "/season2.png", 3: "/show.png"} {
+		path := writeNote(t, dir, fmt.Sprintf("Breaking Bad S%d.md", season), fmt.Sprintf("---\ntitle: Breaking Bad\ntmdb_id: 1396\ntmdb_type: tv\nseason: %d\n---\n", season))
+		if _, err := runner.ProcessFile(context.Background(), path, filepath.Join(dir, "attachments")); err != nil {
+			t.Fatalf("ProcessFile failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read note: %v", err)
+		}
+		if !strings.Contains(string(data), "cover_source: "+want) || !strings.Contains(string(data), fmt.Sprintf("Breaking Bad - 1396 - S%02d - cover.jpg", season)) {
+			t.Fatalf("season %d: expected the cover from %s, got:\n%s", season, want, data)
+		}
+	}
+}
+
+func TestUpdateCoverKeepsLegacyFilename(t *testing.T) {
+	dir := t.TempDir()
+	attachmentsDir := filepath.Join(dir, "attachments")
+	if err := os.MkdirAll(attachmentsDir, 0o755); err != nil {
+		t.Fatalf("create attachments: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(attachmentsDir, "Heat - cover.jpg"), []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("write cover: %v", err)
+	}
+	legacy := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\ncover: attachments/Heat - cover.jpg\ncover_source: /old.png\ntmdb_id: 949\ntmdb_type: movie\n---\n")
+	fresh := writeNote(t, dir, "Heat (1995).md", "---\ntitle: Heat\ntmdb_id: 949\ntmdb_type: movie\n---\n")
+
+	client, _ := newStubTMDB(t)
+	runner := NewRunner(client, Config{Path: dir, Only: []string{OpCover}, ForceCover: true})
+	for _, path := range []string{legacy, fresh} {
+		if _, err := runner.ProcessFile(context.Background(), path, attachmentsDir); err != nil {
+			t.Fatalf("ProcessFile failed: %v", err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		legacy: "cover: attachments/Heat - cover.jpg",
+		fresh:  "cover: attachments/Heat - 949 - cover.jpg",
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read note: %v", err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in %s, got:\n%s", want, filepath.Base(path), data)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(attachmentsDir, "Heat - cover.jpg")); err != nil || string(data) == "jpg" {
+		t.Fatalf("expected the legacy cover to be re-downloaded in place (%v)", err)
+	}
+}
+
+func TestCleanOrphans(t *testing.T) {
+	dir := t.TempDir()
+	attachmentsDir := filepath.Join(dir, "attachments")
+	if err := os.MkdirAll(attachmentsDir, 0o755); err != nil {
+		t.Fatalf("create attachments: %v", err)
+	}
+	for _, name := range []string{"Heat - 949 - cover.jpg", "Heat - 949 - banner.jpg", "Old - cover.png", "diagram.png"} {
+		if err := os.WriteFile(filepath.Join(attachmentsDir, name), []byte("img"), 0o644); err != nil {
+			t.Fatalf("write image: %v", err)
+		}
+	}
+	writeNote(t, dir, "Heat.md", "---\ntitle: Heat\ncover: \"[[Heat - 949 - cover.jpg]]\"\nbanner: attachments/Heat - 949 - banner.jpg\n---\n")
+
+	var out bytes.Buffer
+	orphans, err := CleanOrphans(CleanConfig{Path: dir}, &out)
+	if err != nil {
+		t.Fatalf("CleanOrphans failed: %v", err)
+	}
+	if orphans != 1 || !strings.Contains(out.String(), "Old - cover.png") {
+		t.Fatalf("expected only Old - cover.png as orphan, got %d:\n%s", orphans, out.String())
+	}
+	if _, err := os.Stat(filepath.Join(attachmentsDir, "Old - cover.png")); err != nil {
+		t.Fatalf("expected a dry run to keep the orphan: %v", err)
+	}
+
+	if _, err := CleanOrphans(CleanConfig{Path: dir, Delete: true}, &out); err != nil {
+		t.Fatalf("CleanOrphans failed: %v", err)
+	}
+	entries, err := os.ReadDir(attachmentsDir)
+	if err != nil {
+		t.Fatalf("read attachments: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected only the orphan to be removed, %d files left", len(entries))
+	}
+}
+
+func TestSearchCache(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		dir := t.TempDir()
+		writeNote(t, dir, "Heat.md", "---\ntitle: Heat\n---\n")
+		writeNote(t, dir, "Heat rewatch.md", "---\ntitle: \" heat \"\n---\n")
+
+		client, searches := newStubTMDB(t)
+		summary, err := NewRunner(client, Config{
+			Path:          dir,
+			Only:          []string{OpCover},
+			NoSearchCache: disabled,
+		}).Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if summary.Processed != 2 {
+			t.Fatalf("expected both notes to be processed, got %+v", summary)
+		}
+
+		want := int32(1)
+		if disabled {
+			want = 2
+		}
+		if got := searches.Load(); got != want {
+			t.Fatalf("NoSearchCache=%v: expected %d searches, got %d", disabled, want, got)
+		}
+	}
+}
+
+func TestInapplicableSectionWarnedOnce(t *testing.T) {
+	dir := t.TempDir()
+	first := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\ntmdb_id: 949\ntmdb_type: movie\n---\n")
+	second := writeNote(t, dir, "Heat 2.md", "---\ntitle: Heat\ntmdb_id: 949\ntmdb_type: movie\n---\n")
+
+	client, _ := newStubTMDB(t)
+	runner := NewRunner(client, Config{
+		Path:            dir,
+		GenerateContent: true,
+		Only:            []string{OpContent},
+		ContentSections: []string{"title", "seasons"},
+	})
+	attachmentsDir := filepath.Join(dir, "attachments")
+
+	outcome, err := runner.ProcessFile(context.Background(), first, attachmentsDir)
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	want := "Section 'seasons' is not applicable to movies"
+	if !slices.Contains(outcome.Updated, "content") || !slices.Equal(outcome.Warnings, []string{want}) {
+		t.Fatalf("expected content with the warning %q, got %+v", want, outcome)
+	}
+	outcome, err = runner.ProcessFile(context.Background(), second, attachmentsDir)
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if len(outcome.Warnings) != 0 {
+		t.Fatalf("expected the warning only once, got %v", outcome.Warnings)
+	}
+}
+
+func TestSeasonEpisodesFetchErrorInOutcome(t *testing.T) {
+	dir := t.TempDir()
+	path := writeNote(t, dir, "Breaking Bad.md", "---\ntitle: Breaking Bad\ntmdb_id: 1396\ntmdb_type: tv\n---\n")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/tv/1396":
+			_, _ = w.Write([]byte(`{"id": 1396, "name": "Breaking Bad", "seasons": [{"season_number": 1}, {"season_number": 2}]}`))
+		case "/tv/1396/season/2":
+			_, _ = w.Write([]byte(`{"episodes": [{"episode_number": 1, "name": "Seven Thirty-Seven"}]}`))
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithRetryAttempts(1))
+
+	runner := NewRunner(client, Config{
+		Path:            dir,
+		GenerateContent: true,
+		Only:            []string{OpContent},
+		ContentSections: []string{content.SectionSeasonEpisodes},
+	})
+	outcome, err := runner.ProcessFile(context.Background(), path, filepath.Join(dir, "attachments"))
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if len(outcome.Errors) != 1 || !strings.HasPrefix(outcome.Errors[0], "Failed to fetch season 1 episodes") {
+		t.Fatalf("expected the failed season in the outcome, got %+v", outcome)
+	}
+	if !slices.Contains(outcome.Updated, "content") {
+		t.Fatalf("expected content from the remaining season, got %+v", outcome)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	if !strings.Contains(string(data), "Seven Thirty-Seven") {
+		t.Fatalf("expected season 2 episodes in the content, got:\n%s", data)
+	}
+}
+
+func TestRunSkipsTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Meta", "templates"), 0o755); err != nil {
+		t.Fatalf("create templates folder: %v", err)
+	}
+	writeNote(t, dir, filepath.Join("Meta", "templates", "Movie.md"), "---\ntitle: Movie\n---\n")
+	writeNote(t, dir, "Flagged.md", "---\ntitle: Flagged\ntemplate: true\n---\n")
+	writeNote(t, dir, "Tagged.md", "---\ntitle: Tagged\ntags: [template/movie]\n---\n")
+	writeNote(t, dir, "Templater.md", "# <% tp.file.title %>\n\nWatched: <% tp.date.now() %>\n")
+	writeNote(t, dir, "Heat.md", "---\ntitle: Heat\n---\n")
+
+	client, searches := newStubTMDB(t)
+	runner := NewRunner(client, Config{Path: dir, TemplateFolder: "Templates", AutoSelect: AutoSelectFirst})
+	summary, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.Skipped != 4 || summary.Processed != 1 {
+		t.Fatalf("expected 4 templates skipped and 1 note processed, got %+v", summary)
+	}
+	if got := searches.Load(); got != 1 {
+		t.Fatalf("expected only the real note to be searched, got %d searches", got)
+	}
+}
+
+func TestMatchConfidence(t *testing.T) {
+	tests := []struct {
+		query      string
+		title      string
+		candidates int
+		want       MatchConfidence
+	}{
+		{"Heat", "Heat", 3, MatchExact},
+		{" heat  ", "Heat", 1, MatchCaseInsensitive},
+		{"Blade Runner", "Blade  runner", 1, MatchCaseInsensitive},
+		{"Heat", "Heat Wave", 5, MatchPartial},
+		{"The Matrix Reloaded 2003", "The Matrix Reloaded", 1, MatchPartial},
+		{"Amelie", "Amélie", 4, MatchPicked},
+		{"Amelie", "Amélie", 1, MatchDifferent},
+	}
+	for _, tc := range tests {
+		got := matchConfidence(tc.query, tc.title, tc.candidates)
+		if got != tc.want {
+			t.Fatalf("matchConfidence(%q, %q, %d) = %s, want %s", tc.query, tc.title, tc.candidates, got, tc.want)
+		}
+		if low := tc.want != MatchExact && tc.want != MatchCaseInsensitive; got.Low() != low {
+			t.Fatalf("%s.Low() = %v, want %v", got, got.Low(), low)
+		}
+	}
+}
+
+func TestRunReportsLowConfidenceMatches(t *testing.T) {
+	dir := t.TempDir()
+	writeNote(t, dir, "Heat.md", "---\ntitle: heat\n---\n")
+	sequel := writeNote(t, dir, "Heat 2.md", "---\ntitle: Heat 2\n---\n")
+
+	client, _ := newStubTMDB(t)
+	runner := NewRunner(client, Config{Path: dir, NoSearchCache: true})
+	summary, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, outcome := range summary.Outcomes {
+		if outcome.Match == nil || outcome.Match.TMDBID != 949 {
+			t.Fatalf("expected every note to record its match, got %+v", outcome)
+		}
+	}
+	low := summary.LowConfidenceMatches()
+	if len(low) != 1 || low[0].Path != sequel || low[0].Match.Confidence != MatchPartial {
+		t.Fatalf("expected only %s as a partial match, got %+v", sequel, low)
+	}
+}
+
+func TestCleanOrphansKeepsNestedAndEmbeddedReferences(t *testing.T) {
+	dir := t.TempDir()
+	attachmentsDir := filepath.Join(dir, "attachments")
+	if err := os.MkdirAll(filepath.Join(dir, "Movies", "Crime"), 0o755); err != nil {
+		t.Fatalf("create folders: %v", err)
+	}
+	if err := os.MkdirAll(attachmentsDir, 0o755); err != nil {
+		t.Fatalf("create attachments: %v", err)
+	}
+	images := []string{
+		"Heat - 949 - cover.jpg",     // vault-root path from a nested note
+		"Ronin - 8195 - cover.jpg",   // bare wikilink embed in the body
+		"Thief - 11524 - banner.jpg", // markdown embed in the body
+		"Old - cover.png",
+	}
+	for _, name := range images {
+		if err := os.WriteFile(filepath.Join(attachmentsDir, name), []byte("img"), 0o644); err != nil {
+			t.Fatalf("write image: %v", err)
+		}
+	}
+	writeNote(t, dir, filepath.Join("Movies", "Crime", "Heat.md"),
+		"---\ntitle: Heat\ncover: attachments/Heat - 949 - cover.jpg\n---\n")
+	writeNote(t, dir, filepath.Join("Movies", "Ronin.md"),
+		"---\ntitle: Ronin\n---\n![[Ronin - 8195 - cover.jpg|300]]\n\n![Thief](<attachments/Thief - 11524 - banner.jpg>)\n")
+
+	var out bytes.Buffer
+	orphans, err := CleanOrphans(CleanConfig{Path: dir, Delete: true}, &out)
+	if err != nil {
+		t.Fatalf("CleanOrphans failed: %v", err)
+	}
+	if orphans != 1 {
+		t.Fatalf("expected only Old - cover.png as orphan, got %d:\n%s", orphans, out.String())
+	}
+	for _, name := range images[:3] {
+		if _, err := os.Stat(filepath.Join(attachmentsDir, name)); err != nil {
+			t.Fatalf("expected referenced %s to be kept: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(attachmentsDir, "Old - cover.png")); !os.IsNotExist(err) {
+		t.Fatalf("expected the orphan to be removed")
+	}
+}
+
+func TestResultPagerWalksAllTMDBResults(t *testing.T) {
+	// two TMDB pages: 20 results, then 5 more plus a repeat from page 1
+	tmdbPages := map[int][]int{1: {}, 2: {20, 21, 22, 23, 24, 25}}
+	for id := 1; id <= 20; id++ {
+		tmdbPages[1] = append(tmdbPages[1], id)
+	}
+	var fetches []int
+	pager := newResultPager(10, func(tmdbPage int) (tmdb.SearchResponse, error) {
+		fetches = append(fetches, tmdbPage)
+		var results []tmdb.SearchResult
+		for _, id := range tmdbPages[tmdbPage] {
+			results = append(results, tmdb.SearchResult{ID: id, MediaType: "movie"})
+		}
+		return tmdb.SearchResponse{Results: results, Page: tmdbPage, TotalPages: 2}, nil
+	})
+
+	seen := make(map[int]int)
+	for page := 1; ; page++ {
+		response, err := pager.page(page)
+		if err != nil {
+			t.Fatalf("page %d: %v", page, err)
+		}
+		if response.Page != page {
+			t.Fatalf("page %d: response for page %d", page, response.Page)
+		}
+		for _, result := range response.Results {
+			seen[result.ID]++
+		}
+		if !response.HasMore() {
+			if page != 3 {
+				t.Fatalf("expected 3 selector pages, last was %d", page)
+			}
+			break
+		}
+	}
+	for id := 1; id <= 25; id++ {
+		if seen[id] != 1 {
+			t.Fatalf("expected ID %d exactly once, got %d (all: %v)", id, seen[id], seen)
+		}
+	}
+	if !slices.Equal(fetches, []int{1, 2}) {
+		t.Fatalf("expected each TMDB page to be fetched once in order, got %v", fetches)
+	}
+}
+
+func TestReadImportFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []ImportEntry
+		wantErr string
+	}{
+		{
+			name:    "letterboxd csv",
+			file:    "watched.csv",
+			content: "Date,Name,Year,Letterboxd URI\n2024-01-02,Heat,1995,https://boxd.it/x\n2024-01-03,\"Crouching Tiger, Hidden Dragon\",2000,https://boxd.it/y\n",
+			want: []ImportEntry{
+				{Title: "Heat", Year: 1995, MediaType: "movie"},
+				{Title: "Crouching Tiger, Hidden Dragon", Year: 2000, MediaType: "movie"},
+			},
+		},
+		{
+			name:    "trakt csv",
+			file:    "trakt.CSV",
+			content: "title,year,tmdb_id,type\nThe Wire,2002,1438,show\n,,949,movie\n",
+			want: []ImportEntry{
+				{Title: "The Wire", Year: 2002, TMDBID: 1438, MediaType: "tv"},
+				{TMDBID: 949, MediaType: "movie"},
+			},
+		},
+		{
+			name:    "json",
+			file:    "list.json",
+			content: `[{"Title": " Heat ", "Year": "1995"}, {"name": "The Wire", "TMDB ID": 1438, "Media Type": "series"}]`,
+			want: []ImportEntry{
+				{Title: "Heat", Year: 1995, MediaType: "movie"},
+				{Title: "The Wire", TMDBID: 1438, MediaType: "tv"},
+			},
+		},
+		{
+			name:    "csv without a title column",
+			file:    "ids.csv",
+			content: "year,tmdb_id\n1995,949\n",
+			wantErr: "title or name column",
+		},
+		{
+			name:    "invalid json",
+			file:    "list.json",
+			content: `{"title": "Heat"}`,
+			wantErr: "decode JSON",
+		},
+		{
+			name:    "unsupported extension",
+			file:    "list.txt",
+			content: "Heat\n",
+			wantErr: "unsupported import file type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeNote(t, t.TempDir(), tt.file, tt.content)
+			got, err := ReadImportFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadImportFile failed: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	writeNote(t, dir, "Ronin.md", "---\ntitle: Ronin\n---\n")
+	source := writeNote(t, t.TempDir(), "list.json",
+		`[{"tmdb_id": 949}, {"title": "Heat"}, {"title": "Ronin", "year": 1998}, {"year": 2001}]`)
+
+	client, searches := newStubTMDB(t)
+	summary, err := NewRunner(client, Config{Path: dir, Only: []string{OpCover}, NoSearchCache: true}).Import(context.Background(), source, dir)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if summary.Processed != 1 {
+		t.Fatalf("expected only the created note to be processed, got %+v", summary)
+	}
+	if searches.Load() != 2 {
+		t.Fatalf("expected the two titled entries to be searched and the ID to be looked up, got %d searches", searches.Load())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "Heat.md"))
+	if err != nil {
+		t.Fatalf("expected a note named after the TMDB title: %v", err)
+	}
+	for _, want := range []string{"title: Heat", "tmdb_id: 949", "tmdb_type: movie"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in the imported note:\n%s", want, data)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	var notes []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".md") {
+			notes = append(notes, entry.Name())
+		}
+	}
+	if !slices.Equal(notes, []string{"Heat.md", "Ronin.md"}) {
+		t.Fatalf("expected no other notes to be created, got %v", notes)
+	}
+}
+
+func TestImportRejectsUnknownTMDBID(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithRetryAttempts(1))
+
+	dir := t.TempDir()
+	source := writeNote(t, t.TempDir(), "ids.csv", "title,tmdb_id\n,999999\n")
+	summary, err := NewRunner(client, Config{Path: dir}).Import(context.Background(), source, dir)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if summary.Processed != 0 {
+		t.Fatalf("expected nothing to be processed, got %+v", summary)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("expected no note for an ID without a title, got %v", entries)
+	}
+}
+
+func TestRunRetriesSearchWithAliases(t *testing.T) {
+	dir := t.TempDir()
+	path := writeNote(t, dir, "Der Pate.md", "---\ntitle: Der Pate\naliases:\n  - der pate\n  - Il padrino\n  - The Godfather\n---\n")
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, "/search/") {
+			_, _ = w.Write([]byte(`{"id": 238, "title": "The Godfather"}`))
+			return
+		}
+		query := req.URL.Query().Get("query")
+		queries = append(queries, query)
+		if query == "The Godfather" {
+			_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [{"id": 238, "media_type": "movie", "title": "The Godfather", "poster_path": "/godfather.png"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": []}`))
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL))
+
+	_, err := NewRunner(client, Config{Path: dir, Only: []string{OpMetadata}, AlternativeTitles: true, NoSearchCache: true}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want := []string{"Der Pate", "Il padrino", "The Godfather"}; !slices.Equal(queries, want) {
+		t.Fatalf("expected searches %v, got %v", want, queries)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	if !strings.Contains(string(data), "tmdb_id: 238") {
+		t.Fatalf("expected the alias match to be stored, got:\n%s", data)
+	}
+}
+
+func TestAttachAlternativeTitles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/movie/949/alternative_titles":
+			_, _ = w.Write([]byte(`{"titles": [{"title": "HEAT"}, {"title": " "}, {"title": "Fuego contra fuego"}, {"title": "Heat - Showdown"}, {"title": "fuego contra fuego"}, {"title": "Heat 1995"}, {"title": "Ignored"}]}`))
+		case "/tv/1438/alternative_titles":
+			_, _ = w.Write([]byte(`{"results": [{"title": "Bodymore"}]}`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithRetryAttempts(1))
+
+	results := []tmdb.SearchResult{
+		{ID: 949, MediaType: "movie", Title: "Heat"},
+		{ID: 1438, MediaType: "tv", Name: "The Wire"},
+		{ID: 1, MediaType: "movie", Title: "Missing"},
+		{ID: 287, MediaType: "person", Name: "Brad Pitt"},
+	}
+	NewRunner(client, Config{}).attachAlternativeTitles(context.Background(), results)
+
+	want := [][]string{
+		{"Fuego contra fuego", "Heat - Showdown", "Heat 1995"},
+		{"Bodymore"},
+		nil,
+		nil,
+	}
+	for i, result := range results {
+		if !slices.Equal(result.AlsoKnownAs, want[i]) {
+			t.Fatalf("%s: expected %v, got %v", result.DisplayTitle(), want[i], result.AlsoKnownAs)
+		}
+	}
+}
+
+// newCheckVault writes a vault with one complete, one incomplete, and one
+// unreadable note and returns it with the paths of the latter two.
+func newCheckVault(t *testing.T) (vault, incomplete, unreadable string) {
+	t.Helper()
+	vault = t.TempDir()
+	attachments := filepath.Join(vault, "attachments")
+	if err := os.MkdirAll(attachments, 0o755); err != nil {
+		t.Fatalf("create attachments: %v", err)
+	}
+	writeNote(t, attachments, "Heat - 949 - cover.jpg", "jpeg")
+	writeNote(t, vault, "Heat.md", "---\ntitle: Heat\ntmdb_id: 949\ntmdb_type: movie\nruntime: 170\ntags:\n  - movie/Crime\ncover: attachments/Heat - 949 - cover.jpg\n---\n")
+	incomplete = writeNote(t, vault, "Ronin.md", "---\ntitle: Ronin\ncover: attachments/Ronin - cover.jpg\n---\n")
+	unreadable = writeNote(t, vault, "Broken.md", "---\ntitle: Broken\n")
+	return vault, incomplete, unreadable
+}
+
+func TestCheckText(t *testing.T) {
+	vault, incomplete, unreadable := newCheckVault(t)
+
+	var out bytes.Buffer
+	count, err := Check(CheckConfig{Path: vault}, &out)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected two incomplete notes, got %d:\n%s", count, out.String())
+	}
+	for _, want := range []string{
+		incomplete + ": missing cover, metadata, tmdb_id",
+		unreadable + ": malformed frontmatter",
+		"2 of 3 notes are incomplete",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Heat.md") {
+		t.Fatalf("expected the complete note not to be listed:\n%s", out.String())
+	}
+}
+
+func TestCheckJSON(t *testing.T) {
+	vault, incomplete, unreadable := newCheckVault(t)
+
+	var out bytes.Buffer
+	count, err := Check(CheckConfig{Path: vault, JSON: true}, &out)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	var results []CheckResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	if count != len(results) || len(results) != 2 {
+		t.Fatalf("expected two results, got %d (count %d)", len(results), count)
+	}
+	byPath := make(map[string]CheckResult)
+	for _, result := range results {
+		byPath[result.Path] = result
+	}
+	if got := byPath[incomplete].Missing; !slices.Equal(got, []string{"cover", "metadata", "tmdb_id"}) {
+		t.Fatalf("expected Ronin to miss everything, got %v", got)
+	}
+	broken := byPath[unreadable]
+	if !slices.Equal(broken.Missing, []string{"unreadable"}) || !strings.Contains(broken.Error, "malformed frontmatter") {
+		t.Fatalf("expected Broken to be reported unreadable, got %+v", broken)
+	}
+
+	// a vault without incomplete notes reports an empty array
+	for _, path := range []string{incomplete, unreadable} {
+		if err := os.Remove(path); err != nil {
+			t.Fatalf("remove %s: %v", path, err)
+		}
+	}
+	out.Reset()
+	if count, err := Check(CheckConfig{Path: vault, JSON: true}, &out); err != nil || count != 0 {
+		t.Fatalf("expected no incomplete notes, got %d, %v", count, err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Fatalf("expected an empty JSON array, got %q", out.String())
+	}
+}
+
+func TestCheckSkipsTemplates(t *testing.T) {
+	dir := t.TempDir()
+	templates := filepath.Join(dir, "Templates")
+	if err := os.MkdirAll(templates, 0o755); err != nil {
+		t.Fatalf("create templates: %v", err)
+	}
+	writeNote(t, templates, "Movie.md", "---\ntitle: \"{{title}}\"\n---\n")
+	writeNote(t, dir, "Templater.md", "---\ntitle: <% tp.file.title %>\n---\n")
+	incompletePath := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\n---\n")
+
+	var out bytes.Buffer
+	incomplete, err := Check(CheckConfig{Path: dir, TemplateFolder: "templates"}, &out)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if incomplete != 1 || !strings.Contains(out.String(), incompletePath) {
+		t.Fatalf("expected only Heat.md to be reported, got %d:\n%s", incomplete, out.String())
+	}
+	if !strings.Contains(out.String(), "1 of 1 notes are incomplete") {
+		t.Fatalf("expected templates to be left out of the total:\n%s", out.String())
+	}
+
+	incomplete, err = Check(CheckConfig{Path: dir}, &out)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if incomplete != 2 {
+		t.Fatalf("expected the template folder to be checked without --template-folder, got %d", incomplete)
+	}
+}
+
+func TestCheckAttachmentsDir(t *testing.T) {
+	root := t.TempDir()
+	vault := filepath.Join(root, "vault")
+	media := filepath.Join(root, "media")
+	for _, dir := range []string{vault, media} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("create %s: %v", dir, err)
+		}
+	}
+	writeNote(t, vault, "Heat.md", "---\ntitle: Heat\ncover: \"[[Heat - cover.jpg]]\"\n---\n")
+	writeNote(t, media, "Heat - cover.jpg", "jpeg")
+
+	missingCover := func(cfg CheckConfig) bool {
+		var out bytes.Buffer
+		cfg.JSON = true
+		if _, err := Check(cfg, &out); err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		var results []CheckResult
+		if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+			t.Fatalf("decode report: %v", err)
+		}
+		return len(results) == 1 && slices.Contains(results[0].Missing, "cover")
+	}
+	if !missingCover(CheckConfig{Path: vault}) {
+		t.Fatalf("expected the cover to be missing from <vault>/attachments")
+	}
+	if missingCover(CheckConfig{Path: vault, AttachmentsDir: media}) {
+		t.Fatalf("expected the cover to be found in the attachments dir")
+	}
+}
+
+func TestReprocessCovers(t *testing.T) {
+	vault := t.TempDir()
+	attachments := filepath.Join(vault, "attachments")
+	if err := os.MkdirAll(attachments, 0o755); err != nil {
+		t.Fatalf("create attachments: %v", err)
+	}
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, image.NewRGBA(image.Rect(0, 0, 40, 60))); err != nil {
+		t.Fatalf("encode poster: %v", err)
+	}
+	for _, name := range []string{"Shared - cover.jpg", "Ronin - cover.jpg", "Ronin - cover.png", "Heat - cover.jpeg"} {
+		writeNote(t, attachments, name, poster.String())
+	}
+	wikilink := writeNote(t, vault, "Shared.md", "---\ntitle: Shared\ncover: \"[[Shared - cover.jpg|poster]]\"\n---\n")
+	relative := writeNote(t, vault, "Shared Too.md", "---\ntitle: Shared Too\ncover: attachments/Shared - cover.jpg\n---\n")
+	ronin := writeNote(t, vault, "Ronin.md", "---\ntitle: Ronin\ncover: attachments/Ronin - cover.jpg\n---\n")
+	heat := writeNote(t, vault, "Heat.md", "---\ntitle: Heat\ncover: attachments/Heat - cover.jpeg\n---\n")
+
+	read := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		return string(data)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(attachments, name))
+		return err == nil
+	}
+
+	// .jpeg already is the JPEG format: resized in place, not renamed
+	if err := ReprocessCovers(ReprocessConfig{Path: heat, Image: tmdb.ImageOptions{MaxWidth: 20, Format: tmdb.ImageFormatJPEG}}); err != nil {
+		t.Fatalf("ReprocessCovers failed: %v", err)
+	}
+	if !exists("Heat - cover.jpeg") || exists("Heat - cover.jpg") || !strings.Contains(read(heat), "cover: attachments/Heat - cover.jpeg") {
+		t.Fatalf("expected the .jpeg cover to keep its name:\n%s", read(heat))
+	}
+
+	if err := ReprocessCovers(ReprocessConfig{Path: vault, Image: tmdb.ImageOptions{MaxWidth: 20, Format: tmdb.ImageFormatPNG}}); err != nil {
+		t.Fatalf("ReprocessCovers failed: %v", err)
+	}
+	if !strings.Contains(read(wikilink), `cover: "[[Shared - cover.png|poster]]"`) {
+		t.Fatalf("expected the wikilink cover to keep its style:\n%s", read(wikilink))
+	}
+	if !strings.Contains(read(relative), "cover: attachments/Shared - cover.png") {
+		t.Fatalf("expected the path cover to keep its style:\n%s", read(relative))
+	}
+	if exists("Shared - cover.jpg") || !exists("Shared - cover.png") {
+		t.Fatalf("expected the shared original to be replaced once both notes were updated")
+	}
+	if !strings.Contains(read(ronin), "cover: attachments/Ronin - cover.jpg") || !exists("Ronin - cover.jpg") {
+		t.Fatalf("expected Ronin to be left alone since its .png already exists:\n%s", read(ronin))
+	}
+	if data := read(filepath.Join(attachments, "Ronin - cover.png")); data != poster.String() {
+		t.Fatalf("expected the existing Ronin .png not to be overwritten")
+	}
+}
+
+func TestReprocessKeepsOriginalForOtherNotes(t *testing.T) {
+	vault := t.TempDir()
+	attachments := filepath.Join(vault, "attachments")
+	if err := os.MkdirAll(attachments, 0o755); err != nil {
+		t.Fatalf("create attachments: %v", err)
+	}
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, image.NewRGBA(image.Rect(0, 0, 4, 6))); err != nil {
+		t.Fatalf("encode poster: %v", err)
+	}
+	writeNote(t, attachments, "Heat - cover.jpg", poster.String())
+	path := writeNote(t, vault, "Heat.md", "---\ntitle: Heat\ncover: attachments/Heat - cover.jpg\n---\n")
+	writeNote(t, vault, "Heat Banner.md", "---\ntitle: Heat Banner\nbanner: attachments/Heat - cover.jpg\n---\n")
+
+	// the other note's banner still uses the original, whether the whole
+	// vault or only Heat.md is reprocessed
+	for _, target := range []string{vault, path} {
+		if err := ReprocessCovers(ReprocessConfig{Path: target, Image: tmdb.ImageOptions{Format: tmdb.ImageFormatPNG}}); err != nil {
+			t.Fatalf("ReprocessCovers failed: %v", err)
+		}
+		if err := os.Remove(filepath.Join(attachments, "Heat - cover.png")); err != nil {
+			t.Fatalf("expected a .png cover: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(attachments, "Heat - cover.jpg")); err != nil {
+			t.Fatalf("expected the original to be kept: %v", err)
+		}
+		writeNote(t, vault, "Heat.md", "---\ntitle: Heat\ncover: attachments/Heat - cover.jpg\n---\n")
+	}
+}
+
+func TestDiscoverPagesKeepLoadedResults(t *testing.T) {
+	first := tmdb.SearchResponse{Results: []tmdb.SearchResult{{ID: 1}, {ID: 2}}, Page: 1, TotalPages: 3}
+	pages := newDiscoverPages(first, func(page int) (tmdb.SearchResponse, error) {
+		if page == 3 {
+			return tmdb.SearchResponse{}, fmt.Errorf("page %d unavailable", page)
+		}
+		return tmdb.SearchResponse{Results: []tmdb.SearchResult{{ID: page * 10}}, Page: page, TotalPages: 3}, nil
+	})
+
+	if _, err := pages.load(2); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if _, err := pages.load(3); err == nil {
+		t.Fatalf("expected the failing page to return its error")
+	}
+	pages.remove(1)
+
+	results, page, totalPages := pages.state()
+	var ids []int
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	if !slices.Equal(ids, []int{2, 20}) || page != 2 || totalPages != 3 {
+		t.Fatalf("expected results [2 20] through page 2 of 3, got %v through page %d of %d", ids, page, totalPages)
+	}
+
+	// a repeated load of an earlier page doesn't duplicate results
+	if _, err := pages.load(2); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if results, _, _ := pages.state(); len(results) != 2 {
+		t.Fatalf("expected a repeated page to be ignored, got %v", results)
+	}
+}
+
+func TestCreateStubNote(t *testing.T) {
+	dir := t.TempDir()
+	keys := note.KeyMap{}.WithDefaults()
+	result := tmdb.SearchResult{ID: 1438, MediaType: "tv", Name: "The Wire: Season/One"}
+
+	path, err := createStubNote(dir, keys, result)
+	if err != nil {
+		t.Fatalf("createStubNote failed: %v", err)
+	}
+	if filepath.Dir(path) != dir || strings.ContainsAny(filepath.Base(path), "/:") {
+		t.Fatalf("expected a sanitized file name in %s, got %s", dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	for _, want := range []string{"title: 'The Wire: Season/One'", "tmdb_id: 1438", "tmdb_type: tv"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in the stub:\n%s", want, data)
+		}
+	}
+
+	if _, err := createStubNote(dir, keys, result); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected an existing note to be kept, got %v", err)
+	}
+}
+
+func TestDiscoverHeader(t *testing.T) {
+	tests := []struct {
+		cfg  DiscoverConfig
+		want string
+	}{
+		{DiscoverConfig{MediaType: "movie"}, "Discover movie"},
+		{DiscoverConfig{MediaType: "tv", Genre: "Drama", Year: 2002}, "Discover TV show / Drama / 2002"},
+	}
+	for _, tt := range tests {
+		if got := discoverHeader(tt.cfg); got != tt.want {
+			t.Fatalf("discoverHeader(%+v) = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestSeasonCoverSkipsMetadataWhenComplete(t *testing.T) {
+	dir := t.TempDir()
+	path := writeNote(t, dir, "Breaking Bad S02.md", "---\ntitle: Breaking Bad\ntmdb_id: 1396\ntmdb_type: tv\nseason: 2\nruntime: 47\ntags:\n  - tv/Drama\n---\n")
+
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, image.NewRGBA(image.Rect(0, 0, 4, 6))); err != nil {
+		t.Fatalf("encode poster: %v", err)
+	}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.URL.Path)
+		switch {
+		case strings.HasSuffix(req.URL.Path, ".png"):
+			_, _ = w.Write(poster.Bytes())
+		case req.URL.Path == "/tv/1396/season/2":
+			_, _ = w.Write([]byte(`{"season_number": 2, "poster_path": "/s2.png"}`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL), tmdb.WithRetryAttempts(1))
+
+	summary, err := NewRunner(client, Config{Path: dir}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.Processed != 1 {
+		t.Fatalf("expected the season cover to be downloaded, got %+v (requests %v)", summary, requests)
+	}
+	for _, request := range requests {
+		if request == "/tv/1396" {
+			t.Fatalf("expected no metadata request for a note with complete metadata, got %v", requests)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	if !strings.Contains(string(data), "cover: attachments/Breaking Bad - 1396 - S02 - cover.jpg") {
+		t.Fatalf("expected the season cover, got:\n%s", data)
+	}
+}
+
+func TestEpisodeCoversDontShareShowCover(t *testing.T) {
+	dir := t.TempDir()
+	show := writeNote(t, dir, "Breaking Bad.md", "---\ntitle: Breaking Bad\ntmdb_id: 1396\ntmdb_type: tv\n---\n")
+	first := writeNote(t, dir, "Breaking Bad S01E01.md", "---\ntitle: Breaking Bad\ntmdb_id: 1396\ntmdb_type: tv\nseason: 1\nepisode: 1\n---\n")
+	second := writeNote(t, dir, "Breaking Bad S01E02.md", "---\ntitle: Breaking Bad\ntmdb_id: 1396\ntmdb_type: tv\nseason: 1\nepisode: 2\n---\n")
+
+	// each image has its own width so the files can be told apart
+	images := map[string]int{"/show.png": 4, "/e1.png": 5, "/e2.png": 6}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if width, ok := images[req.URL.Path]; ok {
+			_ = png.Encode(w, image.NewRGBA(image.Rect(0, 0, width, 6)))
+			return
+		}
+		switch req.URL.Path {
+		case "/tv/1396":
+			_, _ = w.Write([]byte(`{"id": 1396, "name": "Breaking Bad", "poster_path": "/show.png"}`))
+		case "/tv/1396/season/1/episode/1":
+			_, _ = w.Write([]byte(`{"still_path": "/e1.png"}`))
+		case "/tv/1396/season/1/episode/2":
+			_, _ = w.Write([]byte(`{"still_path": "/e2.png"}`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL), tmdb.WithRetryAttempts(1))
+
+	summary, err := NewRunner(client, Config{Path: dir, Only: []string{OpCover}, Image: tmdb.ImageOptions{MaxWidth: tmdb.NoResize}}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.Processed != 3 {
+		t.Fatalf("expected three covers, got %+v", summary)
+	}
+	for path, want := range map[string]struct {
+		cover string
+		width int
+	}{
+		show:   {"Breaking Bad - 1396 - cover.jpg", 4},
+		first:  {"Breaking Bad - 1396 - S01E01 - cover.jpg", 5},
+		second: {"Breaking Bad - 1396 - S01E02 - cover.jpg", 6},
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read note: %v", err)
+		}
+		if !strings.Contains(string(data), "cover: attachments/"+want.cover) {
+			t.Fatalf("expected cover %q in %s, got:\n%s", want.cover, filepath.Base(path), data)
+		}
+		file, err := os.Open(filepath.Join(dir, "attachments", want.cover))
+		if err != nil {
+			t.Fatalf("open cover: %v", err)
+		}
+		cfg, _, err := image.DecodeConfig(file)
+		_ = file.Close()
+		if err != nil {
+			t.Fatalf("decode %s: %v", want.cover, err)
+		}
+		if cfg.Width != want.width {
+			t.Fatalf("expected %s to be %dpx wide, got %d", want.cover, want.width, cfg.Width)
+		}
+	}
+}
+
+func TestIsWatchedNote(t *testing.T) {
+	tests := map[string]bool{
+		"vault/Heat.md":             true,
+		"vault/Movies/Heat.MD":      true,
+		"vault/.Heat.md.swp":        false,
+		"vault/.hidden.md":          false,
+		"vault/Heat.md~":            false,
+		"vault/Heat.md.tmp":         false,
+		"vault/attachments/cov.jpg": false,
+	}
+	for path, want := range tests {
+		if got := isWatchedNote(path); got != want {
+			t.Fatalf("isWatchedNote(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestWatchDebouncesAndIgnoresOwnWrites(t *testing.T) {
+	debounce := watchDebounce
+	watchDebounce = 50 * time.Millisecond
+	t.Cleanup(func() { watchDebounce = debounce })
+
+	dir := t.TempDir()
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, image.NewRGBA(image.Rect(0, 0, 4, 6))); err != nil {
+		t.Fatalf("encode poster: %v", err)
+	}
+	var searches, details atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasSuffix(req.URL.Path, ".png"):
+			_, _ = w.Write(poster.Bytes())
+		case strings.HasPrefix(req.URL.Path, "/search/"):
+			searches.Add(1)
+			_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [{"id": 949, "media_type": "movie", "title": "Heat", "poster_path": "/heat.png"}]}`))
+		default:
+			details.Add(1)
+			_, _ = w.Write([]byte(`{"id": 949, "title": "Heat", "poster_path": "/heat.png"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- NewRunner(client, Config{Path: dir, NoSearchCache: true}).Watch(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch failed: %v", err)
+		}
+	})
+	// give the watcher time to start
+	time.Sleep(100 * time.Millisecond)
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// a burst of saves is processed once
+	path := filepath.Join(dir, "Heat.md")
+	for i := range 3 {
+		writeNote(t, dir, "Heat.md", fmt.Sprintf("---\ntitle: Heat\n---\ndraft %d\n", i))
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitFor("the note to be processed", func() bool {
+		data, err := os.ReadFile(path)
+		// the cover is saved after every TMDB request of the run
+		return err == nil && strings.Contains(string(data), "tmdb_id: 949") && strings.Contains(string(data), "cover: attachments/")
+	})
+	if got := searches.Load(); got != 1 {
+		t.Fatalf("expected one search for a burst of saves, got %d", got)
+	}
+
+	// the tool's own save doesn't trigger another run
+	processed := details.Load()
+	time.Sleep(5 * watchDebounce)
+	if got := details.Load(); got != processed {
+		t.Fatalf("expected the tool's own write to be ignored, got %d more requests", got-processed)
+	}
+
+	// a later edit by the user does
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	writeNote(t, dir, "Heat.md", string(data)+"\nwatched again\n")
+	waitFor("the edited note to be processed", func() bool { return details.Load() > processed })
+}
+
+func TestRunDownloadsBanner(t *testing.T) {
+	dir := t.TempDir()
+	var backdrop bytes.Buffer
+	if err := png.Encode(&backdrop, image.NewRGBA(image.Rect(0, 0, 8, 4))); err != nil {
+		t.Fatalf("encode backdrop: %v", err)
+	}
+	var backdrops atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/backdrop.png":
+			backdrops.Add(1)
+			_, _ = w.Write(backdrop.Bytes())
+		case strings.HasSuffix(req.URL.Path, ".png"):
+			_, _ = w.Write(backdrop.Bytes())
+		default:
+			_, _ = w.Write([]byte(`{"id": 949, "title": "Heat", "poster_path": "/heat.png", "backdrop_path": "/backdrop.png"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL))
+
+	missing := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\ntmdb_id: 949\ntmdb_type: movie\nbanner: attachments/Gone - banner.jpg\n---\n")
+	remote := writeNote(t, dir, "Ronin.md", "---\ntitle: Ronin\ntmdb_id: 949\ntmdb_type: movie\nbanner: https://example.com/ronin.jpg\n---\n")
+
+	if _, err := NewRunner(client, Config{Path: dir, Only: []string{OpCover}, Backdrop: true}).Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := backdrops.Load(); got != 1 {
+		t.Fatalf("expected only the missing banner to be downloaded, got %d downloads", got)
+	}
+	data, err := os.ReadFile(missing)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	if !strings.Contains(string(data), "banner: attachments/Heat - 949 - banner.jpg") {
+		t.Fatalf("expected the missing banner to be replaced, got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "attachments", "Heat - 949 - banner.jpg")); err != nil {
+		t.Fatalf("expected the banner file: %v", err)
+	}
+	if data, _ := os.ReadFile(remote); !strings.Contains(string(data), "banner: https://example.com/ronin.jpg") {
+		t.Fatalf("expected the remote banner to be kept, got:\n%s", data)
+	}
+}
+
+func TestConfident(t *testing.T) {
+	tests := []struct {
+		name     string
+		minVotes int
+		result   tmdb.SearchResult
+		want     bool
+	}{
+		{"no minimum", 0, tmdb.SearchResult{MediaType: "movie"}, true},
+		{"enough votes", 50, tmdb.SearchResult{MediaType: "movie", VoteCount: 50}, true},
+		{"too few votes", 50, tmdb.SearchResult{MediaType: "tv", VoteCount: 49}, false},
+		{"person", 50, tmdb.SearchResult{MediaType: "person"}, true},
+	}
+	for _, tt := range tests {
+		runner := NewRunner(nil, Config{MinVotes: tt.minVotes})
+		if got := runner.confident(tt.result); got != tt.want {
+			t.Fatalf("%s: confident() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunMinVotes(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		wantStored bool
+		wantSkip   bool
+		wantOutput string
+	}{
+		{"no minimum", Config{}, true, false, "Found movie: Heat"},
+		{"auto skips", Config{MinVotes: 10, AutoSelect: AutoSelectFirst}, false, true, "Only match Heat has 0 votes (--min-votes 10), skipping"},
+		{"confirm without a terminal", Config{MinVotes: 10}, false, true, "Found 1 results but no terminal is attached"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\n---\n")
+			client, searches := newStubTMDB(t)
+
+			cfg := tt.cfg
+			cfg.Path = dir
+			// skips are warnings, shown even in compact progress mode
+			cfg.Verbose = !tt.wantSkip
+			cfg.Progress = true
+			var output bytes.Buffer
+			cfg.Output = &output
+			summary, err := NewRunner(client, cfg).Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if searches.Load() != 1 {
+				t.Fatalf("expected one search, got %d", searches.Load())
+			}
+			if !strings.Contains(output.String(), tt.wantOutput) {
+				t.Fatalf("expected %q in output:\n%s", tt.wantOutput, output.String())
+			}
+			if warned := len(summary.Outcomes[0].Warnings) > 0; warned != tt.wantSkip {
+				t.Fatalf("expected a skip warning %v, got %+v", tt.wantSkip, summary.Outcomes[0])
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read note: %v", err)
+			}
+			if stored := strings.Contains(string(data), "tmdb_id: 949"); stored != tt.wantStored {
+				t.Fatalf("expected stored match %v, got:\n%s", tt.wantStored, data)
+			}
+		})
+	}
+}
+
+func TestParseAutoSelect(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    AutoSelect
+		wantErr bool
+	}{
+		{"", "", false},
+		{"first", AutoSelectFirst, false},
+		{" Best ", AutoSelectBest, false},
+		{"SKIP", AutoSelectSkip, false},
+		{"random", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAutoSelect(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("ParseAutoSelect(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAutoSelect(t *testing.T) {
+	results := []tmdb.SearchResult{
+		{ID: 1, Popularity: 10, VoteAverage: 7},
+		{ID: 2, Popularity: 30, VoteAverage: 6},
+		{ID: 3, Popularity: 30, VoteAverage: 8},
+		{ID: 4, Popularity: 30, VoteAverage: 8},
+		{ID: 5, Popularity: 20, VoteAverage: 9},
+	}
+	tests := []struct {
+		policy  AutoSelect
+		results []tmdb.SearchResult
+		wantID  int
+		wantOK  bool
+	}{
+		{AutoSelectFirst, results, 1, true},
+		// most popular, ties broken by vote average, then by TMDB order
+		{AutoSelectBest, results, 3, true},
+		{AutoSelectBest, results[:1], 1, true},
+		{AutoSelectSkip, results, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := autoSelect(tt.policy, tt.results)
+		if ok != tt.wantOK || got.ID != tt.wantID {
+			t.Fatalf("autoSelect(%q) = %d, %v; want %d, %v", tt.policy, got.ID, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
+
+func TestRunWarnsAboutSkippedAmbiguousNotes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [` +
+			`{"id": 949, "media_type": "movie", "title": "Heat", "poster_path": "/heat.png"},` +
+			`{"id": 11, "media_type": "movie", "title": "Heat", "poster_path": "/heat86.png"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL))
+
+	tests := map[AutoSelect]string{
+		AutoSelectSkip: "Found 2 results, skipping ambiguous note",
+		"":             "Found 2 results but no terminal is attached, skipping (use --auto to choose)",
+	}
+	for policy, want := range tests {
+		dir := t.TempDir()
+		writeNote(t, dir, "Heat.md", "---\ntitle: Heat\n---\n")
+		var output bytes.Buffer
+		summary, err := NewRunner(client, Config{Path: dir, AutoSelect: policy, Progress: true, Output: &output}).Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if warnings := summary.Outcomes[0].Warnings; !slices.Equal(warnings, []string{want}) {
+			t.Fatalf("policy %q: expected warning %q, got %v", policy, want, warnings)
+		}
+		if !strings.Contains(output.String(), "⚠️  "+want) {
+			t.Fatalf("policy %q: expected the warning in compact output, got:\n%s", policy, output.String())
+		}
+	}
+}
+
+func TestRunWritesEpisodeRuntime(t *testing.T) {
+	dir := t.TempDir()
+	path := writeNote(t, dir, "Breaking Bad.md", "---\ntitle: Breaking Bad\ntmdb_id: 1396\ntmdb_type: tv\n---\n")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/tv/1396" {
+			_, _ = w.Write([]byte(`{"id": 1396, "name": "Breaking Bad", "episode_run_time": [47, 58], "number_of_episodes": 62}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL))
+
+	if _, err := NewRunner(client, Config{Path: dir, Only: []string{OpMetadata}}).Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	for _, want := range []string{"episode_runtime: 47", "total_episodes: 62"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in frontmatter, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestShowChangesImpliesVerbose(t *testing.T) {
+	for _, showChanges := range []bool{false, true} {
+		dir := t.TempDir()
+		writeNote(t, dir, "Heat.md", "---\ntitle: Heat\n---\n")
+		client, _ := newStubTMDB(t)
+
+		var output bytes.Buffer
+		_, err := NewRunner(client, Config{Path: dir, Only: []string{OpMetadata}, Progress: true, ShowChanges: showChanges, Output: &output}).Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if got := strings.Contains(output.String(), "+ tmdb_id: 949"); got != showChanges {
+			t.Fatalf("ShowChanges %v: expected changes listed %v in progress mode, got:\n%s", showChanges, showChanges, output.String())
+		}
+	}
+}
+
+func TestCollectionFetchFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{"server error", http.StatusInternalServerError, nil},
+		{"unauthorized", http.StatusUnauthorized, ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\ntmdb_id: 949\ntmdb_type: movie\n---\n")
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/collection/10" {
+					w.WriteHeader(tt.status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"id": 949, "title": "Heat", "overview": "A heist.", "belongs_to_collection": {"id": 10, "name": "Heat Collection"}}`))
+			}))
+			t.Cleanup(server.Close)
+			client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithRetryAttempts(1))
+
+			runner := NewRunner(client, Config{
+				Path:            dir,
+				GenerateContent: true,
+				Only:            []string{OpContent},
+				ContentSections: []string{"overview", "collection"},
+			})
+			outcome, err := runner.ProcessFile(context.Background(), path, filepath.Join(dir, "attachments"))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessFile failed: %v", err)
+			}
+			if !slices.Contains(outcome.Updated, "content") {
+				t.Fatalf("expected the other sections to be generated, got %+v", outcome)
+			}
+			if len(outcome.Warnings) != 1 || !strings.HasPrefix(outcome.Warnings[0], "Failed to fetch the collection") {
+				t.Fatalf("expected a collection warning, got %v", outcome.Warnings)
+			}
+		})
+	}
+}
+
+func TestUpdateBannerKeepsLegacyFilename(t *testing.T) {
+	dir := t.TempDir()
+	attachmentsDir := filepath.Join(dir, "attachments")
+	if err := os.MkdirAll(attachmentsDir, 0o755); err != nil {
+		t.Fatalf("create attachments: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(attachmentsDir, "Heat - banner.jpg"), []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("write banner: %v", err)
+	}
+	var backdrop bytes.Buffer
+	if err := png.Encode(&backdrop, image.NewRGBA(image.Rect(0, 0, 8, 4))); err != nil {
+		t.Fatalf("encode backdrop: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, ".png") {
+			_, _ = w.Write(backdrop.Bytes())
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": 949, "title": "Heat", "poster_path": "/heat.png", "backdrop_path": "/backdrop.png"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL))
+
+	legacy := writeNote(t, dir, "Heat.md", "---\ntitle: Heat\ncover: attachments/Heat - cover.jpg\nbanner: attachments/Heat - banner.jpg\ntmdb_id: 949\ntmdb_type: movie\n---\n")
+	fresh := writeNote(t, dir, "Heat (1995).md", "---\ntitle: Heat\ncover: attachments/Heat - cover.jpg\ntmdb_id: 949\ntmdb_type: movie\n---\n")
+
+	runner := NewRunner(client, Config{Path: dir, Only: []string{OpCover}, Backdrop: true, ForceCover: true})
+	for _, path := range []string{legacy, fresh} {
+		if _, err := runner.ProcessFile(context.Background(), path, attachmentsDir); err != nil {
+			t.Fatalf("ProcessFile failed: %v", err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		legacy: "banner: attachments/Heat - banner.jpg",
+		fresh:  "banner: attachments/Heat - 949 - banner.jpg",
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read note: %v", err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in %s, got:\n%s", want, filepath.Base(path), data)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(attachmentsDir, "Heat - banner.jpg")); err != nil || string(data) == "jpg" {
+		t.Fatalf("expected the legacy banner to be re-downloaded in place (%v)", err)
+	}
+}
+
+func TestOnlyCoverSkipsMetadataAndContent(t *testing.T) {
+	// a match is remembered even in cover-only runs; nothing else is added
+	wantKeys := []string{"cover", "cover_source", "title", "tmdb_id", "tmdb_type"}
+	tests := map[string]string{
+		"stored ID": "---\ntitle: Heat\ntmdb_id: 949\ntmdb_type: movie\n---\n\nMy notes.\n",
+		"searched":  "---\ntitle: Heat\n---\n\nMy notes.\n",
+	}
+	for name, initial := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeNote(t, dir, "Heat.md", initial)
+
+			var poster bytes.Buffer
+			if err := png.Encode(&poster, image.NewRGBA(image.Rect(0, 0, 4, 6))); err != nil {
+				t.Fatalf("encode poster: %v", err)
+			}
+			var mu sync.Mutex
+			var requests []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mu.Lock()
+				requests = append(requests, req.URL.Path+"?"+req.URL.Query().Get("append_to_response"))
+				mu.Unlock()
+				switch {
+				case strings.HasSuffix(req.URL.Path, ".png"):
+					_, _ = w.Write(poster.Bytes())
+				case strings.HasPrefix(req.URL.Path, "/search/"):
+					_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [{"id": 949, "media_type": "movie", "title": "Heat", "poster_path": "/heat.png"}]}`))
+				default:
+					_, _ = w.Write([]byte(`{"id": 949, "title": "Heat", "poster_path": "/heat.png", "runtime": 170, "overview": "A heist.", "genres": [{"id": 80, "name": "Crime"}]}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+			client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithImageBaseURL(server.URL))
+
+			runner := NewRunner(client, Config{Path: dir, Only: []string{OpCover}, GenerateContent: true})
+			outcome, err := runner.ProcessFile(context.Background(), path, filepath.Join(dir, "attachments"))
+			if err != nil {
+				t.Fatalf("ProcessFile failed: %v", err)
+			}
+			if !slices.Equal(outcome.Updated, []string{"cover"}) {
+				t.Fatalf("expected only the cover to be updated, got %+v", outcome)
+			}
+			for _, request := range requests {
+				if strings.Contains(request, "release_dates") || strings.Contains(request, "credits") {
+					t.Fatalf("expected no metadata request, got %v", requests)
+				}
+			}
+
+			n, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("load note: %v", err)
+			}
+			keys := slices.Sorted(maps.Keys(n.Frontmatter()))
+			if !slices.Equal(keys, wantKeys) {
+				t.Fatalf("expected frontmatter keys %v, got %v", wantKeys, keys)
+			}
+			if body := strings.TrimSpace(n.Body()); body != "My notes." {
+				t.Fatalf("expected the body to be left alone, got:\n%s", n.Body())
+			}
+		})
+	}
+}
+
+func TestRunnerPrefersLastChosenType(t *testing.T) {
+	originalInteractive, originalSelect := interactive, selectResult
+	t.Cleanup(func() { interactive, selectResult = originalInteractive, originalSelect })
+
+	var preferred []string
+	interactive = func() bool { return true }
+	selectResult = func(_ string, results []tmdb.SearchResult, preferType string, _ ...tui.Option) (tui.SelectionResult, error) {
+		preferred = append(preferred, preferType)
+		for _, result := range results {
+			if result.MediaType == "tv" {
+				return tui.SelectionResult{Action: tui.ActionSelected, Selection: &result}, nil
+			}
+		}
+		return tui.SelectionResult{Action: tui.ActionSkipped}, nil
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/search/") {
+			_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [` +
+				`{"id": 949, "media_type": "movie", "title": "Heat", "poster_path": "/heat.png"},` +
+				`{"id": 1396, "media_type": "tv", "name": "Heat", "poster_path": "/heat-tv.png"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL))
+
+	dir := t.TempDir()
+	runner := NewRunner(client, Config{Path: dir, Only: []string{OpMetadata}})
+	for _, name := range []string{"Heat.md", "Heat Again.md"} {
+		path := writeNote(t, dir, name, "---\ntitle: Heat\n---\n")
+		if _, err := runner.ProcessFile(context.Background(), path, filepath.Join(dir, "attachments")); err != nil {
+			t.Fatalf("ProcessFile failed: %v", err)
+		}
+	}
+	if !slices.Equal(preferred, []string{"", "tv"}) {
+		t.Fatalf("expected the second selector to prefer the type picked first, got %q", preferred)
+	}
+}
+
+func TestUnauthorizedCountsNoteAsFailed(t *testing.T) {
+	tests := []struct {
+		name         string
+		note         string
+		unauthorized string
+		cfg          Config
+	}{
+		{"search", "---\ntitle: Heat\n---\n", "/search/multi", Config{}},
+		{"content", "---\ntitle: Heat\ntmdb_id: 949\ntmdb_type: movie\n---\n", "/collection/10", Config{
+			GenerateContent: true,
+			Only:            []string{OpContent},
+			ContentSections: []string{"overview", "collection"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeNote(t, dir, "Heat.md", tt.note)
+			writeNote(t, dir, "Ronin.md", "---\ntitle: Ronin\n---\n")
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == tt.unauthorized {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write([]byte(`{"id": 949, "title": "Heat", "overview": "A heist.", "belongs_to_collection": {"id": 10, "name": "Heat Collection"}}`))
+			}))
+			t.Cleanup(server.Close)
+			client := tmdb.NewClient("key", tmdb.WithBaseURL(server.URL), tmdb.WithRetryAttempts(1))
+
+			cfg := tt.cfg
+			cfg.Path = dir
+			summary, err := NewRunner(client, cfg).Run(context.Background())
+			if !errors.Is(err, ErrUnauthorized) {
+				t.Fatalf("expected ErrUnauthorized, got %v", err)
+			}
+			if summary.Failed != 1 || summary.Processed != 0 || len(summary.Outcomes) != 1 {
+				t.Fatalf("expected only the note that hit the 401 counted as failed, got %+v", summary)
+			}
+			if outcome := summary.Outcomes[0]; outcome.Status != OutcomeFailed || len(outcome.Errors) == 0 {
+				t.Fatalf("expected a failed outcome with the error, got %+v", outcome)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
+)
+
+// CheckConfig holds the options for auditing a vault.
+type CheckConfig struct {
+	Path   string
+	KeyMap note.KeyMap
+	// JSON writes the report as a JSON array instead of text.
+	JSON bool
+	// AttachmentsDir is where covers are looked up; "<vault>/attachments"
+	// when empty.
+	AttachmentsDir string
+	// TemplateFolder names the folder holding Obsidian templates, which
+	// are left out of the report like the main run skips them.
+	TemplateFolder string
+}
+
+// CheckResult lists what one incomplete note is missing.
+type CheckResult struct {
+	Path string `json:"path"`
+	// Missing holds "cover", "metadata", and/or "tmdb_id". Notes that could
+	// not be read report "unreadable" with the reason in Error.
+	Missing []string `json:"missing"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Check reports notes that lack a cover, metadata, or a TMDB ID without
+// touching them or making any TMDB requests. Template notes are skipped. It
+// returns the number of incomplete notes.
+func Check(cfg CheckConfig, w io.Writer) (int, error) {
+	files, vaultPath, err := collectNotes(cfg.Path)
+	if err != nil {
+		return 0, err
+	}
+	attachmentsDir := resolveAttachmentsDir(vaultPath, cfg.AttachmentsDir)
+
+	results := make([]CheckResult, 0)
+	checked := 0
+	for _, file := range files {
+		n, err := note.LoadWithKeyMap(file, cfg.KeyMap)
+		if err != nil {
+			checked++
+			results = append(results, CheckResult{Path: file, Missing: []string{"unreadable"}, Error: err.Error()})
+			continue
+		}
+		if templateReason(n, cfg.Path, cfg.TemplateFolder) != "" {
+			continue
+		}
+		checked++
+
+		var missing []string
+		if n.NeedsCover() {
+			missing = append(missing, "cover")
+		} else if _, ok := n.ResolveLocalCover(filepath.Dir(file), attachmentsDir); !ok {
+			missing = append(missing, "cover")
+		}
+		if n.NeedsMetadata() {
+			missing = append(missing, "metadata")
+		}
+		if n.NeedsTMDB() {
+			missing = append(missing, "tmdb_id")
+		}
+		if len(missing) > 0 {
+			results = append(results, CheckResult{Path: file, Missing: missing})
+		}
+	}
+
+	if cfg.JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return len(results), encoder.Encode(results)
+	}
+
+	for _, result := range results {
+		line := fmt.Sprintf("%s: missing %s", result.Path, strings.Join(result.Missing, ", "))
+		if result.Error != "" {
+			line = fmt.Sprintf("%s: %s", result.Path, result.Error)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return len(results), err
+		}
+	}
+	_, err = fmt.Fprintf(w, "\n%d of %d notes are incomplete\n", len(results), checked)
+	return len(results), err
+}
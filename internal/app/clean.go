@@ -0,0 +1,135 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
+)
+
+// generatedImagePattern matches the file names this tool gives covers and
+// banners, e.g. "Heat - 949 - cover.jpg". Other images in the attachments
+// directory are never considered orphans.
+var generatedImagePattern = regexp.MustCompile(`(?i)^.+ - (cover|banner)\.(jpe?g|png)$`)
+
+// CleanConfig holds the options for finding orphaned covers.
+type CleanConfig struct {
+	// Path is the vault directory; every note in it is scanned for
+	// references.
+	Path   string
+	KeyMap note.KeyMap
+	// AttachmentsDir is the directory searched for orphans. When empty, the
+	// attachments folder inside the vault is used.
+	AttachmentsDir string
+	// Delete removes the orphaned files; otherwise they are only listed.
+	Delete bool
+}
+
+// CleanOrphans lists covers and banners in the attachments directory that no
+// note references and, with cfg.Delete, removes them. Only files named like
+// the ones this tool downloads are considered. References in the cover and
+// banner keys and image embeds in note bodies count. Like Obsidian, a bare
+// file name refers to a file anywhere in the vault, so any reference with
+// the same file name keeps an image. It returns the number of orphans found.
+func CleanOrphans(cfg CleanConfig, w io.Writer) (int, error) {
+	info, err := os.Stat(cfg.Path)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		// a single note can't tell whether other notes use an image
+		return 0, fmt.Errorf("clean-orphans needs the vault directory: %s", cfg.Path)
+	}
+	files, vaultPath, err := collectNotes(cfg.Path)
+	if err != nil {
+		return 0, err
+	}
+	attachmentsDir := resolveAttachmentsDir(vaultPath, cfg.AttachmentsDir)
+
+	referenced := make(map[string]bool)
+	// referencedNames holds the lowercased file names of every reference
+	referencedNames := make(map[string]bool)
+	unreadable := 0
+	for _, file := range files {
+		n, err := note.LoadWithKeyMap(file, cfg.KeyMap)
+		if err != nil {
+			if _, err := fmt.Fprintf(w, "⚠️  %s: %v\n", file, err); err != nil {
+				return 0, err
+			}
+			unreadable++
+			continue
+		}
+		for _, image := range n.LocalImages(filepath.Dir(file), attachmentsDir, vaultPath) {
+			if abs, err := filepath.Abs(image); err == nil {
+				referenced[abs] = true
+			}
+		}
+		for _, ref := range n.ImageReferences() {
+			referencedNames[strings.ToLower(path.Base(filepath.ToSlash(ref)))] = true
+			if decoded, err := url.PathUnescape(ref); err == nil {
+				referencedNames[strings.ToLower(path.Base(filepath.ToSlash(decoded)))] = true
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(attachmentsDir)
+	if errors.Is(err, os.ErrNotExist) {
+		_, err = fmt.Fprintf(w, "No attachments directory at %s\n", attachmentsDir)
+		return 0, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	var orphans []string
+	for _, entry := range entries {
+		if entry.IsDir() || !generatedImagePattern.MatchString(entry.Name()) {
+			continue
+		}
+		if referencedNames[strings.ToLower(entry.Name())] {
+			continue
+		}
+		image := filepath.Join(attachmentsDir, entry.Name())
+		if abs, err := filepath.Abs(image); err == nil && !referenced[abs] {
+			orphans = append(orphans, image)
+		}
+	}
+	sort.Strings(orphans)
+
+	// a note that can't be read may reference any of the orphans
+	remove := cfg.Delete && unreadable == 0
+	removed := 0
+	for _, orphan := range orphans {
+		line := orphan
+		if remove {
+			if err := os.Remove(orphan); err != nil {
+				line = fmt.Sprintf("%s: %v", orphan, err)
+			} else {
+				line = "Removed " + orphan
+				removed++
+			}
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return len(orphans), err
+		}
+	}
+
+	switch {
+	case remove:
+		_, err = fmt.Fprintf(w, "\nRemoved %d of %d orphaned image(s)\n", removed, len(orphans))
+	case cfg.Delete && len(orphans) > 0:
+		_, err = fmt.Fprintf(w, "\nFound %d orphaned image(s); nothing removed because %d note(s) could not be read\n", len(orphans), unreadable)
+	case len(orphans) > 0:
+		_, err = fmt.Fprintf(w, "\nFound %d orphaned image(s); run with --delete to remove them\n", len(orphans))
+	default:
+		_, err = fmt.Fprintln(w, "No orphaned images found")
+	}
+	return len(orphans), err
+}
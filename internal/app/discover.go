@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tui"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/util"
+)
+
+// DiscoverConfig holds the options for browsing TMDB and creating stub notes.
+type DiscoverConfig struct {
+	// Dir is where new notes are written.
+	Dir       string
+	MediaType string
+	// Genre is a genre name, matched as by tmdb.Client.GenreIDByName.
+	Genre  string
+	Year   int
+	SortBy string
+	KeyMap note.KeyMap
+}
+
+// Discover lets the user browse TMDB by genre and year and writes a stub
+// note for every title they pick. The stubs hold only the title and TMDB
+// ID; a regular run over Dir then fills in covers and metadata.
+func Discover(ctx context.Context, client *tmdb.Client, cfg DiscoverConfig) error {
+	params := tmdb.DiscoverParams{
+		MediaType: cfg.MediaType,
+		Year:      cfg.Year,
+		SortBy:    cfg.SortBy,
+	}
+	if cfg.Genre != "" {
+		id, ok, err := client.GenreIDByName(ctx, cfg.MediaType, cfg.Genre)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("unknown %s genre: %s", mapMediaType(cfg.MediaType), cfg.Genre)
+		}
+		params.GenreID = id
+	}
+
+	response, err := client.Discover(ctx, params)
+	if err != nil {
+		return err
+	}
+	if len(response.Results) == 0 {
+		fmt.Println("No results found")
+		return nil
+	}
+
+	header := discoverHeader(cfg)
+	keys := cfg.KeyMap.WithDefaults()
+	pages := newDiscoverPages(response, func(page int) (tmdb.SearchResponse, error) {
+		next := params
+		next.Page = page
+		return client.Discover(ctx, next)
+	})
+	created := 0
+	for {
+		results, page, totalPages := pages.state()
+		if len(results) == 0 {
+			break
+		}
+		selection, err := tui.Select(header, results,
+			tui.WithHeader(header),
+			tui.WithPosters(func(posterPath string) ([]byte, error) {
+				return client.FetchImage(ctx, client.ThumbnailURL(posterPath))
+			}),
+			tui.WithPagination(page, totalPages, pages.load),
+		)
+		if err != nil {
+			return err
+		}
+		if selection.Action != tui.ActionSelected || selection.Selection == nil {
+			break
+		}
+
+		chosen := *selection.Selection
+		path, err := createStubNote(cfg.Dir, keys, chosen)
+		switch {
+		case errors.Is(err, fs.ErrExist):
+			fmt.Printf("  Note already exists: %s\n", path)
+		case err != nil:
+			return err
+		default:
+			fmt.Printf("  ✓ Created %s\n", path)
+			created++
+		}
+
+		pages.remove(chosen.ID)
+	}
+
+	fmt.Printf("\nCreated %d note(s) in %s\n", created, cfg.Dir)
+	if created > 0 {
+		fmt.Println("Run obsidian-tmdb-cover on the directory to fetch covers and metadata.")
+	}
+	return nil
+}
+
+func discoverHeader(cfg DiscoverConfig) string {
+	header := "Discover " + mapMediaType(cfg.MediaType)
+	if cfg.Genre != "" {
+		header += " / " + cfg.Genre
+	}
+	if cfg.Year > 0 {
+		header += " / " + strconv.Itoa(cfg.Year)
+	}
+	return header
+}
+
+func createStubNote(dir string, keys note.KeyMap, result tmdb.SearchResult) (string, error) {
+	path := filepath.Join(dir, util.SanitizeFilename(result.DisplayTitle())+".md")
+	n := note.New(path, map[string]any{
+		keys.Title:    result.DisplayTitle(),
+		keys.TMDBID:   result.ID,
+		keys.TMDBType: result.MediaType,
+	})
+	return path, n.Create(false)
+}
+
+// discoverPages holds every discover result loaded so far, including pages
+// loaded with "n", so the selector reopens with all of them after a pick.
+type discoverPages struct {
+	fetch func(page int) (tmdb.SearchResponse, error)
+	// mu guards the fields below; pages load while the selector runs
+	mu         sync.Mutex
+	results    []tmdb.SearchResult
+	page       int
+	totalPages int
+}
+
+func newDiscoverPages(first tmdb.SearchResponse, fetch func(page int) (tmdb.SearchResponse, error)) *discoverPages {
+	return &discoverPages{fetch: fetch, results: first.Results, page: first.Page, totalPages: first.TotalPages}
+}
+
+// load fetches page and keeps its results. It is the selector's page loader.
+func (d *discoverPages) load(page int) (tmdb.SearchResponse, error) {
+	response, err := d.fetch(page)
+	if err != nil {
+		return response, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if response.Page > d.page {
+		d.results = append(d.results, response.Results...)
+		d.page, d.totalPages = response.Page, response.TotalPages
+	}
+	return response, nil
+}
+
+// state returns the remaining results and the last loaded page.
+func (d *discoverPages) state() ([]tmdb.SearchResult, int, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.results), d.page, d.totalPages
+}
+
+// remove drops a picked result so it isn't offered again.
+func (d *discoverPages) remove(id int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.results = removeResult(d.results, id)
+}
+
+func removeResult(results []tmdb.SearchResult, id int) []tmdb.SearchResult {
+	remaining := make([]tmdb.SearchResult, 0, len(results))
+	for _, result := range results {
+		if result.ID != id {
+			remaining = append(remaining, result)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,280 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+)
+
+// ImportEntry is one title read from an import file.
+type ImportEntry struct {
+	Title string
+	Year  int
+	// TMDBID and MediaType skip the search when the export already knows
+	// the TMDB title. MediaType defaults to "movie".
+	TMDBID    int
+	MediaType string
+}
+
+// Import creates a note in dir for every entry in the CSV or JSON file at
+// source, then runs the normal pipeline over the new notes. Entries whose
+// note already exists are skipped. Entries with only a TMDB ID take their
+// title from TMDB. Entries without a TMDB ID are searched;
+// a single match for the entry's year is stored directly, otherwise the
+// usual selector is shown while processing. The summary covers the
+// processing of the created notes.
+func (r *Runner) Import(ctx context.Context, source, dir string) (RunSummary, error) {
+	entries, err := ReadImportFile(source)
+	if err != nil {
+		return RunSummary{}, err
+	}
+	r.printf("Read %d entries from %s\n", len(entries), filepath.Base(source))
+
+	keys := r.cfg.KeyMap.WithDefaults()
+	var (
+		created  []string
+		existing int
+		failed   int
+	)
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		if strings.TrimSpace(entry.Title) == "" && entry.TMDBID == 0 {
+			failed++
+			continue
+		}
+
+		if entry.TMDBID == 0 {
+			r.matchImportEntry(ctx, &entry)
+		} else if strings.TrimSpace(entry.Title) == "" {
+			if err := r.lookupImportTitle(ctx, &entry); err != nil {
+				r.printf("  ✗ Failed to look up the title of TMDB %s %d: %v\n", entry.MediaType, entry.TMDBID, err)
+				failed++
+				continue
+			}
+		}
+
+		path := filepath.Join(dir, r.sanitizeFilename(entry.Title, entry.TMDBID)+".md")
+		frontmatter := map[string]any{keys.Title: entry.Title}
+		if entry.TMDBID > 0 {
+			frontmatter[keys.TMDBID] = entry.TMDBID
+			frontmatter[keys.TMDBType] = entry.MediaType
+		}
+
+		err := note.New(path, frontmatter).Create(false)
+		switch {
+		case errors.Is(err, fs.ErrExist):
+			r.detailf("  Skipping %s: note already exists\n", entry.Title)
+			existing++
+		case err != nil:
+			r.printf("  ✗ Failed to create %s: %v\n", entry.Title, err)
+			failed++
+		default:
+			created = append(created, path)
+		}
+	}
+
+	r.printf("\n=== Import ===\n")
+	r.printf("Created: %d\n", len(created))
+	r.printf("Already existed: %d\n", existing)
+	r.printf("Failed: %d\n", failed)
+
+	if len(created) == 0 {
+		return RunSummary{}, ctx.Err()
+	}
+	return r.processFiles(ctx, created, dir)
+}
+
+// matchImportEntry stores the TMDB ID on entry when exactly one search
+// result matches its title (and year, when known).
+func (r *Runner) matchImportEntry(ctx context.Context, entry *ImportEntry) {
+	response, err := r.client.SearchByType(ctx, entry.Title, r.cfg.MediaType, 1, r.resultLimit())
+	if err != nil {
+		r.printf("  ✗ Search failed for %s: %v\n", entry.Title, err)
+		return
+	}
+
+	var matches []tmdb.SearchResult
+	for _, result := range response.Results {
+		if entry.Year == 0 || result.Year() == strconv.Itoa(entry.Year) {
+			matches = append(matches, result)
+		}
+	}
+	if len(matches) == 1 {
+		entry.TMDBID = matches[0].ID
+		entry.MediaType = matches[0].MediaType
+	}
+}
+
+// lookupImportTitle sets the title of an entry that only has a TMDB ID from
+// the title's TMDB details.
+func (r *Runner) lookupImportTitle(ctx context.Context, entry *ImportEntry) error {
+	var (
+		details map[string]any
+		err     error
+	)
+	if entry.MediaType == "tv" {
+		details, err = r.client.GetTVDetails(ctx, entry.TMDBID, "")
+	} else {
+		details, err = r.client.GetMovieDetails(ctx, entry.TMDBID)
+	}
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"title", "name"} {
+		if title, _ := details[key].(string); strings.TrimSpace(title) != "" {
+			entry.Title = strings.TrimSpace(title)
+			return nil
+		}
+	}
+	return errors.New("TMDB returned no title")
+}
+
+// ReadImportFile reads entries from a CSV or JSON file, chosen by extension.
+func ReadImportFile(path string) ([]ImportEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return readImportCSV(file)
+	case ".json":
+		return readImportJSON(file)
+	default:
+		return nil, fmt.Errorf("unsupported import file type: %s (expected .csv or .json)", filepath.Ext(path))
+	}
+}
+
+// readImportCSV reads a CSV with a header row. Column names are matched
+// case-insensitively, so Letterboxd ("Name", "Year") and Trakt style
+// ("title", "year", "tmdb_id") exports both work.
+func readImportCSV(r io.Reader) ([]ImportEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[normalizeColumn(name)] = i
+	}
+	titleCol, ok := firstColumn(columns, "title", "name")
+	if !ok {
+		return nil, errors.New("CSV needs a title or name column")
+	}
+	yearCol, hasYear := firstColumn(columns, "year")
+	idCol, hasID := firstColumn(columns, "tmdb_id", "tmdbid", "tmdb")
+	typeCol, hasType := firstColumn(columns, "type", "media_type", "tmdb_type")
+
+	var entries []ImportEntry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(col int, ok bool) string {
+			if !ok || col >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col])
+		}
+		entry := ImportEntry{Title: field(titleCol, true)}
+		entry.Year, _ = strconv.Atoi(field(yearCol, hasYear))
+		entry.TMDBID, _ = strconv.Atoi(field(idCol, hasID))
+		entry.MediaType = importMediaType(field(typeCol, hasType))
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// readImportJSON reads an array of objects with title, year, tmdb_id, and
+// type fields. Numbers may be given as JSON numbers or strings.
+func readImportJSON(r io.Reader) ([]ImportEntry, error) {
+	var raw []map[string]any
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode JSON: %w", err)
+	}
+
+	entries := make([]ImportEntry, 0, len(raw))
+	for _, obj := range raw {
+		fields := make(map[string]any, len(obj))
+		for key, value := range obj {
+			fields[normalizeColumn(key)] = value
+		}
+		entry := ImportEntry{
+			Title:     jsonString(fields, "title", "name"),
+			Year:      jsonInt(fields, "year"),
+			TMDBID:    jsonInt(fields, "tmdb_id", "tmdbid", "tmdb"),
+			MediaType: importMediaType(jsonString(fields, "type", "media_type", "tmdb_type")),
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+func firstColumn(columns map[string]int, names ...string) (int, bool) {
+	for _, name := range names {
+		if col, ok := columns[name]; ok {
+			return col, true
+		}
+	}
+	return 0, false
+}
+
+func importMediaType(value string) string {
+	switch strings.ToLower(value) {
+	case "tv", "show", "series":
+		return "tv"
+	default:
+		return "movie"
+	}
+}
+
+func jsonString(fields map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := fields[key].(string); ok && value != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func jsonInt(fields map[string]any, keys ...string) int {
+	for _, key := range keys {
+		switch value := fields[key].(type) {
+		case float64:
+			return int(value)
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
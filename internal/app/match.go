@@ -0,0 +1,87 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+)
+
+// MatchConfidence rates how closely a search match's title fits the query
+// it was found with.
+type MatchConfidence string
+
+const (
+	// MatchExact is a title equal to the query.
+	MatchExact MatchConfidence = "exact"
+	// MatchCaseInsensitive is a title equal to the query apart from case
+	// and spacing.
+	MatchCaseInsensitive MatchConfidence = "case-insensitive"
+	// MatchPartial is a title containing the query, or contained in it.
+	MatchPartial MatchConfidence = "partial"
+	// MatchPicked is an unrelated title chosen among several results, by
+	// the user or --auto.
+	MatchPicked MatchConfidence = "picked"
+	// MatchDifferent is the only result, with an unrelated title.
+	MatchDifferent MatchConfidence = "different"
+)
+
+// Low reports whether the match is worth reviewing: anything short of an
+// exact or case-insensitive title match.
+func (c MatchConfidence) Low() bool {
+	return c != MatchExact && c != MatchCaseInsensitive
+}
+
+// Match records the search result a note was matched to in this run.
+type Match struct {
+	Query      string          `json:"query"`
+	Title      string          `json:"title"`
+	TMDBID     int             `json:"tmdb_id"`
+	MediaType  string          `json:"tmdb_type"`
+	Confidence MatchConfidence `json:"confidence"`
+	// Candidates is the number of results the match was chosen from.
+	Candidates int `json:"candidates"`
+}
+
+// newMatch rates chosen, found with query among candidates results.
+func newMatch(query string, chosen tmdb.SearchResult, candidates int) *Match {
+	return &Match{
+		Query:      query,
+		Title:      chosen.DisplayTitle(),
+		TMDBID:     chosen.ID,
+		MediaType:  chosen.MediaType,
+		Confidence: matchConfidence(query, chosen.DisplayTitle(), candidates),
+		Candidates: candidates,
+	}
+}
+
+func matchConfidence(query, title string, candidates int) MatchConfidence {
+	query = strings.TrimSpace(query)
+	if title == query {
+		return MatchExact
+	}
+	normalizedQuery := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	normalizedTitle := strings.ToLower(strings.Join(strings.Fields(title), " "))
+	switch {
+	case normalizedTitle == normalizedQuery:
+		return MatchCaseInsensitive
+	case normalizedTitle != "" && normalizedQuery != "" &&
+		(strings.Contains(normalizedTitle, normalizedQuery) || strings.Contains(normalizedQuery, normalizedTitle)):
+		return MatchPartial
+	case candidates > 1:
+		return MatchPicked
+	default:
+		return MatchDifferent
+	}
+}
+
+// LowConfidenceMatches returns the outcomes whose match is worth reviewing,
+// in run order.
+func (s RunSummary) LowConfidenceMatches() []Outcome {
+	var low []Outcome
+	for _, outcome := range s.Outcomes {
+		if outcome.Match != nil && outcome.Match.Confidence.Low() {
+			low = append(low, outcome)
+		}
+	}
+	return low
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+)
+
+// resultPager splits TMDB's search pages (20 results each) into selector
+// pages of limit results. Results left over from a TMDB page are shown
+// before the next TMDB page is fetched, so every result is offered exactly
+// once whatever the limit.
+type resultPager struct {
+	// fetch returns one full TMDB search page.
+	fetch func(tmdbPage int) (tmdb.SearchResponse, error)
+	limit int
+	// pages holds the selector pages built so far.
+	pages [][]tmdb.SearchResult
+	// pending holds fetched results not yet on a selector page.
+	pending   []tmdb.SearchResult
+	tmdbPage  int
+	seen      map[string]bool
+	exhausted bool
+}
+
+func newResultPager(limit int, fetch func(tmdbPage int) (tmdb.SearchResponse, error)) *resultPager {
+	return &resultPager{fetch: fetch, limit: max(1, limit), seen: make(map[string]bool)}
+}
+
+// page returns selector page number page (starting at 1). Page and
+// TotalPages of the response count selector pages; TotalPages is one more
+// than Page while further results remain.
+func (p *resultPager) page(page int) (tmdb.SearchResponse, error) {
+	page = max(1, page)
+	for len(p.pages) < page && !p.done() {
+		if err := p.fill(); err != nil {
+			return tmdb.SearchResponse{}, err
+		}
+		if len(p.pending) == 0 {
+			break
+		}
+		n := min(p.limit, len(p.pending))
+		p.pages = append(p.pages, p.pending[:n:n])
+		p.pending = p.pending[n:]
+	}
+	if page > len(p.pages) {
+		return tmdb.SearchResponse{Page: page, TotalPages: len(p.pages)}, nil
+	}
+	total := len(p.pages)
+	if !p.done() {
+		total++
+	}
+	return tmdb.SearchResponse{Results: p.pages[page-1], Page: page, TotalPages: total}, nil
+}
+
+// fill fetches TMDB pages until a full selector page is pending or TMDB
+// has no more.
+func (p *resultPager) fill() error {
+	for len(p.pending) < p.limit && !p.exhausted {
+		response, err := p.fetch(p.tmdbPage + 1)
+		if err != nil {
+			return err
+		}
+		p.tmdbPage++
+		for _, result := range response.Results {
+			// TMDB can repeat a title on a later page when rankings shift
+			key := fmt.Sprintf("%s/%d", result.MediaType, result.ID)
+			if !p.seen[key] {
+				p.seen[key] = true
+				p.pending = append(p.pending, result)
+			}
+		}
+		if p.tmdbPage >= response.TotalPages {
+			p.exhausted = true
+		}
+	}
+	return nil
+}
+
+// done reports whether every result has been put on a selector page.
+func (p *resultPager) done() bool {
+	return p.exhausted && len(p.pending) == 0
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+)
+
+// ReprocessConfig holds the options for re-resizing existing local covers.
+type ReprocessConfig struct {
+	Path   string
+	KeyMap note.KeyMap
+	// Image is the target size and format for the covers.
+	Image tmdb.ImageOptions
+	// AttachmentsDir is searched for covers in addition to the note's
+	// folder. When empty, the attachments folder inside the vault is used.
+	AttachmentsDir string
+}
+
+// ReprocessCovers re-resizes every local cover under cfg.Path to cfg.Image
+// without contacting TMDB. Notes with external or missing covers are
+// skipped. When the format changes, the file gets the new extension and the
+// note's cover gets it too, written the way it was before. A file already
+// at the new path is never overwritten, and the original is only removed
+// once no note references it any more; a single-note run always keeps it.
+func ReprocessCovers(cfg ReprocessConfig) error {
+	files, vaultPath, err := collectNotes(cfg.Path)
+	if err != nil {
+		return err
+	}
+	attachmentsDir := resolveAttachmentsDir(vaultPath, cfg.AttachmentsDir)
+
+	var processed, skipped, failed int
+	notes := make(map[string]*note.Note, len(files))
+	// references counts the cover and banner references to each image, so
+	// a renamed cover's original stays while another note still uses it
+	references := make(map[string]int)
+	for _, file := range files {
+		n, err := note.LoadWithKeyMap(file, cfg.KeyMap)
+		if err != nil {
+			fmt.Printf("✗ %s: %v\n", filepath.Base(file), err)
+			failed++
+			continue
+		}
+		notes[file] = n
+		for _, image := range n.LocalImages(filepath.Dir(file), attachmentsDir, vaultPath) {
+			if abs, err := filepath.Abs(image); err == nil {
+				references[abs]++
+			}
+		}
+	}
+	// a note that can't be read, or one outside a single-note run, may
+	// reference any of the originals
+	keepOriginals := failed > 0 || len(files) == 1 && strings.EqualFold(filepath.Ext(cfg.Path), ".md")
+
+	// converted maps an original cover to its reprocessed file, so notes
+	// sharing a cover convert it once
+	converted := make(map[string]string)
+	for _, file := range files {
+		n, ok := notes[file]
+		if !ok {
+			continue
+		}
+		if n.HasExternalCover() {
+			skipped++
+			continue
+		}
+		coverPath, ok := n.ResolveLocalCover(filepath.Dir(file), attachmentsDir)
+		if !ok {
+			skipped++
+			continue
+		}
+		original, err := filepath.Abs(coverPath)
+		if err != nil {
+			original = coverPath
+		}
+
+		newPath, done := converted[original]
+		if !done {
+			newPath, err = reprocessCover(coverPath, cfg.Image)
+			if err != nil {
+				fmt.Printf("✗ %s: %v\n", filepath.Base(coverPath), err)
+				failed++
+				continue
+			}
+			converted[original] = newPath
+		}
+		if newPath != coverPath {
+			if err := n.ReplaceCoverExtension(filepath.Ext(newPath)); err != nil {
+				fmt.Printf("✗ %s: failed to update cover: %v\n", filepath.Base(file), err)
+				failed++
+				continue
+			}
+			references[original]--
+			if references[original] <= 0 && !keepOriginals {
+				if err := os.Remove(coverPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					fmt.Printf("✗ %s: failed to remove original: %v\n", filepath.Base(coverPath), err)
+				}
+			}
+		}
+		fmt.Printf("✓ %s\n", filepath.Base(newPath))
+		processed++
+	}
+
+	fmt.Println("\n=== Summary ===")
+	fmt.Printf("Processed: %d\n", processed)
+	fmt.Printf("Skipped: %d\n", skipped)
+	fmt.Printf("Failed: %d\n", failed)
+	return nil
+}
+
+// reprocessCover resizes the cover at path in place, or next to it under the
+// new extension when opts changes the format, and returns the final path.
+// The original is left for the caller to remove. It refuses to overwrite a
+// different file already at the new path.
+func reprocessCover(path string, opts tmdb.ImageOptions) (string, error) {
+	newPath := path
+	if ext := opts.Format.Extension(); imageExtension(filepath.Ext(path)) != ext {
+		newPath = strings.TrimSuffix(path, filepath.Ext(path)) + ext
+		if _, err := os.Stat(newPath); err == nil {
+			return "", fmt.Errorf("%s already exists", filepath.Base(newPath))
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmdb.ResizeImageReader(file, &buf, opts)
+	_ = file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	// write to a temporary file first so a failed write keeps the original
+	tmp := newPath + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, newPath); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return newPath, nil
+}
+
+// imageExtension returns ext in lower case with ".jpeg" spelled ".jpg", the
+// way ImageFormat.Extension writes it.
+func imageExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext == ".jpeg" {
+		return ".jpg"
+	}
+	return ext
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+)
+
+// searchCache holds the search responses of a single run so notes sharing a
+// title don't repeat the same request. Only TMDB's results are stored, never
+// the choice the user made among them.
+type searchCache struct {
+	mu        sync.Mutex
+	responses map[string]tmdb.SearchResponse
+}
+
+func newSearchCache() *searchCache {
+	return &searchCache{responses: make(map[string]tmdb.SearchResponse)}
+}
+
+// searchKey identifies a search by its normalized query, media type, page,
+// and result limit.
+func searchKey(query, searchType string, page, limit int) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	return fmt.Sprintf("%s|%d|%d|%s", searchType, page, limit, normalized)
+}
+
+// fetch returns the cached response for key, calling search and storing its
+// response on a miss. A nil cache always calls search. Errors are not
+// cached.
+func (c *searchCache) fetch(key string, search func() (tmdb.SearchResponse, error)) (tmdb.SearchResponse, error) {
+	if c == nil {
+		return search()
+	}
+	c.mu.Lock()
+	response, ok := c.responses[key]
+	c.mu.Unlock()
+	if !ok {
+		var err error
+		response, err = search()
+		if err != nil {
+			return tmdb.SearchResponse{}, err
+		}
+		c.mu.Lock()
+		c.responses[key] = response
+		c.mu.Unlock()
+	}
+	// callers may annotate or reorder the results
+	response.Results = slices.Clone(response.Results)
+	return response, nil
+}
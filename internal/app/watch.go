@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/util"
+)
+
+// watchDebounce is how long a note must stay unchanged before it is
+// processed, so notes aren't picked up mid-edit. Tests shorten it.
+var watchDebounce = 2 * time.Second
+
+// Watch processes notes under Config.Path whenever they are created or
+// saved, until ctx is cancelled.
+func (r *Runner) Watch(ctx context.Context) error {
+	info, err := os.Stat(r.cfg.Path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("watch needs a directory: %s", r.cfg.Path)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = watcher.Close() }()
+
+	if err := watchTree(watcher, r.cfg.Path); err != nil {
+		return err
+	}
+	attachmentsDir := resolveAttachmentsDir(r.cfg.Path, r.cfg.AttachmentsDir)
+	if err := util.EnsureDir(attachmentsDir); err != nil {
+		return fmt.Errorf("create attachments dir: %w", err)
+	}
+	r.printf("Watching %s for note changes (Ctrl-C to stop)\n", r.cfg.Path)
+
+	var (
+		ready   = make(chan string)
+		pending = make(map[string]*time.Timer)
+		// written holds each note's modification time after we saved it,
+		// so our own writes don't trigger another run
+		written = make(map[string]time.Time)
+	)
+	defer func() {
+		for _, timer := range pending {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			r.printf("⚠️  Watch error: %v\n", err)
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchTree(watcher, event.Name); err != nil {
+						r.printf("⚠️  Cannot watch %s: %v\n", event.Name, err)
+					}
+					continue
+				}
+			}
+			// editors that save atomically write a temporary file and
+			// rename it over the note, which shows up as a Create of the note
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			if !isWatchedNote(event.Name) {
+				continue
+			}
+			path := event.Name
+			if timer, ok := pending[path]; ok {
+				timer.Reset(watchDebounce)
+				continue
+			}
+			pending[path] = time.AfterFunc(watchDebounce, func() {
+				select {
+				case ready <- path:
+				case <-ctx.Done():
+				}
+			})
+
+		case path := <-ready:
+			delete(pending, path)
+			info, err := os.Stat(path)
+			if err != nil {
+				// removed or renamed away before it settled
+				continue
+			}
+			if info.ModTime().Equal(written[path]) {
+				continue
+			}
+
+			r.printf("\nProcessing: %s\n", filepath.Base(path))
+			outcome, err := r.ProcessFile(ctx, path, attachmentsDir)
+			r.printOutcome(outcome)
+			if info, statErr := os.Stat(path); statErr == nil {
+				written[path] = info.ModTime()
+			}
+			switch {
+			case errors.Is(err, ErrStopProcessing):
+				r.printf("\n⚠️  Watching stopped by user\n")
+				return nil
+			case err != nil && ctx.Err() != nil:
+				return nil
+			case err != nil:
+				return err
+			}
+		}
+	}
+}
+
+// watchTree adds root and every directory below it to watcher, skipping
+// hidden directories such as .obsidian and .trash.
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// isWatchedNote reports whether path is a markdown note rather than an
+// editor's temporary or hidden file.
+func isWatchedNote(path string) bool {
+	name := filepath.Base(path)
+	return strings.EqualFold(filepath.Ext(name), ".md") && !strings.HasPrefix(name, ".")
+}
@@ -0,0 +1,70 @@
+// Package config loads the optional YAML configuration file for obsidian-tmdb-cover.
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
+)
+
+const (
+	appName         = "obsidian-tmdb-cover"
+	defaultFileName = "config.yaml"
+)
+
+// File holds the settings read from the configuration file.
+type File struct {
+	Keys                note.KeyMap `yaml:"keys"`
+	ContentMarkerPrefix string      `yaml:"content_marker_prefix"`
+	// InfoStyle is the default for --info-style: table, list, or dataview.
+	InfoStyle string `yaml:"info_style"`
+	// InlineFields writes selected metadata as Dataview inline fields in
+	// the content block.
+	InlineFields note.InlineFields `yaml:"inline_fields"`
+}
+
+// Default returns the configuration used when no file is present.
+func Default() File {
+	return File{
+		Keys:                note.DefaultKeyMap(),
+		ContentMarkerPrefix: note.DefaultMarkerPrefix,
+	}
+}
+
+// DefaultPath returns the default configuration file location,
+// e.g. ~/.config/obsidian-tmdb-cover/config.yaml on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName, defaultFileName), nil
+}
+
+// Load reads the configuration file at path. Settings missing from the file
+// keep their defaults. If optional is true, a missing file is not an error.
+func Load(path string, optional bool) (File, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Default(), err
+	}
+	cfg.Keys = cfg.Keys.WithDefaults()
+	if err := cfg.InlineFields.Validate(); err != nil {
+		return Default(), err
+	}
+	return cfg, nil
+}
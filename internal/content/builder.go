@@ -4,35 +4,149 @@ package content
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
 )
 
-// BuildTMDBContent generates markdown content from TMDB details.
-func BuildTMDBContent(details map[string]any, mediaType string, sections []string) string {
+// SectionSeasonEpisodes is the seasons section variant that also lists each
+// season's episodes. Episodes are read from each season's "episodes" key.
+const SectionSeasonEpisodes = "seasons:episodes"
+
+// SectionTitle emits a top-level "# Title (Year)" heading. It is opt-in and
+// callers should drop it for notes that already have an H1.
+const SectionTitle = "title"
+
+// MediaTypeEpisode is the content type of a single TV episode. Its details
+// come from TMDB's episode endpoint rather than a movie or show.
+const MediaTypeEpisode = "episode"
+
+// Sections lists every section name BuildTMDBContent understands.
+var Sections = []string{
+	SectionTitle, "overview", "info", "seasons", SectionSeasonEpisodes,
+	"collection", "recommendations", "filmography",
+}
+
+// UnknownSections returns the names in sections that aren't in Sections, in
+// the order given.
+func UnknownSections(sections []string) []string {
+	var unknown []string
+	for _, section := range sections {
+		if !slices.Contains(Sections, section) {
+			unknown = append(unknown, section)
+		}
+	}
+	return unknown
+}
+
+// sectionMediaTypes lists the media types each section applies to. Sections
+// missing from the map apply to every type.
+var sectionMediaTypes = map[string][]string{
+	"info":                {"movie", "tv", MediaTypeEpisode},
+	"seasons":             {"tv"},
+	SectionSeasonEpisodes: {"tv"},
+	"collection":          {"movie"},
+	"recommendations":     {"movie", "tv"},
+	"filmography":         {"person"},
+}
+
+// SectionApplies reports whether section produces content for mediaType;
+// BuildTMDBContent skips sections that don't.
+func SectionApplies(section, mediaType string) bool {
+	types, ok := sectionMediaTypes[section]
+	return !ok || slices.Contains(types, mediaType)
+}
+
+// DefaultSections returns the sections generated for a media type when none are requested.
+func DefaultSections(mediaType string) []string {
+	switch mediaType {
+	case "tv":
+		return []string{"overview", "info", "seasons"}
+	case MediaTypeEpisode:
+		return []string{"overview", "info"}
+	case "person":
+		return []string{"overview", "filmography"}
+	default:
+		return []string{"overview", "info"}
+	}
+}
+
+// sectionAppends lists the append_to_response tokens each section reads,
+// per media type.
+var sectionAppends = map[string]map[string][]string{
+	"info": {
+		"movie":          {"external_ids", "release_dates"},
+		"tv":             {"external_ids", "content_ratings"},
+		MediaTypeEpisode: {"external_ids"},
+	},
+	"recommendations": {
+		"movie": {"recommendations"},
+		"tv":    {"recommendations"},
+	},
+	"filmography": {
+		"person": {"combined_credits"},
+	},
+}
+
+// AppendToResponse returns the append_to_response value that fetches
+// everything the sections need in a single details request. It returns an
+// empty string when no extra data is needed.
+func AppendToResponse(mediaType string, sections []string) string {
 	if len(sections) == 0 {
-		if mediaType == "tv" {
-			sections = []string{"overview", "info", "seasons"}
-		} else {
-			sections = []string{"overview", "info"}
+		sections = DefaultSections(mediaType)
+	}
+	var tokens []string
+	for _, section := range sections {
+		for _, token := range sectionAppends[section][mediaType] {
+			if !slices.Contains(tokens, token) {
+				tokens = append(tokens, token)
+			}
 		}
 	}
+	return strings.Join(tokens, ",")
+}
+
+// BuildTMDBContent generates markdown content from TMDB details.
+func BuildTMDBContent(details map[string]any, mediaType string, sections []string, opts ...Option) string {
+	o := newOptions(opts)
+	if len(sections) == 0 {
+		sections = DefaultSections(mediaType)
+	}
 
 	var blocks []string
 	for _, section := range sections {
+		if !SectionApplies(section, mediaType) {
+			continue
+		}
 		switch section {
+		case SectionTitle:
+			if block := buildTitle(details); block != "" {
+				blocks = append(blocks, block)
+			}
 		case "overview":
-			if block := buildOverview(details); block != "" {
+			if block := buildOverview(details, o.overviewStyle); block != "" {
 				blocks = append(blocks, block)
 			}
 		case "info":
-			if block := buildInfo(details, mediaType); block != "" {
+			if block := buildInfo(details, mediaType, o); block != "" {
 				blocks = append(blocks, block)
 			}
-		case "seasons":
-			if mediaType == "tv" {
-				if block := buildSeasons(details); block != "" {
-					blocks = append(blocks, block)
-				}
+		case "seasons", SectionSeasonEpisodes:
+			if block := buildSeasons(details, section == SectionSeasonEpisodes, o.emoji); block != "" {
+				blocks = append(blocks, block)
+			}
+		case "collection":
+			if block := buildCollection(details); block != "" {
+				blocks = append(blocks, block)
+			}
+		case "recommendations":
+			if block := buildRecommendations(details); block != "" {
+				blocks = append(blocks, block)
+			}
+		case "filmography":
+			if block := buildFilmography(details); block != "" {
+				blocks = append(blocks, block)
 			}
 		}
 	}
@@ -40,54 +154,123 @@ func BuildTMDBContent(details map[string]any, mediaType string, sections []strin
 	return strings.Join(blocks, "\n\n")
 }
 
-func buildOverview(details map[string]any) string {
+func buildTitle(details map[string]any) string {
+	title := stringVal(details, "title")
+	if title == "" {
+		title = stringVal(details, "name")
+	}
+	if strings.TrimSpace(title) == "" {
+		return ""
+	}
+
+	date := stringVal(details, "release_date")
+	if date == "" {
+		date = stringVal(details, "first_air_date")
+	}
+	if date == "" {
+		date = stringVal(details, "air_date")
+	}
+	if len(date) >= 4 {
+		return fmt.Sprintf("# %s (%s)", title, date[:4])
+	}
+	return "# " + title
+}
+
+func buildOverview(details map[string]any, style OverviewStyle) string {
 	overview := stringVal(details, "overview")
+	if strings.TrimSpace(overview) == "" {
+		// people have a biography instead of an overview
+		overview = stringVal(details, "biography")
+	}
 	if strings.TrimSpace(overview) == "" {
 		return ""
 	}
 
-	tagline := stringVal(details, "tagline")
+	text := strings.TrimSpace(overview)
+	if tagline := strings.TrimSpace(stringVal(details, "tagline")); tagline != "" {
+		text += "\n\n> _\"" + tagline + "\"_"
+	}
 
-	var builder strings.Builder
-	builder.WriteString("## Overview\n\n")
-	builder.WriteString(strings.TrimSpace(overview))
-	builder.WriteString("\n")
+	switch style {
+	case OverviewCallout:
+		// every line of a callout, blank ones included, starts with ">"
+		var builder strings.Builder
+		builder.WriteString("> [!abstract]- Overview\n")
+		for line := range strings.SplitSeq(text, "\n") {
+			builder.WriteString(strings.TrimRight("> "+line, " "))
+			builder.WriteString("\n")
+		}
+		return builder.String()
+	case OverviewDetails:
+		// the blank lines let Markdown render inside the HTML element
+		return "<details>\n<summary>Overview</summary>\n\n" + text + "\n\n</details>\n"
+	default:
+		return "## Overview\n\n" + text + "\n"
+	}
+}
+
+// infoRow is one key/value pair of an info section.
+type infoRow struct {
+	key   string
+	value string
+}
 
-	if tagline = strings.TrimSpace(tagline); tagline != "" {
-		builder.WriteString("\n> _\"")
-		builder.WriteString(tagline)
-		builder.WriteString("\"_\n")
+func buildInfo(details map[string]any, mediaType string, o options) string {
+	switch mediaType {
+	case MediaTypeEpisode:
+		return renderInfo("Episode Info", episodeInfoRows(details, o.emoji), o.infoStyle)
+	case "tv":
+		return renderInfo("Series Info", infoRows(details, mediaType, o), o.infoStyle)
+	default:
+		return renderInfo("Movie Info", infoRows(details, mediaType, o), o.infoStyle)
 	}
-	return builder.String()
 }
 
-func buildInfo(details map[string]any, mediaType string) string {
+// renderInfo formats the rows of an info section under heading. Only the
+// formatting differs between styles; the rows are the same.
+func renderInfo(heading string, rows []infoRow, style InfoStyle) string {
 	var builder strings.Builder
-	builder.WriteString("## ")
-	if mediaType == "tv" {
-		builder.WriteString("Series Info\n\n")
-	} else {
-		builder.WriteString("Movie Info\n\n")
+	builder.WriteString("## " + heading + "\n\n")
+	switch style {
+	case InfoList:
+		for _, row := range rows {
+			builder.WriteString(fmt.Sprintf("- **%s:** %s\n", row.key, row.value))
+		}
+	case InfoDataview:
+		for _, row := range rows {
+			builder.WriteString(fmt.Sprintf("%s:: %s\n", row.key, row.value))
+		}
+	default:
+		builder.WriteString("| | |\n")
+		builder.WriteString("|---|---|\n")
+		for _, row := range rows {
+			builder.WriteString(fmt.Sprintf("| **%s** | %s |\n", row.key, row.value))
+		}
 	}
+	return strings.TrimRight(builder.String(), "\n")
+}
 
-	builder.WriteString("| | |\n")
-	builder.WriteString("|---|---|\n")
-
+// infoRows collects the info rows of a movie or TV show.
+func infoRows(details map[string]any, mediaType string, o options) []infoRow {
+	var rows []infoRow
 	status := stringVal(details, "status")
 	inProduction := boolVal(details, "in_production")
 	if status == "" {
 		status = "Unknown"
 	}
 	if mediaType == "tv" && inProduction {
-		builder.WriteString(fmt.Sprintf("| **Status** | %s (In Production) |\n", status))
+		rows = append(rows, infoRow{"Status", fmt.Sprintf("%s (In Production)", status)})
 	} else {
-		builder.WriteString(fmt.Sprintf("| **Status** | %s |\n", status))
+		rows = append(rows, infoRow{"Status", status})
 	}
 
 	if mediaType == "tv" {
 		seasons, _ := intVal(details, "number_of_seasons")
 		episodes, _ := intVal(details, "number_of_episodes")
-		builder.WriteString(fmt.Sprintf("| **Seasons** | %d (%d episodes) |\n", seasons, episodes))
+		rows = append(rows, infoRow{"Seasons", fmt.Sprintf("%d (%d episodes)", seasons, episodes)})
+		if runtime, ok := tmdb.EpisodeRuntime(details); ok && runtime > 0 {
+			rows = append(rows, infoRow{"Episode Runtime", fmt.Sprintf("%d min", runtime)})
+		}
 
 		firstAir := stringVal(details, "first_air_date")
 		lastAir := stringVal(details, "last_air_date")
@@ -95,37 +278,37 @@ func buildInfo(details map[string]any, mediaType string) string {
 			airText := firstAir
 			switch {
 			case lastAir != "" && lastAir != firstAir:
-				airText = fmt.Sprintf("%s → %s", firstAir, lastAir)
+				airText = fmt.Sprintf("%s %s %s", firstAir, arrow, lastAir)
 			case inProduction:
-				airText = fmt.Sprintf("%s → Present", firstAir)
+				airText = fmt.Sprintf("%s %s Present", firstAir, arrow)
 			}
-			builder.WriteString(fmt.Sprintf("| **Aired** | %s |\n", airText))
+			rows = append(rows, infoRow{"Aired", airText})
 		}
 	} else {
 		if runtime, ok := intVal(details, "runtime"); ok && runtime > 0 {
-			builder.WriteString(fmt.Sprintf("| **Runtime** | %d min |\n", runtime))
+			rows = append(rows, infoRow{"Runtime", fmt.Sprintf("%d min", runtime)})
 		}
 		release := stringVal(details, "release_date")
 		if release != "" {
-			builder.WriteString(fmt.Sprintf("| **Released** | %s |\n", release))
+			rows = append(rows, infoRow{"Released", release})
 		}
 	}
 
 	if rating, ok := floatVal(details, "vote_average"); ok && rating > 0 {
 		votes, _ := intVal(details, "vote_count")
-		builder.WriteString(fmt.Sprintf("| **Rating** | ⭐ %.1f/10 (%s votes) |\n", rating, formatNumber(votes)))
+		rows = append(rows, infoRow{"Rating", fmt.Sprintf("%s (%s votes)", formatRating(rating, o.emoji), formatNumber(votes))})
 	}
 
 	if mediaType == "tv" {
 		if networkName := firstStringFromArray(details, "networks", "name"); networkName != "" {
-			builder.WriteString(fmt.Sprintf("| **Network** | %s |\n", networkName))
+			rows = append(rows, infoRow{"Network", networkName})
 		}
 	} else {
 		if budget, ok := intVal(details, "budget"); ok && budget > 0 {
-			builder.WriteString(fmt.Sprintf("| **Budget** | $%s |\n", formatNumber(budget)))
+			rows = append(rows, infoRow{"Budget", formatMoney(budget, o.currencySymbol, o.shortMoney)})
 		}
 		if revenue, ok := intVal(details, "revenue"); ok && revenue > 0 {
-			builder.WriteString(fmt.Sprintf("| **Revenue** | $%s |\n", formatNumber(revenue)))
+			rows = append(rows, infoRow{"Revenue", formatMoney(revenue, o.currencySymbol, o.shortMoney)})
 		}
 	}
 
@@ -135,32 +318,105 @@ func buildInfo(details map[string]any, mediaType string) string {
 			if i >= 3 {
 				break
 			}
-			parts = append(parts, fmt.Sprintf("%s %s", countryFlag(code), code))
+			if o.emoji {
+				code = countryFlag(code) + " " + code
+			}
+			parts = append(parts, code)
+		}
+		separator := " "
+		if !o.emoji {
+			separator = ", "
+		}
+		rows = append(rows, infoRow{"Origin", strings.Join(parts, separator)})
+	}
+
+	if mediaType != "tv" {
+		if studios := stringsFromArray(details, "production_companies", "name", 3); len(studios) > 0 {
+			rows = append(rows, infoRow{"Studios", strings.Join(studios, ", ")})
+		}
+		if countries := stringsFromArray(details, "production_countries", "name", 3); len(countries) > 0 {
+			rows = append(rows, infoRow{"Countries", strings.Join(countries, ", ")})
 		}
-		builder.WriteString(fmt.Sprintf("| **Origin** | %s |\n", strings.Join(parts, " ")))
 	}
 
 	if mediaType == "tv" {
-		if rating := usContentRating(details); rating != "" {
-			builder.WriteString(fmt.Sprintf("| **Content Rating** | %s |\n", rating))
+		if rating := tmdb.TVContentRating(details, o.region); rating != "" {
+			rows = append(rows, infoRow{"Content Rating", rating})
 		}
+	} else if rating := tmdb.MovieCertification(details, o.region); rating != "" {
+		rows = append(rows, infoRow{"Content Rating", rating})
 	}
 
 	if imdb := nestedString(details, "external_ids", "imdb_id"); imdb != "" {
-		builder.WriteString(fmt.Sprintf("| **IMDB** | [imdb.com/title/%s](https://www.imdb.com/title/%s/) |\n", imdb, imdb))
+		rows = append(rows, infoRow{"IMDB", fmt.Sprintf("[imdb.com/title/%s](https://www.imdb.com/title/%s/)", imdb, imdb)})
 	}
 	if tvdb := nestedString(details, "external_ids", "tvdb_id"); tvdb != "" {
-		builder.WriteString(fmt.Sprintf("| **TVDB** | [thetvdb.com/%s](https://thetvdb.com/series/%s) |\n", tvdb, tvdb))
+		rows = append(rows, infoRow{"TVDB", fmt.Sprintf("[thetvdb.com/%s](https://thetvdb.com/series/%s)", tvdb, tvdb)})
 	}
 
 	if homepage := stringVal(details, "homepage"); homepage != "" {
-		builder.WriteString(fmt.Sprintf("| **Homepage** | [%s](%s) |\n", friendlyHomepageName(homepage), homepage))
+		rows = append(rows, infoRow{"Homepage", fmt.Sprintf("[%s](%s)", friendlyHomepageName(homepage), homepage)})
 	}
+	return rows
+}
 
-	return strings.TrimRight(builder.String(), "\n")
+// episodeInfoRows collects the info rows of a single TV episode.
+func episodeInfoRows(details map[string]any, emoji bool) []infoRow {
+	var rows []infoRow
+	season, _ := intVal(details, "season_number")
+	episode, _ := intVal(details, "episode_number")
+	label := fmt.Sprintf("S%02dE%02d", season, episode)
+	if name := stringVal(details, "name"); name != "" {
+		label += " " + middleDot + " " + name
+	}
+	rows = append(rows, infoRow{"Episode", label})
+
+	if airDate := stringVal(details, "air_date"); airDate != "" {
+		rows = append(rows, infoRow{"Aired", airDate})
+	}
+	if runtime, ok := intVal(details, "runtime"); ok && runtime > 0 {
+		rows = append(rows, infoRow{"Runtime", fmt.Sprintf("%d min", runtime)})
+	}
+	if rating, ok := floatVal(details, "vote_average"); ok && rating > 0 {
+		votes, _ := intVal(details, "vote_count")
+		rows = append(rows, infoRow{"Rating", fmt.Sprintf("%s (%s votes)", formatRating(rating, emoji), formatNumber(votes))})
+	}
+	if directors := crewNames(details, "Director"); len(directors) > 0 {
+		rows = append(rows, infoRow{"Directed by", strings.Join(directors, ", ")})
+	}
+	if writers := crewNames(details, "Writer", "Teleplay", "Story"); len(writers) > 0 {
+		rows = append(rows, infoRow{"Written by", strings.Join(writers, ", ")})
+	}
+	if guests := stringsFromArray(details, "guest_stars", "name", 5); len(guests) > 0 {
+		rows = append(rows, infoRow{"Guest Stars", strings.Join(guests, ", ")})
+	}
+	if imdb := nestedString(details, "external_ids", "imdb_id"); imdb != "" {
+		rows = append(rows, infoRow{"IMDB", fmt.Sprintf("[imdb.com/title/%s](https://www.imdb.com/title/%s/)", imdb, imdb)})
+	}
+	return rows
+}
+
+// crewNames returns the names of an episode's crew members with one of the
+// given jobs, without duplicates.
+func crewNames(details map[string]any, jobs ...string) []string {
+	crew, ok := details["crew"].([]any)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, item := range crew {
+		member, ok := item.(map[string]any)
+		if !ok || !slices.Contains(jobs, stringVal(member, "job")) {
+			continue
+		}
+		if name := stringVal(member, "name"); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
-func buildSeasons(details map[string]any) string {
+func buildSeasons(details map[string]any, withEpisodes, emoji bool) string {
 	raw, ok := details["seasons"].([]any)
 	if !ok || len(raw) == 0 {
 		return ""
@@ -195,7 +451,7 @@ func buildSeasons(details map[string]any) string {
 
 		builder.WriteString(fmt.Sprintf("### %s (%s)", name, year))
 		if vote > 0 {
-			builder.WriteString(fmt.Sprintf(" • ⭐ %.1f/10", vote))
+			builder.WriteString(" " + bullet + " " + formatRating(vote, emoji))
 		}
 		builder.WriteString("\n\n")
 
@@ -213,9 +469,18 @@ func buildSeasons(details map[string]any) string {
 		isLatest := idx == len(raw)-1
 
 		if isLatest && inProduction {
-			builder.WriteString(" • **Status:** Currently Airing\n\n")
+			builder.WriteString(" " + bullet + " **Status:** Currently Airing\n\n")
+		} else if emoji {
+			builder.WriteString(" " + bullet + " **Status:** " + checkEmoji + " Complete\n\n")
 		} else {
-			builder.WriteString(" • **Status:** ✅ Complete\n\n")
+			builder.WriteString(" " + bullet + " **Status:** Complete\n\n")
+		}
+
+		if withEpisodes {
+			if list := buildEpisodeList(s); list != "" {
+				builder.WriteString(list)
+				builder.WriteString("\n\n")
+			}
 		}
 
 		builder.WriteString("---\n\n")
@@ -228,6 +493,259 @@ func buildSeasons(details map[string]any) string {
 	return out
 }
 
+func buildCollection(details map[string]any) string {
+	collection, ok := details["collection"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	parts, ok := collection["parts"].([]any)
+	if !ok || len(parts) == 0 {
+		return ""
+	}
+
+	type entry struct {
+		title string
+		date  string
+		id    int
+	}
+	entries := make([]entry, 0, len(parts))
+	for _, part := range parts {
+		obj, ok := part.(map[string]any)
+		if !ok {
+			continue
+		}
+		title := stringVal(obj, "title")
+		if title == "" {
+			continue
+		}
+		id, _ := intVal(obj, "id")
+		entries = append(entries, entry{title: title, date: stringVal(obj, "release_date"), id: id})
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+
+	// unreleased entries without a date go last
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		switch {
+		case a.date == b.date:
+			return 0
+		case a.date == "":
+			return 1
+		case b.date == "":
+			return -1
+		default:
+			return strings.Compare(a.date, b.date)
+		}
+	})
+
+	currentID, _ := intVal(details, "id")
+
+	var builder strings.Builder
+	builder.WriteString("## Collection\n\n")
+	if name := stringVal(collection, "name"); name != "" {
+		builder.WriteString(fmt.Sprintf("**%s**\n\n", name))
+	}
+	for _, e := range entries {
+		year := "TBA"
+		if len(e.date) >= 4 {
+			year = e.date[:4]
+		}
+		if e.id != 0 && e.id == currentID {
+			builder.WriteString(fmt.Sprintf("- **%s (%s)** _(this movie)_\n", e.title, year))
+		} else {
+			builder.WriteString(fmt.Sprintf("- %s (%s)\n", e.title, year))
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func buildRecommendations(details map[string]any) string {
+	raw, ok := details["recommendations"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	results, ok := raw["results"].([]any)
+	if !ok || len(results) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	for _, result := range results {
+		obj, ok := result.(map[string]any)
+		if !ok {
+			continue
+		}
+		title := stringVal(obj, "title")
+		date := stringVal(obj, "release_date")
+		if title == "" {
+			title = stringVal(obj, "name")
+			date = stringVal(obj, "first_air_date")
+		}
+		if title == "" {
+			continue
+		}
+		year := "TBA"
+		if len(date) >= 4 {
+			year = date[:4]
+		}
+		builder.WriteString(fmt.Sprintf("- %s (%s)\n", wikilink(title), year))
+	}
+	if builder.Len() == 0 {
+		return ""
+	}
+
+	return "## Recommendations\n\n" + strings.TrimRight(builder.String(), "\n")
+}
+
+// wikilink renders title as an Obsidian wikilink, dropping characters that
+// Obsidian does not allow in link targets.
+func wikilink(title string) string {
+	target := strings.Map(func(r rune) rune {
+		switch r {
+		case '[', ']', '|', '#', '^':
+			return -1
+		}
+		return r
+	}, title)
+	return "[[" + strings.TrimSpace(target) + "]]"
+}
+
+// filmographyLimit caps how many credits are listed in the filmography section.
+const filmographyLimit = 30
+
+type credit struct {
+	title     string
+	mediaType string
+	year      string
+	role      string
+	votes     int
+}
+
+func buildFilmography(details map[string]any) string {
+	raw, ok := details["combined_credits"].(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	byKey := make(map[string]*credit)
+	var order []string
+	collect := func(listKey, roleKey string) {
+		entries, ok := raw[listKey].([]any)
+		if !ok {
+			return
+		}
+		for _, entry := range entries {
+			obj, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+			title := stringVal(obj, "title")
+			if title == "" {
+				title = stringVal(obj, "name")
+			}
+			date := stringVal(obj, "release_date")
+			if date == "" {
+				date = stringVal(obj, "first_air_date")
+			}
+			if title == "" || len(date) < 4 {
+				continue
+			}
+			mediaType := stringVal(obj, "media_type")
+			id, _ := intVal(obj, "id")
+			key := fmt.Sprintf("%s/%d", mediaType, id)
+			role := strings.TrimSpace(stringVal(obj, roleKey))
+
+			if existing, ok := byKey[key]; ok {
+				if role != "" && !strings.Contains(existing.role, role) {
+					if existing.role != "" {
+						existing.role += ", "
+					}
+					existing.role += role
+				}
+				continue
+			}
+			votes, _ := intVal(obj, "vote_count")
+			byKey[key] = &credit{
+				title:     title,
+				mediaType: mediaType,
+				year:      date[:4],
+				role:      role,
+				votes:     votes,
+			}
+			order = append(order, key)
+		}
+	}
+	collect("cast", "character")
+	collect("crew", "job")
+
+	if len(order) == 0 {
+		return ""
+	}
+
+	credits := make([]*credit, 0, len(order))
+	for _, key := range order {
+		credits = append(credits, byKey[key])
+	}
+	// keep the most notable credits, then list them newest first
+	slices.SortStableFunc(credits, func(a, b *credit) int { return b.votes - a.votes })
+	if len(credits) > filmographyLimit {
+		credits = credits[:filmographyLimit]
+	}
+	slices.SortStableFunc(credits, func(a, b *credit) int { return strings.Compare(b.year, a.year) })
+
+	var builder strings.Builder
+	builder.WriteString("## Filmography\n")
+
+	currentYear := ""
+	for _, c := range credits {
+		if c.year != currentYear {
+			currentYear = c.year
+			builder.WriteString(fmt.Sprintf("\n### %s\n\n", currentYear))
+		}
+		label := "Movie"
+		if c.mediaType == "tv" {
+			label = "TV"
+		}
+		builder.WriteString(fmt.Sprintf("- **%s** _(%s)_", c.title, label))
+		if c.role != "" {
+			builder.WriteString(fmt.Sprintf(" - %s", c.role))
+		}
+		builder.WriteString("\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// buildEpisodeList renders a season's episodes as a collapsed Obsidian callout.
+func buildEpisodeList(season map[string]any) string {
+	episodes, ok := season["episodes"].([]any)
+	if !ok || len(episodes) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("> [!example]- Episode list")
+	for _, raw := range episodes {
+		ep, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		number, _ := intVal(ep, "episode_number")
+		name := strings.TrimSpace(stringVal(ep, "name"))
+		if name == "" {
+			name = fmt.Sprintf("Episode %d", number)
+		}
+		airDate := stringVal(ep, "air_date")
+		if airDate == "" {
+			airDate = "TBA"
+		}
+		builder.WriteString(fmt.Sprintf("\n> %d. **%s** (%s)", number, name, airDate))
+	}
+	return builder.String()
+}
+
 func stringVal(m map[string]any, key string) string {
 	if val, ok := m[key]; ok {
 		if s, ok := val.(string); ok {
@@ -256,6 +774,7 @@ func intVal(m map[string]any, key string) (int, bool) {
 	return 0, false
 }
 
+// episodeRuntime returns the first of a show's episode_run_time values.
 func floatVal(m map[string]any, key string) (float64, bool) {
 	if val, ok := m[key]; ok {
 		switch v := val.(type) {
@@ -339,23 +858,24 @@ func firstStringFromArray(m map[string]any, key string, nested string) string {
 	return ""
 }
 
-func usContentRating(details map[string]any) string {
-	raw, ok := details["content_ratings"].(map[string]any)
+// stringsFromArray collects up to limit non-empty nested string values from an array of objects.
+func stringsFromArray(m map[string]any, key string, nested string, limit int) []string {
+	arr, ok := m[key].([]any)
 	if !ok {
-		return ""
-	}
-	results, ok := raw["results"].([]any)
-	if !ok {
-		return ""
+		return nil
 	}
-	for _, entry := range results {
-		if obj, ok := entry.(map[string]any); ok {
-			if strings.EqualFold(stringVal(obj, "iso_3166_1"), "US") {
-				return stringVal(obj, "rating")
+	out := make([]string, 0, min(limit, len(arr)))
+	for _, item := range arr {
+		if len(out) >= limit {
+			break
+		}
+		if obj, ok := item.(map[string]any); ok {
+			if value := stringVal(obj, nested); value != "" {
+				out = append(out, value)
 			}
 		}
 	}
-	return ""
+	return out
 }
 
 func friendlyHomepageName(url string) string {
@@ -377,62 +897,72 @@ func friendlyHomepageName(url string) string {
 	}
 }
 
+// Glyphs used in the generated content, spelled as escapes so an editor or
+// tool that mangles the file's encoding can't corrupt them.
+const (
+	starEmoji  = "\u2b50"     // ⭐
+	checkEmoji = "\u2705"     // ✅
+	globeEmoji = "\U0001F310" // 🌐
+	arrow      = "\u2192"     // →
+	bullet     = "\u2022"     // •
+	middleDot  = "\u00b7"     // ·
+)
+
+// countryFlag returns the flag emoji for an ISO 3166-1 alpha-2 code: the
+// pair of regional indicator symbols matching its letters.
 func countryFlag(code string) string {
-	flags := map[string]string{
-		"GB": "🇬🇧",
-		"US": "🇺🇸",
-		"CA": "🇨🇦",
-		"FR": "🇫🇷",
-		"DE": "🇩🇪",
-		"IT": "🇮🇹",
-		"ES": "🇪🇸",
-		"JP": "🇯🇵",
-		"KR": "🇰🇷",
-		"AU": "🇦🇺",
-		"NZ": "🇳🇿",
-		"IN": "🇮🇳",
-		"BR": "🇧🇷",
-		"MX": "🇲🇽",
-		"SE": "🇸🇪",
-		"NO": "🇳🇴",
-		"DK": "🇩🇰",
-		"FI": "🇫🇮",
-		"NL": "🇳🇱",
-		"BE": "🇧🇪",
-		"CH": "🇨🇭",
-		"AT": "🇦🇹",
-		"IE": "🇮🇪",
-		"PL": "🇵🇱",
-		"CZ": "🇨🇿",
-		"RU": "🇷🇺",
-		"CN": "🇨🇳",
-		"TW": "🇹🇼",
-		"HK": "🇭🇰",
-		"SG": "🇸🇬",
-		"TH": "🇹🇭",
-		"ID": "🇮🇩",
-		"MY": "🇲🇾",
-		"PH": "🇵🇭",
-		"VN": "🇻🇳",
-		"AR": "🇦🇷",
-		"CL": "🇨🇱",
-		"CO": "🇨🇴",
-		"PE": "🇵🇪",
-		"ZA": "🇿🇦",
-		"EG": "🇪🇬",
-		"IL": "🇮🇱",
-		"TR": "🇹🇷",
-		"GR": "🇬🇷",
-		"PT": "🇵🇹",
-		"RO": "🇷🇴",
-		"HU": "🇭🇺",
-		"UA": "🇺🇦",
-	}
 	code = strings.ToUpper(code)
-	if flag, ok := flags[code]; ok {
-		return flag
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return globeEmoji
+	}
+	const regionalIndicatorA = 0x1F1E6
+	return string([]rune{
+		regionalIndicatorA + rune(code[0]-'A'),
+		regionalIndicatorA + rune(code[1]-'A'),
+	})
+}
+
+// formatRating formats a TMDB vote average, prefixed with a star unless
+// emoji are disabled.
+func formatRating(vote float64, emoji bool) string {
+	rating := fmt.Sprintf("%.1f/10", vote)
+	if emoji {
+		return starEmoji + " " + rating
+	}
+	return rating
+}
+
+// formatMoney formats an amount with the currency symbol in front, either
+// exactly with grouped digits ("$165,000,000") or abbreviated ("$165M",
+// "$1.2B", "$950K").
+func formatMoney(value int, symbol string, short bool) string {
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	if !short {
+		return sign + symbol + formatNumber(value)
+	}
+
+	units := []struct {
+		size   float64
+		suffix string
+	}{{1e9, "B"}, {1e6, "M"}, {1e3, "K"}}
+	amount := strconv.Itoa(value)
+	for i, unit := range units {
+		if float64(value) < unit.size {
+			continue
+		}
+		amount = strconv.FormatFloat(float64(value)/unit.size, 'f', 1, 64)
+		// 999,960 rounds to "1000.0"; show it as 1M instead
+		if amount == "1000.0" && i > 0 {
+			amount, unit = "1.0", units[i-1]
+		}
+		amount = strings.TrimSuffix(amount, ".0") + unit.suffix
+		break
 	}
-	return "🌐"
+	return sign + symbol + amount
 }
 
 func formatNumber(value int) string {
@@ -0,0 +1,335 @@
+package content
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestBuildInfoMovieStudiosAndCountries(t *testing.T) {
+	details := map[string]any{
+		"status":         "Released",
+		"origin_country": []any{"US"},
+		"production_companies": []any{
+			map[string]any{"name": "Warner Bros. Pictures"},
+			map[string]any{"name": "Village Roadshow Pictures"},
+			map[string]any{"name": "Groucho II Film Partnership"},
+			map[string]any{"name": "Silver Pictures"},
+		},
+		"production_countries": []any{
+			map[string]any{"iso_3166_1": "US", "name": "United States of America"},
+			map[string]any{"iso_3166_1": "AU", "name": "Australia"},
+		},
+	}
+
+	got := buildInfo(details, "movie", newOptions(nil))
+
+	wantRows := []string{
+		"| **Studios** | Warner Bros. Pictures, Village Roadshow Pictures, Groucho II Film Partnership |",
+		"| **Countries** | United States of America, Australia |",
+	}
+	for _, row := range wantRows {
+		if !strings.Contains(got, row) {
+			t.Fatalf("expected row %q in:\n%s", row, got)
+		}
+	}
+	if strings.Contains(got, "Silver Pictures") {
+		t.Fatalf("expected studios to be capped at three:\n%s", got)
+	}
+}
+
+func TestBuildInfoMovieWithoutStudiosOrCountries(t *testing.T) {
+	got := buildInfo(map[string]any{"status": "Released"}, "movie", newOptions(nil))
+	if strings.Contains(got, "**Studios**") || strings.Contains(got, "**Countries**") {
+		t.Fatalf("expected no studio or country rows:\n%s", got)
+	}
+}
+
+func TestBuildInfoContentRatingRegion(t *testing.T) {
+	movie := map[string]any{
+		"release_dates": map[string]any{"results": []any{
+			map[string]any{"iso_3166_1": "US", "release_dates": []any{
+				map[string]any{"certification": "R", "type": float64(3)},
+			}},
+			map[string]any{"iso_3166_1": "DE", "release_dates": []any{
+				map[string]any{"certification": "12", "type": float64(4)},
+				map[string]any{"certification": "16", "type": float64(3)},
+			}},
+		}},
+	}
+	show := map[string]any{
+		"content_ratings": map[string]any{"results": []any{
+			map[string]any{"iso_3166_1": "US", "rating": "TV-MA"},
+			map[string]any{"iso_3166_1": "DE", "rating": "16"},
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		details   map[string]any
+		mediaType string
+		opts      []Option
+		want      string
+	}{
+		{"movie default", movie, "movie", nil, "| **Content Rating** | R |"},
+		{"movie region prefers theatrical", movie, "movie", []Option{WithRegion("de")}, "| **Content Rating** | 16 |"},
+		{"tv default", show, "tv", nil, "| **Content Rating** | TV-MA |"},
+		{"tv region", show, "tv", []Option{WithRegion("DE")}, "| **Content Rating** | 16 |"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildInfo(tt.details, tt.mediaType, newOptions(tt.opts))
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("expected row %q in:\n%s", tt.want, got)
+			}
+		})
+	}
+
+	got := buildInfo(movie, "movie", newOptions([]Option{WithRegion("FI")}))
+	if strings.Contains(got, "**Content Rating**") {
+		t.Fatalf("expected no content rating for a region without one:\n%s", got)
+	}
+}
+
+func TestBuildInfoEpisode(t *testing.T) {
+	details := map[string]any{
+		"name":           "Winter Is Coming",
+		"air_date":       "2011-04-17",
+		"season_number":  float64(1),
+		"episode_number": float64(1),
+		"runtime":        float64(62),
+		"crew": []any{
+			map[string]any{"job": "Director", "name": "Tim Van Patten"},
+			map[string]any{"job": "Writer", "name": "David Benioff"},
+			map[string]any{"job": "Writer", "name": "D. B. Weiss"},
+			map[string]any{"job": "Editor", "name": "Oral Norrie Ottey"},
+		},
+		"guest_stars": []any{map[string]any{"name": "Sean Bean"}},
+	}
+
+	got := BuildTMDBContent(details, MediaTypeEpisode, nil)
+
+	wantRows := []string{
+		"## Episode Info",
+		"| **Episode** | S01E01 · Winter Is Coming |",
+		"| **Aired** | 2011-04-17 |",
+		"| **Runtime** | 62 min |",
+		"| **Directed by** | Tim Van Patten |",
+		"| **Written by** | David Benioff, D. B. Weiss |",
+		"| **Guest Stars** | Sean Bean |",
+	}
+	for _, row := range wantRows {
+		if !strings.Contains(got, row) {
+			t.Fatalf("expected row %q in:\n%s", row, got)
+		}
+	}
+	if strings.Contains(got, "Oral Norrie Ottey") || strings.Contains(got, "Status") {
+		t.Fatalf("unexpected rows in episode info:\n%s", got)
+	}
+}
+
+func TestBuildInfoStylesGolden(t *testing.T) {
+	details := map[string]any{
+		"status":       "Released",
+		"release_date": "1995-12-15",
+		"runtime":      float64(170),
+		"budget":       float64(60000000),
+		"genres":       []any{map[string]any{"name": "Crime"}, map[string]any{"name": "Drama"}},
+		"production_companies": []any{
+			map[string]any{"name": "Warner Bros. Pictures"},
+		},
+		"external_ids": map[string]any{"imdb_id": "tt0113277"},
+	}
+	for _, style := range []InfoStyle{InfoTable, InfoList, InfoDataview} {
+		t.Run(string(style), func(t *testing.T) {
+			got := buildInfo(details, "movie", newOptions([]Option{WithInfoStyle(style)}))
+			checkGolden(t, "info_"+string(style), got)
+		})
+	}
+}
+
+// checkGolden compares a built section against testdata/<name>.golden,
+// rewriting the file first when -update is set.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	got += "\n"
+	golden := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestBuildContentEmoji(t *testing.T) {
+	details := map[string]any{
+		"status":         "Ended",
+		"first_air_date": "2008-01-20",
+		"last_air_date":  "2013-09-29",
+		"vote_average":   8.9,
+		"vote_count":     float64(15000),
+		"origin_country": []any{"US", "GB"},
+		"seasons": []any{
+			map[string]any{"name": "Season 1", "air_date": "2008-01-20", "vote_average": 8.3, "episode_count": float64(7)},
+		},
+	}
+
+	got := BuildTMDBContent(details, "tv", []string{"info", "seasons"})
+	for _, want := range []string{
+		"\u2b50 8.9/10", // star
+		"\U0001F1FA\U0001F1F8 US \U0001F1EC\U0001F1E7 GB", // regional indicator flags
+		"2008-01-20 \u2192 2013-09-29",                    // arrow
+		" \u2022 **Status:** \u2705 Complete",             // bullet and check mark
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+
+	plain := BuildTMDBContent(details, "tv", []string{"info", "seasons"}, WithEmoji(false))
+	for _, r := range plain {
+		if r == 0x2b50 || r == 0x2705 || (r >= 0x1F1E6 && r <= 0x1F1FF) {
+			t.Fatalf("unexpected emoji %q with emoji disabled:\n%s", r, plain)
+		}
+	}
+	if !strings.Contains(plain, "| **Origin** | US, GB |") {
+		t.Errorf("expected plain country codes in:\n%s", plain)
+	}
+}
+
+func TestUnknownSections(t *testing.T) {
+	got := UnknownSections([]string{"overveiw", "info", SectionSeasonEpisodes, "cast"})
+	if strings.Join(got, ",") != "overveiw,cast" {
+		t.Fatalf("UnknownSections() = %v, want [overveiw cast]", got)
+	}
+	if got := UnknownSections(Sections); got != nil {
+		t.Fatalf("expected every known section to be accepted, got %v", got)
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		value int
+		short bool
+		want  string
+	}{
+		{0, false, "$0"},
+		{950, false, "$950"},
+		{165000000, false, "$165,000,000"},
+		{0, true, "$0"},
+		{950, true, "$950"},
+		{950000, true, "$950K"},
+		{999960, true, "$1M"},
+		{1500000, true, "$1.5M"},
+		{165000000, true, "$165M"},
+		{165432000, true, "$165.4M"},
+		{1234000000, true, "$1.2B"},
+		{2500000000000, true, "$2500B"},
+	}
+	for _, tt := range tests {
+		if got := formatMoney(tt.value, "$", tt.short); got != tt.want {
+			t.Errorf("formatMoney(%d, short=%v) = %q, want %q", tt.value, tt.short, got, tt.want)
+		}
+	}
+	if got := formatMoney(165000000, "US$", true); got != "US$165M" {
+		t.Errorf("expected the configured symbol, got %q", got)
+	}
+}
+
+func TestBuildOverviewStyles(t *testing.T) {
+	details := map[string]any{
+		"overview": "A thief plans one last heist.\n\nA detective closes in.",
+		"tagline":  "A Los Angeles crime saga",
+	}
+	tests := map[OverviewStyle]string{
+		OverviewPlain:   "## Overview\n\nA thief plans one last heist.\n\nA detective closes in.\n\n> _\"A Los Angeles crime saga\"_\n",
+		OverviewCallout: "> [!abstract]- Overview\n> A thief plans one last heist.\n>\n> A detective closes in.\n>\n> > _\"A Los Angeles crime saga\"_\n",
+		OverviewDetails: "<details>\n<summary>Overview</summary>\n\nA thief plans one last heist.\n\nA detective closes in.\n\n> _\"A Los Angeles crime saga\"_\n\n</details>\n",
+	}
+	for style, want := range tests {
+		if got := buildOverview(details, style); got != want {
+			t.Errorf("%s overview:\n%q\nwant:\n%q", style, got, want)
+		}
+	}
+	if got := BuildTMDBContent(details, "movie", []string{"overview"}); !strings.HasPrefix(got, "## Overview") {
+		t.Errorf("expected the plain style by default, got:\n%s", got)
+	}
+}
+
+func TestAppendToResponse(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		sections  []string
+		want      string
+	}{
+		{"movie", nil, "external_ids,release_dates"},
+		{"tv", []string{"overview", "info", "recommendations", "seasons"}, "external_ids,content_ratings,recommendations"},
+		{"person", nil, "combined_credits"},
+		{"movie", []string{"overview"}, ""},
+	}
+	for _, tc := range tests {
+		if got := AppendToResponse(tc.mediaType, tc.sections); got != tc.want {
+			t.Fatalf("AppendToResponse(%q, %v) = %q, want %q", tc.mediaType, tc.sections, got, tc.want)
+		}
+	}
+}
+
+func TestBuildFilmographyGolden(t *testing.T) {
+	details := map[string]any{
+		"combined_credits": map[string]any{
+			"cast": []any{
+				map[string]any{"id": float64(949), "media_type": "movie", "title": "Heat", "release_date": "1995-12-15", "character": "Lt. Vincent Hanna", "vote_count": float64(7000)},
+				map[string]any{"id": float64(1100), "media_type": "tv", "name": "Crime Story", "first_air_date": "1986-09-18", "character": "Narrator", "vote_count": float64(90)},
+				map[string]any{"id": float64(2000), "media_type": "movie", "title": "The Insider", "release_date": "1999-11-05", "character": "Lowell Bergman", "vote_count": float64(2500)},
+				map[string]any{"id": float64(3000), "media_type": "movie", "title": "Untitled Project", "character": "Unknown", "vote_count": float64(0)},
+			},
+			"crew": []any{
+				map[string]any{"id": float64(949), "media_type": "movie", "title": "Heat", "release_date": "1995-12-15", "job": "Executive Producer", "vote_count": float64(7000)},
+				map[string]any{"id": float64(2000), "media_type": "movie", "title": "The Insider", "release_date": "1999-11-05", "job": "Producer", "vote_count": float64(2500)},
+				map[string]any{"id": float64(4000), "media_type": "movie", "title": "Ronin", "release_date": "1998-09-25", "job": "", "vote_count": float64(1800)},
+			},
+		},
+	}
+	checkGolden(t, "filmography", buildFilmography(details))
+}
+
+func TestBuildCollectionGolden(t *testing.T) {
+	details := map[string]any{
+		"id": float64(105),
+		"collection": map[string]any{
+			"name": "Back to the Future Collection",
+			"parts": []any{
+				map[string]any{"id": float64(165), "title": "Back to the Future Part II", "release_date": "1989-11-22"},
+				map[string]any{"id": float64(999), "title": "Back to the Future Part IV"},
+				map[string]any{"id": float64(105), "title": "Back to the Future", "release_date": "1985-07-03"},
+				map[string]any{"id": float64(196), "title": "Back to the Future Part III", "release_date": "1990-05-25"},
+			},
+		},
+	}
+	checkGolden(t, "collection", buildCollection(details))
+}
+
+func TestBuildRecommendationsGolden(t *testing.T) {
+	details := map[string]any{
+		"recommendations": map[string]any{
+			"results": []any{
+				map[string]any{"title": "The Insider", "release_date": "1999-11-05"},
+				map[string]any{"name": "Crime Story", "first_air_date": "1986-09-18"},
+				map[string]any{"title": "Heat [Director's Cut] #2"},
+				map[string]any{"overview": "No title"},
+			},
+		},
+	}
+	checkGolden(t, "recommendations", buildRecommendations(details))
+}
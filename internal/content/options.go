@@ -0,0 +1,131 @@
+package content
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OverviewStyle controls how the overview section is rendered.
+type OverviewStyle string
+
+const (
+	// OverviewPlain renders the overview as a regular section.
+	OverviewPlain OverviewStyle = "plain"
+	// OverviewCallout folds the overview into a collapsed Obsidian callout
+	// so spoilers aren't visible at a glance.
+	OverviewCallout OverviewStyle = "callout"
+	// OverviewDetails folds the overview into an HTML <details> element,
+	// which also works outside Obsidian.
+	OverviewDetails OverviewStyle = "details"
+)
+
+// ParseOverviewStyle converts a string into an OverviewStyle.
+func ParseOverviewStyle(value string) (OverviewStyle, error) {
+	switch style := OverviewStyle(strings.ToLower(strings.TrimSpace(value))); style {
+	case "", OverviewPlain:
+		return OverviewPlain, nil
+	case OverviewCallout, OverviewDetails:
+		return style, nil
+	default:
+		return "", fmt.Errorf("unknown overview style: %q", value)
+	}
+}
+
+// InfoStyle controls how the info section's key/value pairs are rendered.
+type InfoStyle string
+
+const (
+	// InfoTable renders a two-column Markdown table.
+	InfoTable InfoStyle = "table"
+	// InfoList renders a bullet list of "**Key:** Value" items.
+	InfoList InfoStyle = "list"
+	// InfoDataview renders Dataview inline fields ("Key:: Value").
+	InfoDataview InfoStyle = "dataview"
+)
+
+// ParseInfoStyle converts a string into an InfoStyle.
+func ParseInfoStyle(value string) (InfoStyle, error) {
+	switch style := InfoStyle(strings.ToLower(strings.TrimSpace(value))); style {
+	case "", InfoTable:
+		return InfoTable, nil
+	case InfoList, InfoDataview:
+		return style, nil
+	default:
+		return "", fmt.Errorf("unknown info style: %q", value)
+	}
+}
+
+// Option configures BuildTMDBContent.
+type Option func(*options)
+
+type options struct {
+	overviewStyle OverviewStyle
+	infoStyle     InfoStyle
+	emoji         bool
+	// TMDB reports budget and revenue in US dollars
+	currencySymbol string
+	shortMoney     bool
+	// region picks the country whose content rating is shown
+	region string
+}
+
+func newOptions(opts []Option) options {
+	o := options{overviewStyle: OverviewPlain, infoStyle: InfoTable, emoji: true, currencySymbol: "$", region: "US"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithOverviewStyle sets how the overview section is rendered.
+func WithOverviewStyle(style OverviewStyle) Option {
+	return func(o *options) {
+		if style != "" {
+			o.overviewStyle = style
+		}
+	}
+}
+
+// WithInfoStyle sets how the info section is rendered.
+func WithInfoStyle(style InfoStyle) Option {
+	return func(o *options) {
+		if style != "" {
+			o.infoStyle = style
+		}
+	}
+}
+
+// WithEmoji controls whether ratings, flags, and status markers include
+// emoji. They are on by default.
+func WithEmoji(enabled bool) Option {
+	return func(o *options) {
+		o.emoji = enabled
+	}
+}
+
+// WithShortMoney abbreviates budget and revenue, e.g. "$165M" instead of
+// "$165,000,000".
+func WithShortMoney(enabled bool) Option {
+	return func(o *options) {
+		o.shortMoney = enabled
+	}
+}
+
+// WithCurrencySymbol sets the symbol written before budget and revenue, e.g.
+// "US$" to tell the amounts apart from a local dollar. The amounts are
+// TMDB's US dollar figures and are not converted.
+func WithCurrencySymbol(symbol string) Option {
+	return func(o *options) {
+		o.currencySymbol = symbol
+	}
+}
+
+// WithRegion sets the ISO 3166-1 country code whose content rating the info
+// section shows. It is "US" by default.
+func WithRegion(region string) Option {
+	return func(o *options) {
+		if region = strings.TrimSpace(region); region != "" {
+			o.region = strings.ToUpper(region)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package note
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CoverEncoding controls how the cover and banner paths are written to
+// frontmatter, for sync tools that mishandle spaces or special characters.
+type CoverEncoding string
+
+const (
+	// CoverEncodingNone writes the path as is.
+	CoverEncodingNone CoverEncoding = "none"
+	// CoverEncodingPercent percent-encodes each path segment, e.g.
+	// "attachments/The%20Matrix%20-%20603%20-%20cover.jpg". Wikilinks are
+	// never encoded since Obsidian doesn't decode them.
+	CoverEncodingPercent CoverEncoding = "percent"
+	// CoverEncodingQuoted writes the value as a double-quoted YAML string.
+	CoverEncodingQuoted CoverEncoding = "quoted"
+)
+
+// ParseCoverEncoding converts a string into a CoverEncoding.
+func ParseCoverEncoding(value string) (CoverEncoding, error) {
+	switch encoding := CoverEncoding(strings.ToLower(strings.TrimSpace(value))); encoding {
+	case "", CoverEncodingNone:
+		return CoverEncodingNone, nil
+	case CoverEncodingPercent, CoverEncodingQuoted:
+		return encoding, nil
+	default:
+		return "", fmt.Errorf("unknown cover encoding: %q", value)
+	}
+}
+
+// SetCoverEncoding sets how UpdateCover and UpdateBanner write paths.
+func (n *Note) SetCoverEncoding(e CoverEncoding) {
+	n.coverEncoding = e
+}
+
+// formatImagePath renders a relative image path in format, applying the
+// note's cover encoding, and records whether key must be quoted.
+func (n *Note) formatImagePath(key, relative string, format CoverFormat) string {
+	if n.coverEncoding == CoverEncodingPercent && format != CoverFormatWikilink {
+		segments := strings.Split(relative, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		relative = strings.Join(segments, "/")
+	}
+	if n.coverEncoding == CoverEncodingQuoted {
+		n.quoted[key] = true
+	}
+	return format.Format(relative)
+}
+
+// decodeImageReference returns the unescaped form of a percent-encoded
+// image reference, and false when ref isn't encoded.
+func decodeImageReference(ref string) (string, bool) {
+	if !strings.Contains(ref, "%") {
+		return "", false
+	}
+	decoded, err := url.PathUnescape(ref)
+	if err != nil || decoded == ref {
+		return "", false
+	}
+	return decoded, true
+}
+
+// detectQuoted reports which of keys hold double-quoted strings in the raw
+// frontmatter, so re-saving the note keeps them quoted. For a list, the
+// first item counts.
+func detectQuoted(fm string, keys ...string) map[string]bool {
+	quoted := make(map[string]bool)
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(fm), &doc); err != nil {
+		return quoted
+	}
+	root := &doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	for _, key := range keys {
+		if node := firstScalar(mappingValue(root, key)); node != nil && node.Style&yaml.DoubleQuotedStyle != 0 {
+			quoted[key] = true
+		}
+	}
+	return quoted
+}
+
+// firstScalar returns node itself when it is a scalar, or the first item of
+// a sequence.
+func firstScalar(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.SequenceNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.ScalarNode {
+		return nil
+	}
+	return node
+}
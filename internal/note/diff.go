@@ -0,0 +1,108 @@
+package note
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeKind describes how a frontmatter key changed.
+type ChangeKind string
+
+const (
+	// ChangeAdded is a key that didn't exist before.
+	ChangeAdded ChangeKind = "added"
+	// ChangeChanged is a key whose value differs.
+	ChangeChanged ChangeKind = "changed"
+	// ChangeRemoved is a key that no longer exists.
+	ChangeRemoved ChangeKind = "removed"
+)
+
+// Change is a single frontmatter key that differs between two snapshots.
+type Change struct {
+	Key  string
+	Kind ChangeKind
+	Old  any
+	New  any
+}
+
+// String renders the change on one line, e.g. "+ runtime: 170" or
+// "~ tags: [drama] → [drama, movie/Crime]".
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.Key, formatValue(c.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.Key, formatValue(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s → %s", c.Key, formatValue(c.Old), formatValue(c.New))
+	}
+}
+
+// Snapshot returns a copy of the frontmatter to compare against later with
+// DiffFrontmatter. Lists are copied too, since updates modify them in place.
+func (n *Note) Snapshot() map[string]any {
+	snapshot := make(map[string]any, len(n.frontmatter))
+	for key, value := range n.frontmatter {
+		switch v := value.(type) {
+		case []any:
+			snapshot[key] = append([]any(nil), v...)
+		case []string:
+			snapshot[key] = append([]string(nil), v...)
+		default:
+			snapshot[key] = value
+		}
+	}
+	return snapshot
+}
+
+// DiffFrontmatter returns the keys added, changed, or removed between before
+// and after, sorted by key. Lists are compared by their items, so a list
+// re-read as []any equals the []string it was written from.
+func DiffFrontmatter(before, after map[string]any) []Change {
+	var changes []Change
+	for key, old := range before {
+		value, ok := after[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: key, Kind: ChangeRemoved, Old: old})
+		case !sameValue(old, value):
+			changes = append(changes, Change{Key: key, Kind: ChangeChanged, Old: old, New: value})
+		}
+	}
+	for key, value := range after {
+		if _, ok := before[key]; !ok {
+			changes = append(changes, Change{Key: key, Kind: ChangeAdded, New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+func sameValue(a, b any) bool {
+	return reflect.DeepEqual(normalizeList(a), normalizeList(b))
+}
+
+// normalizeList converts a []string to []any so both list forms compare equal.
+func normalizeList(value any) any {
+	if list, ok := value.([]string); ok {
+		items := make([]any, len(list))
+		for i, item := range list {
+			items[i] = item
+		}
+		return items
+	}
+	return value
+}
+
+func formatValue(value any) string {
+	if list, ok := normalizeList(value).([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
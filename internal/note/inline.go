@@ -0,0 +1,146 @@
+package note
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// InlineFieldNames lists the metadata fields that can be written as Dataview
+// inline fields. They use the same names as the KeyMap settings.
+var InlineFieldNames = []string{
+	"runtime", "episode_runtime", "total_episodes", "year",
+	"directors", "content_rating", "status", "in_production",
+	"episode_title", "air_date",
+}
+
+// InlineFields selects metadata written as Dataview inline fields
+// ("runtime:: 170") at the top of the generated content block, for vaults
+// that query the body rather than the frontmatter.
+type InlineFields struct {
+	// Fields holds names from InlineFieldNames.
+	Fields []string `yaml:"fields"`
+	// Only leaves the selected fields out of the frontmatter.
+	Only bool `yaml:"only"`
+}
+
+// Validate returns an error for field names that can't be written inline.
+func (f InlineFields) Validate() error {
+	for _, field := range f.Fields {
+		if !slices.Contains(InlineFieldNames, field) {
+			return fmt.Errorf("unknown inline field %q (available: %s)", field, strings.Join(InlineFieldNames, ", "))
+		}
+	}
+	return nil
+}
+
+// inlineFieldPattern matches a Dataview inline field line: "key:: value".
+var inlineFieldPattern = regexp.MustCompile(`^([^:\s][^:]*?)::\s?(.*)$`)
+
+type inlineField struct {
+	key   string
+	value string
+}
+
+// SetInlineFields selects the metadata written as inline fields.
+func (n *Note) SetInlineFields(f InlineFields) {
+	n.inline = f
+}
+
+// writesInline reports whether the field is written as an inline field and
+// whether it also belongs in the frontmatter.
+func (n *Note) writesInline(name string) (inline, frontmatter bool) {
+	inline = slices.Contains(n.inline.Fields, name)
+	return inline, !inline || !n.inline.Only
+}
+
+// splitInlineFields separates the inline fields at the top of a content
+// block from the generated content after them.
+func splitInlineFields(content string) (fields []inlineField, rest string) {
+	lines := strings.Split(strings.TrimLeft(content, "\n"), "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		match := inlineFieldPattern.FindStringSubmatch(lines[i])
+		if match == nil {
+			break
+		}
+		fields = append(fields, inlineField{key: match[1], value: match[2]})
+	}
+	return fields, strings.Trim(strings.Join(lines[i:], "\n"), "\n")
+}
+
+// joinInlineFields renders fields above rest, separated by a blank line.
+func joinInlineFields(fields []inlineField, rest string) string {
+	lines := make([]string, 0, len(fields))
+	for _, field := range fields {
+		lines = append(lines, field.key+":: "+field.value)
+	}
+	text := strings.Join(lines, "\n")
+	switch {
+	case text == "":
+		return rest
+	case rest == "":
+		return text
+	default:
+		return text + "\n\n" + rest
+	}
+}
+
+// blockInlineFields returns the inline fields in the note's content block.
+func (n *Note) blockInlineFields() []inlineField {
+	content, _, ok := n.blockContent()
+	if !ok {
+		return nil
+	}
+	fields, _ := splitInlineFields(content)
+	return fields
+}
+
+// hasField reports whether key is set in the frontmatter or as an inline
+// field in the content block.
+func (n *Note) hasField(key string) bool {
+	if _, ok := n.frontmatter[key]; ok {
+		return true
+	}
+	for _, field := range n.blockInlineFields() {
+		if field.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// setInlineFields stores updates in the content block, replacing fields
+// with the same key and keeping the rest of the block. The note isn't
+// saved.
+func (n *Note) setInlineFields(updates []inlineField) {
+	content, existing, ok := n.blockContent()
+	if !ok {
+		n.insertBlock(joinInlineFields(updates, ""))
+		return
+	}
+	fields, rest := splitInlineFields(content)
+	for _, update := range updates {
+		idx := slices.IndexFunc(fields, func(f inlineField) bool { return f.key == update.key })
+		if idx == -1 {
+			fields = append(fields, update)
+		} else {
+			fields[idx] = update
+		}
+	}
+	n.replaceBlock(existing, joinInlineFields(fields, rest))
+}
+
+// inlineValue formats a metadata value for an inline field.
+func inlineValue(value any) string {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case []string:
+		return strings.Join(v, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -3,10 +3,13 @@ package note
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
-	"sort"
+	"slices"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -14,23 +17,216 @@ import (
 	"github.com/lepinkainen/obsidian-tmdb-cover/internal/util"
 )
 
-const (
-	startMarker = "<!-- TMDB_DATA_START -->"
-	endMarker   = "<!-- TMDB_DATA_END -->"
-)
+// DefaultMarkerPrefix is the name used in the default content markers.
+const DefaultMarkerPrefix = "TMDB_DATA"
+
+// Markers delimit the generated TMDB content block in a note body.
+type Markers struct {
+	Start string
+	End   string
+}
+
+// MarkersWithPrefix returns markers of the form <!-- PREFIX_START --> and <!-- PREFIX_END -->.
+// An empty prefix yields the default markers.
+func MarkersWithPrefix(prefix string) Markers {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		prefix = DefaultMarkerPrefix
+	}
+	return Markers{
+		Start: "<!-- " + prefix + "_START -->",
+		End:   "<!-- " + prefix + "_END -->",
+	}
+}
+
+// DefaultMarkers returns the standard <!-- TMDB_DATA_START/END --> markers.
+func DefaultMarkers() Markers {
+	return MarkersWithPrefix(DefaultMarkerPrefix)
+}
+
+// ErrMalformedFrontmatter is returned when a note opens a frontmatter block
+// that is unterminated or not valid YAML. Such notes are left untouched
+// rather than rewritten with their broken block pushed into the body. A
+// leading "---" followed by something other than YAML keys is a horizontal
+// rule, and the note is read as body only.
+var ErrMalformedFrontmatter = errors.New("malformed frontmatter")
 
 var (
 	frontMatterDelimiter = "---"
 	htmlColorPattern     = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	// yamlKeyPattern matches a "key:" line that opens a frontmatter block.
+	yamlKeyPattern = regexp.MustCompile(`^["']?[\w.-][^:]*["']?:(\s|$)`)
+)
+
+// CoverFormat controls how the cover value is written to frontmatter.
+type CoverFormat string
+
+const (
+	// CoverFormatPath stores the cover as a relative POSIX path.
+	CoverFormatPath CoverFormat = "path"
+	// CoverFormatWikilink stores the cover as an Obsidian wikilink, e.g. [[attachments/Foo - cover.jpg]].
+	CoverFormatWikilink CoverFormat = "wikilink"
+	// CoverFormatFilename stores only the cover's file name.
+	CoverFormatFilename CoverFormat = "filename"
+)
+
+// ParseCoverFormat converts a string into a CoverFormat.
+func ParseCoverFormat(value string) (CoverFormat, error) {
+	switch format := CoverFormat(strings.ToLower(strings.TrimSpace(value))); format {
+	case "", CoverFormatPath:
+		return CoverFormatPath, nil
+	case CoverFormatWikilink, CoverFormatFilename:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown cover format: %q", value)
+	}
+}
+
+// ContentPlacement controls where a new TMDB content block is inserted.
+// Existing blocks are always updated in place.
+type ContentPlacement string
+
+const (
+	// PlacementBottom appends the block after the existing body.
+	PlacementBottom ContentPlacement = "bottom"
+	// PlacementTop inserts the block directly after the frontmatter.
+	PlacementTop ContentPlacement = "top"
+	// PlacementAfterH1 inserts the block after the first H1 heading, or at
+	// the top when the note has none.
+	PlacementAfterH1 ContentPlacement = "after-h1"
 )
 
+// ParseContentPlacement converts a string into a ContentPlacement.
+func ParseContentPlacement(value string) (ContentPlacement, error) {
+	switch placement := ContentPlacement(strings.ToLower(strings.TrimSpace(value))); placement {
+	case "", PlacementBottom:
+		return PlacementBottom, nil
+	case PlacementTop, PlacementAfterH1:
+		return placement, nil
+	default:
+		return "", fmt.Errorf("unknown content placement: %q", value)
+	}
+}
+
+// Format renders a relative cover path in this format.
+func (f CoverFormat) Format(relative string) string {
+	switch f {
+	case CoverFormatWikilink:
+		return "[[" + relative + "]]"
+	case CoverFormatFilename:
+		return path.Base(relative)
+	default:
+		return relative
+	}
+}
+
 // Metadata holds TMDB metadata to be added to a note.
 type Metadata struct {
-	Runtime       *int
-	TotalEpisodes *int
+	Runtime        *int
+	EpisodeRuntime *int
+	TotalEpisodes  *int
+	Year           *int
+	// Directors holds movie directors or TV creators.
+	Directors     []string
 	GenreTags     []string
 	TMDBID        *int
 	TMDBType      *string
+	ContentRating *string
+	// Status is the release or airing state; InProduction is only set for
+	// TV shows.
+	Status       *string
+	InProduction *bool
+	// EpisodeTitle and AirDate describe a single TV episode.
+	EpisodeTitle *string
+	AirDate      *string
+	// ReplaceGenreTags removes the note's existing movie/ and tv/ genre tags
+	// (and network/ and studio/ tags) before adding GenreTags; other tags are
+	// kept.
+	ReplaceGenreTags bool
+}
+
+// KeyMap maps logical note fields to the frontmatter keys used in a vault.
+type KeyMap struct {
+	Title          string `yaml:"title"`
+	Cover          string `yaml:"cover"`
+	Banner         string `yaml:"banner"`
+	Runtime        string `yaml:"runtime"`
+	EpisodeRuntime string `yaml:"episode_runtime"`
+	TotalEpisodes  string `yaml:"total_episodes"`
+	Year           string `yaml:"year"`
+	Directors      string `yaml:"directors"`
+	Tags           string `yaml:"tags"`
+	TMDBID         string `yaml:"tmdb_id"`
+	TMDBType       string `yaml:"tmdb_type"`
+	ContentRating  string `yaml:"content_rating"`
+	Status         string `yaml:"status"`
+	InProduction   string `yaml:"in_production"`
+	CoverSource    string `yaml:"cover_source"`
+	SearchQuery    string `yaml:"search_query"`
+	Season         string `yaml:"season"`
+	Episode        string `yaml:"episode"`
+	EpisodeTitle   string `yaml:"episode_title"`
+	AirDate        string `yaml:"air_date"`
+	Aliases        string `yaml:"aliases"`
+}
+
+// DefaultKeyMap returns the frontmatter key names used when none are configured.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Title:          "title",
+		Cover:          "cover",
+		Banner:         "banner",
+		Runtime:        "runtime",
+		EpisodeRuntime: "episode_runtime",
+		TotalEpisodes:  "total_episodes",
+		Year:           "year",
+		Directors:      "directors",
+		Tags:           "tags",
+		TMDBID:         "tmdb_id",
+		TMDBType:       "tmdb_type",
+		ContentRating:  "content_rating",
+		Status:         "tmdb_status",
+		InProduction:   "tmdb_in_production",
+		CoverSource:    "cover_source",
+		SearchQuery:    "tmdb_query",
+		Season:         "season",
+		Episode:        "episode",
+		EpisodeTitle:   "episode_title",
+		AirDate:        "air_date",
+		Aliases:        "aliases",
+	}
+}
+
+// WithDefaults fills any empty key with its default name.
+func (k KeyMap) WithDefaults() KeyMap {
+	defaults := DefaultKeyMap()
+	fill := func(value *string, fallback string) {
+		if strings.TrimSpace(*value) == "" {
+			*value = fallback
+		}
+	}
+	fill(&k.Title, defaults.Title)
+	fill(&k.Cover, defaults.Cover)
+	fill(&k.Banner, defaults.Banner)
+	fill(&k.Runtime, defaults.Runtime)
+	fill(&k.EpisodeRuntime, defaults.EpisodeRuntime)
+	fill(&k.TotalEpisodes, defaults.TotalEpisodes)
+	fill(&k.Year, defaults.Year)
+	fill(&k.Directors, defaults.Directors)
+	fill(&k.Tags, defaults.Tags)
+	fill(&k.TMDBID, defaults.TMDBID)
+	fill(&k.TMDBType, defaults.TMDBType)
+	fill(&k.ContentRating, defaults.ContentRating)
+	fill(&k.Status, defaults.Status)
+	fill(&k.InProduction, defaults.InProduction)
+	fill(&k.CoverSource, defaults.CoverSource)
+	fill(&k.SearchQuery, defaults.SearchQuery)
+	fill(&k.Season, defaults.Season)
+	fill(&k.Episode, defaults.Episode)
+	fill(&k.EpisodeTitle, defaults.EpisodeTitle)
+	fill(&k.AirDate, defaults.AirDate)
+	fill(&k.Aliases, defaults.Aliases)
+	return k
 }
 
 // Note represents an Obsidian markdown note with frontmatter and body.
@@ -38,50 +234,161 @@ type Note struct {
 	Path        string
 	frontmatter map[string]any
 	body        string
+	flowTags    bool
+	keys        KeyMap
+	markers     Markers
+	placement   ContentPlacement
+	overrides   OverrideConfig
+	inline      InlineFields
+	// coverEncoding applies to cover and banner paths written by this run;
+	// quoted records the keys to write as double-quoted strings.
+	coverEncoding CoverEncoding
+	quoted        map[string]bool
+	// asciiFilenames transliterates generated cover and banner names.
+	asciiFilenames bool
+	// crlf records that the file used Windows line endings. Content is
+	// handled with "\n" internally and converted back on save.
+	crlf bool
+}
+
+// New returns an unsaved note at path with the given frontmatter. Call
+// Create to write it to disk.
+func New(path string, frontmatter map[string]any) *Note {
+	n := newNote(path, DefaultKeyMap())
+	for key, value := range frontmatter {
+		n.frontmatter[key] = value
+	}
+	return n
+}
+
+func newNote(path string, keys KeyMap) *Note {
+	return &Note{
+		Path:        path,
+		frontmatter: make(map[string]any),
+		keys:        keys.WithDefaults(),
+		markers:     DefaultMarkers(),
+		quoted:      make(map[string]bool),
+	}
+}
+
+// Create writes a new note to disk, creating its directory as needed.
+// Unless overwrite is set, it fails with an error wrapping fs.ErrExist when
+// the file already exists; the check and the write are a single exclusive
+// open, so a note created concurrently is never overwritten.
+func (n *Note) Create(overwrite bool) error {
+	if err := util.EnsureDir(filepath.Dir(n.Path)); err != nil {
+		return err
+	}
+	if overwrite {
+		return n.save()
+	}
+	output, err := n.render()
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(n.Path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(output); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return n.reload()
 }
 
-// Load reads and parses an Obsidian note from disk.
+// Load reads and parses an Obsidian note from disk using the default key map.
 func Load(path string) (*Note, error) {
+	return LoadWithKeyMap(path, DefaultKeyMap())
+}
+
+// LoadWithKeyMap reads and parses an Obsidian note from disk, using keys to
+// locate the frontmatter fields the tool reads and writes.
+func LoadWithKeyMap(path string, keys KeyMap) (*Note, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
 	content := string(data)
-	n := &Note{
-		Path:        path,
-		frontmatter: make(map[string]any),
-		body:        content,
+	crlf := strings.Contains(content, "\r\n")
+	if crlf {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
 	}
+	n := newNote(path, keys)
+	n.body = content
+	n.crlf = crlf
 
-	if !strings.HasPrefix(content, frontMatterDelimiter) {
+	firstLine, rest, _ := strings.Cut(content, "\n")
+	if firstLine != frontMatterDelimiter {
 		n.body = content
+		n.overrides = ParseOverrides(n.body)
 		return n, nil
 	}
 
-	trimmed := strings.TrimPrefix(content, frontMatterDelimiter)
-	trimmed = strings.TrimPrefix(trimmed, "\n")
-	parts := strings.SplitN(trimmed, "\n"+frontMatterDelimiter+"\n", 2)
-	if len(parts) != 2 {
-		// malformed frontmatter; treat entire file as body
-		n.body = content
-		return n, nil
+	fm, body, ok := splitFrontmatter(rest)
+	if ok {
+		err = yaml.Unmarshal([]byte(fm), &n.frontmatter)
+	} else {
+		fm = rest
 	}
-
-	fm := strings.TrimSuffix(parts[0], "\n")
-	body := parts[1]
-
-	if err := yaml.Unmarshal([]byte(fm), &n.frontmatter); err != nil {
-		// leave frontmatter empty, treat as body
+	if (!ok || err != nil) && !looksLikeYAML(fm) {
+		// a horizontal rule at the top of the body, not frontmatter
 		n.frontmatter = make(map[string]any)
-		n.body = content
+		n.overrides = ParseOverrides(n.body)
 		return n, nil
 	}
+	if !ok {
+		return nil, fmt.Errorf("%w: missing closing %s", ErrMalformedFrontmatter, frontMatterDelimiter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedFrontmatter, err)
+	}
+	if n.frontmatter == nil {
+		n.frontmatter = make(map[string]any)
+	}
 
+	n.flowTags = detectFlowTags(fm, n.keys.Tags)
+	n.quoted = detectQuoted(fm, n.keys.Cover, n.keys.Banner)
 	n.body = body
+	n.overrides = ParseOverrides(n.body)
 	return n, nil
 }
 
+// looksLikeYAML reports whether the first non-blank line of text is a YAML
+// "key:" line, i.e. whether text reads as a frontmatter block rather than
+// regular Markdown.
+func looksLikeYAML(text string) bool {
+	for line := range strings.SplitSeq(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return yamlKeyPattern.MatchString(line)
+		}
+	}
+	return false
+}
+
+// splitFrontmatter splits the text following the opening delimiter into the
+// frontmatter and the body. ok is false when there is no closing delimiter.
+func splitFrontmatter(rest string) (fm, body string, ok bool) {
+	closing := frontMatterDelimiter + "\n"
+	if strings.HasPrefix(rest, closing) {
+		return "", rest[len(closing):], true
+	}
+	if rest == frontMatterDelimiter {
+		return "", "", true
+	}
+	if fm, body, found := strings.Cut(rest, "\n"+closing); found {
+		return fm, body, true
+	}
+	if fm, found := strings.CutSuffix(rest, "\n"+frontMatterDelimiter); found {
+		return fm, "", true
+	}
+	return "", "", false
+}
+
 // Frontmatter returns the note's frontmatter as a map.
 func (n *Note) Frontmatter() map[string]any {
 	return n.frontmatter
@@ -94,26 +401,72 @@ func (n *Note) Body() string {
 
 // GetTitle extracts the note title from frontmatter, H1 header, or filename.
 func (n *Note) GetTitle() string {
-	if title, ok := n.frontmatter["title"].(string); ok && title != "" {
+	if title, ok := n.frontmatter[n.keys.Title].(string); ok && title != "" {
 		return title
 	}
 
-	lines := strings.Split(n.body, "\n")
-	for _, line := range lines {
+	if heading, ok := firstHeading(n.userBody()); ok {
+		return heading
+	}
+
+	return strings.TrimSuffix(filepath.Base(n.Path), filepath.Ext(n.Path))
+}
+
+// HasHeading reports whether the note body has an H1 heading outside the
+// generated TMDB content block.
+func (n *Note) HasHeading() bool {
+	_, ok := firstHeading(n.userBody())
+	return ok
+}
+
+// userBody returns the note body without the generated TMDB content block,
+// so headings we wrote ourselves aren't mistaken for the user's.
+func (n *Note) userBody() string {
+	existing, ok := n.findMarkers()
+	if !ok {
+		return n.body
+	}
+	startIdx := strings.Index(n.body, existing.Start)
+	endIdx := strings.Index(n.body, existing.End)
+	if startIdx == -1 || endIdx <= startIdx {
+		return n.body
+	}
+	return n.body[:startIdx] + n.body[endIdx+len(existing.End):]
+}
+
+// firstHeading returns the text of the first H1 heading in body.
+func firstHeading(body string) (string, bool) {
+	for _, line := range strings.Split(body, "\n") {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "# ") {
-			return strings.TrimSpace(line[2:])
+			return strings.TrimSpace(line[2:]), true
 		}
 	}
+	return "", false
+}
 
-	return strings.TrimSuffix(filepath.Base(n.Path), filepath.Ext(n.Path))
+// GetSearchQuery returns the TMDB search query for the note. An explicit
+// tmdb_query frontmatter value is used verbatim; otherwise the title is used.
+func (n *Note) GetSearchQuery() string {
+	if query, ok := n.frontmatter[n.keys.SearchQuery].(string); ok && strings.TrimSpace(query) != "" {
+		return query
+	}
+	return n.GetTitle()
 }
 
+// hasCover returns the note's cover. When the cover is a list of
+// candidates, the first entry is used.
 func (n *Note) hasCover() (string, bool) {
-	value, ok := n.frontmatter["cover"]
+	value, ok := n.frontmatter[n.keys.Cover]
 	if !ok {
 		return "", false
 	}
+	if list, ok := value.([]any); ok {
+		if len(list) == 0 {
+			return "", false
+		}
+		value = list[0]
+	}
 	cover, ok := value.(string)
 	if !ok || cover == "" {
 		return "", false
@@ -130,7 +483,7 @@ func (n *Note) HasExternalCover() bool {
 	if htmlColorPattern.MatchString(cover) {
 		return false
 	}
-	return strings.HasPrefix(cover, "http")
+	return isExternalURL(cover)
 }
 
 // GetExistingCoverURL returns the external cover URL if present.
@@ -142,105 +495,471 @@ func (n *Note) GetExistingCoverURL() (string, bool) {
 	return "", false
 }
 
-// GenerateLocalCoverPath generates a local path for the cover image.
-func (n *Note) GenerateLocalCoverPath(attachmentsDir string) string {
-	title := n.GetTitle()
-	filename := util.SanitizeFilename(title + " - cover.jpg")
+// ResolveLocalCover resolves a local cover reference (path, wikilink, or bare
+// file name) to a file on disk. The reference is tried relative to noteDir
+// first and then relative to each of extraDirs. It returns false if the cover
+// is not local or the referenced file does not exist.
+func (n *Note) ResolveLocalCover(noteDir string, extraDirs ...string) (string, bool) {
+	cover, ok := n.hasCover()
+	if !ok {
+		return "", false
+	}
+	return resolveLocalImage(cover, noteDir, extraDirs)
+}
+
+// LocalImages returns the existing local files referenced by the cover
+// (including every candidate of a cover list) and banner keys, resolved like
+// ResolveLocalCover.
+func (n *Note) LocalImages(noteDir string, extraDirs ...string) []string {
+	var values []any
+	if list, ok := n.frontmatter[n.keys.Cover].([]any); ok {
+		values = append(values, list...)
+	} else {
+		values = append(values, n.frontmatter[n.keys.Cover])
+	}
+	values = append(values, n.frontmatter[n.keys.Banner])
+
+	var images []string
+	for _, value := range values {
+		if ref, ok := value.(string); ok {
+			if image, ok := resolveLocalImage(ref, noteDir, extraDirs); ok {
+				images = append(images, image)
+			}
+		}
+	}
+	return images
+}
+
+// embedPattern matches image embeds in a note body: "![[Foo - cover.jpg]]"
+// (optionally with an alias or size) and "![alt](attachments/Foo.jpg)".
+var embedPattern = regexp.MustCompile(`!\[\[([^\]|#]+)[^\]]*\]\]|!\[[^\]]*\]\(<?([^)>]+?)>?\)`)
+
+// ImageReferences returns every local image reference in the note: the
+// cover (each candidate of a cover list) and banner keys as well as image
+// embeds in the body. Wikilink syntax is stripped; external URLs and colors
+// are left out. The files aren't resolved.
+func (n *Note) ImageReferences() []string {
+	var values []any
+	if list, ok := n.frontmatter[n.keys.Cover].([]any); ok {
+		values = append(values, list...)
+	} else {
+		values = append(values, n.frontmatter[n.keys.Cover])
+	}
+	values = append(values, n.frontmatter[n.keys.Banner])
+	for _, match := range embedPattern.FindAllStringSubmatch(n.body, -1) {
+		if match[1] != "" {
+			values = append(values, match[1])
+		} else {
+			values = append(values, match[2])
+		}
+	}
+
+	var refs []string
+	for _, value := range values {
+		ref, ok := value.(string)
+		if !ok || ref == "" || htmlColorPattern.MatchString(ref) || isExternalURL(ref) {
+			continue
+		}
+		if ref = localCoverReference(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// resolveLocalImage resolves a frontmatter image reference to an existing
+// file, trying noteDir and then extraDirs for relative references.
+func resolveLocalImage(value, noteDir string, extraDirs []string) (string, bool) {
+	if value == "" || htmlColorPattern.MatchString(value) || isExternalURL(value) {
+		return "", false
+	}
+
+	ref := localCoverReference(value)
+	if ref == "" {
+		return "", false
+	}
+
+	refs := []string{ref}
+	if decoded, ok := decodeImageReference(ref); ok {
+		// written with percent encoding; a literal "%" in a file name
+		// is still tried first
+		refs = append(refs, decoded)
+	}
+	var candidates []string
+	for _, ref := range refs {
+		if filepath.IsAbs(filepath.FromSlash(ref)) {
+			candidates = append(candidates, filepath.FromSlash(ref))
+			continue
+		}
+		for _, dir := range append([]string{noteDir}, extraDirs...) {
+			candidates = append(candidates, filepath.Join(dir, filepath.FromSlash(ref)))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// localCoverReference strips wikilink syntax and aliases from a cover value,
+// e.g. "![[attachments/Foo.jpg|cover]]" becomes "attachments/Foo.jpg".
+func localCoverReference(cover string) string {
+	ref := strings.TrimSpace(cover)
+	ref = strings.TrimPrefix(ref, "!")
+	if strings.HasPrefix(ref, "[[") && strings.HasSuffix(ref, "]]") {
+		ref = strings.TrimSuffix(strings.TrimPrefix(ref, "[["), "]]")
+		if idx := strings.Index(ref, "|"); idx != -1 {
+			ref = ref[:idx]
+		}
+	}
+	return strings.TrimSpace(ref)
+}
+
+// GenerateLocalCoverPath generates a local path for the cover image using
+// the given file extension (".jpg" when empty). A non-zero tmdbID is part of
+// the file name, e.g. "The Office - 2316 - cover.jpg", so notes sharing a
+// title don't overwrite each other's covers. Season and episode notes add
+// the season or episode, e.g. "Breaking Bad - 1396 - S02 - cover.jpg".
+func (n *Note) GenerateLocalCoverPath(attachmentsDir, ext string, tmdbID int) string {
+	return n.localImagePath(attachmentsDir, "cover", ext, tmdbID)
+}
+
+// GenerateLocalBannerPath generates a local path for the backdrop banner,
+// e.g. "attachments/Title - 949 - banner.jpg".
+func (n *Note) GenerateLocalBannerPath(attachmentsDir, ext string, tmdbID int) string {
+	return n.localImagePath(attachmentsDir, "banner", ext, tmdbID)
+}
+
+func (n *Note) localImagePath(attachmentsDir, kind, ext string, tmdbID int) string {
+	if ext == "" {
+		ext = ".jpg"
+	}
+	name := n.GetTitle()
+	if n.asciiFilenames {
+		name = util.ASCIIFilename(name, "")
+	}
+	var suffix string
+	switch {
+	case name == "" && tmdbID > 0:
+		// a title without ASCII characters leaves only the ID
+		name = strconv.Itoa(tmdbID)
+	case name == "":
+		// without an ID the title is kept as is
+		name = n.GetTitle()
+	case tmdbID > 0:
+		suffix = fmt.Sprintf(" - %d", tmdbID)
+	}
+	// season and episode notes share the show's ID and often its title
+	if season, episode, ok := n.GetEpisode(); ok {
+		suffix += fmt.Sprintf(" - S%02dE%02d", season, episode)
+	} else if season, ok := n.GetSeason(); ok {
+		suffix += fmt.Sprintf(" - S%02d", season)
+	}
+	// long titles are shortened, never the ID and extension
+	filename := util.SanitizeFilenameWithSuffix(name, suffix+" - "+kind+ext)
 	return filepath.Join(attachmentsDir, filename)
 }
 
+// SetASCIIFilenames makes GenerateLocalCoverPath and GenerateLocalBannerPath
+// transliterate the title to ASCII (see util.ASCIIFilename). A title with
+// nothing left is replaced by the TMDB ID.
+func (n *Note) SetASCIIFilenames(enabled bool) {
+	n.asciiFilenames = enabled
+}
+
+// HasBanner reports whether the note already has a banner image. A remote
+// URL counts as a banner the user chose and is kept; a local reference only
+// counts when it resolves to a file (see ResolveLocalBanner).
+func (n *Note) HasBanner(noteDir string, extraDirs ...string) bool {
+	banner, ok := n.frontmatter[n.keys.Banner].(string)
+	if ok && isExternalURL(strings.TrimSpace(banner)) {
+		return true
+	}
+	_, ok = n.ResolveLocalBanner(noteDir, extraDirs...)
+	return ok
+}
+
+// ResolveLocalBanner resolves a local banner reference to a file on disk,
+// looked up like ResolveLocalCover.
+func (n *Note) ResolveLocalBanner(noteDir string, extraDirs ...string) (string, bool) {
+	banner, _ := n.frontmatter[n.keys.Banner].(string)
+	return resolveLocalImage(banner, noteDir, extraDirs)
+}
+
+// UpdateBanner stores the relative banner path in frontmatter, formatted
+// like the cover.
+func (n *Note) UpdateBanner(relative string, format CoverFormat) error {
+	n.frontmatter[n.keys.Banner] = n.formatImagePath(n.keys.Banner, relative, format)
+	return n.save()
+}
+
 // GetRelativeCoverPath returns the relative path from the note to the cover.
+// The cover may live outside the vault, e.g. in a shared media folder.
 func (n *Note) GetRelativeCoverPath(localPath string) (string, error) {
-	noteDir := filepath.Dir(n.Path)
+	// filepath.Rel cannot mix relative and absolute paths
+	noteDir, err := filepath.Abs(filepath.Dir(n.Path))
+	if err != nil {
+		return "", err
+	}
+	localPath, err = filepath.Abs(localPath)
+	if err != nil {
+		return "", err
+	}
 	return util.RelativeTo(noteDir, localPath)
 }
 
-// UpdateCover updates the note's cover path in frontmatter.
-func (n *Note) UpdateCover(path string) error {
-	n.frontmatter["cover"] = path
+// UpdateCover updates the note's cover in frontmatter, formatting the
+// relative path according to format. A non-empty source (the TMDB poster
+// path the cover was downloaded from) is recorded alongside it.
+func (n *Note) UpdateCover(relative string, format CoverFormat, source string) error {
+	value := n.formatImagePath(n.keys.Cover, relative, format)
+	if list, ok := n.frontmatter[n.keys.Cover].([]any); ok && len(list) > 0 {
+		// replace the first candidate in place, keeping the rest
+		list[0] = value
+	} else {
+		n.frontmatter[n.keys.Cover] = value
+	}
+	if source != "" {
+		n.frontmatter[n.keys.CoverSource] = source
+	}
 	return n.save()
 }
 
-// UpdateMetadata updates the note's TMDB metadata in frontmatter.
+// ReplaceCoverExtension changes the extension of the local cover reference
+// to ext, keeping the rest of the value as written (wikilink, path, or file
+// name, aliases, and encoding). For a cover list, the first candidate
+// changes.
+func (n *Note) ReplaceCoverExtension(ext string) error {
+	cover, ok := n.hasCover()
+	if !ok {
+		return errors.New("note has no cover")
+	}
+	ref := localCoverReference(cover)
+	oldExt := path.Ext(ref)
+	idx := strings.LastIndex(cover, ref)
+	if oldExt == "" || idx == -1 {
+		return fmt.Errorf("cover %q has no file extension", cover)
+	}
+	value := cover[:idx] + strings.TrimSuffix(ref, oldExt) + ext + cover[idx+len(ref):]
+	if list, ok := n.frontmatter[n.keys.Cover].([]any); ok && len(list) > 0 {
+		list[0] = value
+	} else {
+		n.frontmatter[n.keys.Cover] = value
+	}
+	return n.save()
+}
+
+// GetCoverSource returns the poster path the current cover was downloaded from.
+func (n *Note) GetCoverSource() (string, bool) {
+	source, ok := n.frontmatter[n.keys.CoverSource].(string)
+	if !ok || source == "" {
+		return "", false
+	}
+	return source, true
+}
+
+// UpdateMetadata updates the note's TMDB metadata in frontmatter. Fields
+// selected with SetInlineFields are written to the content block instead of,
+// or in addition to, the frontmatter.
 func (n *Note) UpdateMetadata(meta Metadata) error {
+	var inline []inlineField
+	set := func(name, key string, value any) {
+		toInline, toFrontmatter := n.writesInline(name)
+		if toInline {
+			inline = append(inline, inlineField{key: key, value: inlineValue(value)})
+		}
+		if toFrontmatter {
+			n.frontmatter[key] = value
+		}
+	}
+
 	if meta.Runtime != nil {
-		n.frontmatter["runtime"] = *meta.Runtime
+		set("runtime", n.keys.Runtime, *meta.Runtime)
+	}
+	if meta.EpisodeRuntime != nil {
+		set("episode_runtime", n.keys.EpisodeRuntime, *meta.EpisodeRuntime)
 	}
 	if meta.TotalEpisodes != nil {
-		n.frontmatter["total_episodes"] = *meta.TotalEpisodes
+		set("total_episodes", n.keys.TotalEpisodes, *meta.TotalEpisodes)
+	}
+	if meta.Year != nil {
+		set("year", n.keys.Year, *meta.Year)
+	}
+	if len(meta.Directors) > 0 {
+		set("directors", n.keys.Directors, mergeTags(n.getStringList(n.keys.Directors), meta.Directors))
 	}
 	if len(meta.GenreTags) > 0 {
 		existing := n.getTags()
-		tagSet := make(map[string]struct{}, len(existing)+len(meta.GenreTags))
-		for _, t := range existing {
-			tagSet[t] = struct{}{}
-		}
-		for _, t := range meta.GenreTags {
-			tagSet[t] = struct{}{}
+		if meta.ReplaceGenreTags {
+			existing = slices.DeleteFunc(existing, func(tag string) bool {
+				return isGenreTag(tag) || isCompanyTag(tag)
+			})
 		}
-		merged := make([]string, 0, len(tagSet))
-		for tag := range tagSet {
-			merged = append(merged, tag)
-		}
-		sort.Strings(merged)
-		n.frontmatter["tags"] = merged
+		n.frontmatter[n.keys.Tags] = mergeTags(existing, meta.GenreTags)
 	}
 	if meta.TMDBID != nil {
-		n.frontmatter["tmdb_id"] = *meta.TMDBID
+		n.frontmatter[n.keys.TMDBID] = *meta.TMDBID
 	}
 	if meta.TMDBType != nil {
-		n.frontmatter["tmdb_type"] = *meta.TMDBType
+		n.frontmatter[n.keys.TMDBType] = *meta.TMDBType
+	}
+	if meta.ContentRating != nil && *meta.ContentRating != "" {
+		set("content_rating", n.keys.ContentRating, *meta.ContentRating)
+	}
+	if meta.Status != nil && *meta.Status != "" && n.ownsStatus() {
+		set("status", n.keys.Status, *meta.Status)
+	}
+	if meta.InProduction != nil {
+		set("in_production", n.keys.InProduction, *meta.InProduction)
+	}
+	if meta.EpisodeTitle != nil && *meta.EpisodeTitle != "" {
+		set("episode_title", n.keys.EpisodeTitle, *meta.EpisodeTitle)
+	}
+	if meta.AirDate != nil && *meta.AirDate != "" {
+		set("air_date", n.keys.AirDate, *meta.AirDate)
+	}
+	if len(inline) > 0 {
+		n.setInlineFields(inline)
 	}
 	return n.save()
 }
 
-// UpdateBodyContent updates or injects TMDB content into the note body.
+// tmdbStatuses lists the release and airing states TMDB reports for movies
+// and TV shows.
+var tmdbStatuses = []string{
+	"Rumored", "Planned", "In Production", "Post Production", "Released",
+	"Canceled", "Returning Series", "Ended", "Pilot",
+}
+
+// ownsStatus reports whether the status key may be written: it is unset or
+// already holds a TMDB status. Vaults often keep their own watch state
+// ("watched", "to-watch") under a status key, which is never overwritten.
+func (n *Note) ownsStatus() bool {
+	value, ok := n.frontmatter[n.keys.Status]
+	if !ok || value == nil {
+		return true
+	}
+	status, ok := value.(string)
+	return ok && (status == "" || slices.Contains(tmdbStatuses, status))
+}
+
+// UpdateBodyContent updates or injects TMDB content into the note body. An
+// existing block is replaced in place; the text around it is kept byte for
+// byte, apart from adding a line break between it and a marker if missing.
+// Inline fields at the top of the block are kept while SetInlineFields
+// selects any.
 func (n *Note) UpdateBodyContent(content string) error {
 	body := strings.TrimSpace(content)
 	if body == "" {
 		return errors.New("empty content")
 	}
 
-	if n.HasTMDBContentMarkers() {
-		startIdx := strings.Index(n.body, startMarker)
-		endIdx := strings.Index(n.body, endMarker)
-		if startIdx != -1 && endIdx != -1 && endIdx > startIdx {
-			before := strings.TrimSpace(n.body[:startIdx])
-			after := strings.TrimSpace(n.body[endIdx+len(endMarker):])
-
-			var builder strings.Builder
-			if before != "" {
-				builder.WriteString(before)
-				builder.WriteString("\n\n")
-			}
-			builder.WriteString(startMarker)
-			builder.WriteString("\n")
-			builder.WriteString(body)
-			builder.WriteString("\n")
-			builder.WriteString(endMarker)
-			if after != "" {
-				builder.WriteString("\n")
-				builder.WriteString(after)
-			}
-			n.body = builder.String()
-			return n.save()
+	if current, existing, ok := n.blockContent(); ok {
+		if len(n.inline.Fields) > 0 {
+			fields, _ := splitInlineFields(current)
+			body = joinInlineFields(fields, body)
 		}
+		n.replaceBlock(existing, body)
+		return n.save()
 	}
 	return n.injectTMDBMarkers(body)
 }
 
-// HasTMDBContentMarkers returns true if the note contains TMDB content markers.
+// blockContent returns the text between the content markers and the
+// markers found.
+func (n *Note) blockContent() (string, Markers, bool) {
+	existing, ok := n.findMarkers()
+	if !ok {
+		return "", Markers{}, false
+	}
+	startIdx := strings.Index(n.body, existing.Start)
+	endIdx := strings.Index(n.body, existing.End)
+	if startIdx == -1 || endIdx == -1 || endIdx < startIdx {
+		return "", Markers{}, false
+	}
+	return n.body[startIdx+len(existing.Start) : endIdx], existing, true
+}
+
+// replaceBlock replaces the block delimited by existing with content,
+// writing the configured markers. The note isn't saved.
+func (n *Note) replaceBlock(existing Markers, content string) {
+	startIdx := strings.Index(n.body, existing.Start)
+	endIdx := strings.Index(n.body, existing.End)
+	before := n.body[:startIdx]
+	after := n.body[endIdx+len(existing.End):]
+
+	var builder strings.Builder
+	builder.WriteString(before)
+	if before != "" && !strings.HasSuffix(before, "\n") {
+		builder.WriteString("\n")
+	}
+	builder.WriteString(n.markers.Start)
+	builder.WriteString("\n")
+	builder.WriteString(content)
+	builder.WriteString("\n")
+	builder.WriteString(n.markers.End)
+	if after != "" && !strings.HasPrefix(after, "\n") {
+		builder.WriteString("\n")
+	}
+	builder.WriteString(after)
+	n.body = builder.String()
+}
+
+// HasTMDBContentMarkers returns true if the note contains TMDB content markers,
+// either the configured ones or the default markers.
 func (n *Note) HasTMDBContentMarkers() bool {
-	return strings.Contains(n.body, startMarker) && strings.Contains(n.body, endMarker)
+	_, ok := n.findMarkers()
+	return ok
+}
+
+// SetPlacement sets where a new content block is inserted.
+func (n *Note) SetPlacement(p ContentPlacement) {
+	n.placement = p
+}
+
+// SetMarkers sets the markers used to delimit generated content.
+func (n *Note) SetMarkers(m Markers) {
+	if m.Start == "" || m.End == "" {
+		m = DefaultMarkers()
+	}
+	n.markers = m
+}
+
+// findMarkers returns the markers present in the body, preferring the
+// configured markers and falling back to the defaults for older notes.
+func (n *Note) findMarkers() (Markers, bool) {
+	for _, m := range []Markers{n.markers, DefaultMarkers()} {
+		if strings.Contains(n.body, m.Start) && strings.Contains(n.body, m.End) {
+			return m, true
+		}
+	}
+	return Markers{}, false
 }
 
 // GetTMDBID returns the TMDB ID stored in the note's frontmatter.
 func (n *Note) GetTMDBID() (int, bool) {
-	id, ok := n.frontmatter["tmdb_id"]
+	return n.intValue(n.keys.TMDBID)
+}
+
+// ClearTMDBID removes a stale TMDB ID, along with the cover source recorded
+// for it, so the note is searched again. The type is kept to narrow that
+// search.
+func (n *Note) ClearTMDBID() error {
+	delete(n.frontmatter, n.keys.TMDBID)
+	delete(n.frontmatter, n.keys.CoverSource)
+	return n.save()
+}
+
+// intValue returns the frontmatter value under key as an integer.
+func (n *Note) intValue(key string) (int, bool) {
+	value, ok := n.frontmatter[key]
 	if !ok {
 		return 0, false
 	}
-	switch v := id.(type) {
+	switch v := value.(type) {
 	case int:
 		return v, true
 	case int64:
@@ -252,14 +971,14 @@ func (n *Note) GetTMDBID() (int, bool) {
 	}
 }
 
-// GetTMDBType returns the TMDB type (movie or tv) stored in frontmatter.
+// GetTMDBType returns the TMDB type (movie, tv, or person) stored in frontmatter.
 func (n *Note) GetTMDBType() (string, bool) {
-	value, ok := n.frontmatter["tmdb_type"].(string)
+	value, ok := n.frontmatter[n.keys.TMDBType].(string)
 	if !ok {
 		return "", false
 	}
 	value = strings.TrimSpace(value)
-	if value != "movie" && value != "tv" {
+	if value != "movie" && value != "tv" && value != "person" {
 		return "", false
 	}
 	return value, true
@@ -272,14 +991,29 @@ func (n *Note) AttachmentsDir(basePath string) (string, error) {
 }
 
 func (n *Note) save() error {
+	output, err := n.render()
+	if err != nil {
+		return err
+	}
+	// skip the write when nothing changed, keeping the modification time
+	if current, err := os.ReadFile(n.Path); err != nil || string(current) != output {
+		if err := os.WriteFile(n.Path, []byte(output), 0o644); err != nil {
+			return err
+		}
+	}
+	return n.reload()
+}
+
+// render returns the note's file contents: frontmatter followed by the body.
+func (n *Note) render() (string, error) {
 	var builder strings.Builder
 	builder.WriteString(frontMatterDelimiter)
 	builder.WriteString("\n")
 
 	if len(n.frontmatter) > 0 {
-		data, err := yaml.Marshal(n.frontmatter)
+		data, err := n.marshalFrontmatter()
 		if err != nil {
-			return err
+			return "", err
 		}
 		builder.Write(data)
 		if !strings.HasSuffix(builder.String(), "\n") {
@@ -290,15 +1024,19 @@ func (n *Note) save() error {
 	builder.WriteString(frontMatterDelimiter)
 	builder.WriteString("\n")
 	builder.WriteString(strings.TrimLeft(n.body, "\n"))
-	if !strings.HasSuffix(builder.String(), "\n") {
-		builder.WriteString("\n")
-	}
 
-	if err := os.WriteFile(n.Path, []byte(builder.String()), 0o644); err != nil {
-		return err
+	// end with exactly one newline so repeated saves are stable
+	output := strings.TrimRight(builder.String(), "\n") + "\n"
+	if n.crlf {
+		output = strings.ReplaceAll(output, "\n", "\r\n")
 	}
-	// refresh body/frontmatter to reflect canonical formatting
-	updated, err := Load(n.Path)
+	return output, nil
+}
+
+// reload refreshes body and frontmatter from disk to reflect canonical
+// formatting.
+func (n *Note) reload() error {
+	updated, err := LoadWithKeyMap(n.Path, n.keys)
 	if err != nil {
 		return err
 	}
@@ -307,8 +1045,95 @@ func (n *Note) save() error {
 	return nil
 }
 
+// marshalFrontmatter encodes the frontmatter, keeping the tags list in the
+// same YAML style (flow or block) that the note originally used.
+func (n *Note) marshalFrontmatter() ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(n.frontmatter); err != nil {
+		return nil, err
+	}
+	if n.flowTags {
+		if tags := mappingValue(&doc, n.keys.Tags); tags != nil && tags.Kind == yaml.SequenceNode {
+			tags.Style = yaml.FlowStyle
+		}
+	}
+	for key := range n.quoted {
+		if value := firstScalar(mappingValue(&doc, key)); value != nil {
+			value.Style = yaml.DoubleQuotedStyle
+		}
+	}
+	return yaml.Marshal(&doc)
+}
+
+// detectFlowTags reports whether the raw frontmatter declares tags as a
+// flow sequence, e.g. `tags: [a, b]`.
+func detectFlowTags(fm, key string) bool {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(fm), &doc); err != nil {
+		return false
+	}
+	root := &doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	tags := mappingValue(root, key)
+	return tags != nil && tags.Kind == yaml.SequenceNode && tags.Style&yaml.FlowStyle != 0
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mergeTags appends tags from additions that are not already present,
+// keeping the existing tags in their original order.
+func mergeTags(existing, additions []string) []string {
+	seen := make(map[string]struct{}, len(existing)+len(additions))
+	merged := make([]string, 0, len(existing)+len(additions))
+	for _, t := range existing {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		merged = append(merged, t)
+	}
+	for _, t := range additions {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		merged = append(merged, t)
+	}
+	return merged
+}
+
 func (n *Note) getTags() []string {
-	value, ok := n.frontmatter["tags"]
+	return n.getStringList(n.keys.Tags)
+}
+
+// Aliases returns the note's Obsidian aliases. A single alias may be given
+// as a plain string.
+func (n *Note) Aliases() []string {
+	if alias, ok := n.frontmatter[n.keys.Aliases].(string); ok {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			return []string{alias}
+		}
+		return nil
+	}
+	return n.getStringList(n.keys.Aliases)
+}
+
+// getStringList returns a frontmatter list of strings, or nil when the key
+// is missing or not a list.
+func (n *Note) getStringList(key string) []string {
+	value, ok := n.frontmatter[key]
 	if !ok {
 		return nil
 	}
@@ -329,20 +1154,52 @@ func (n *Note) getTags() []string {
 }
 
 func (n *Note) injectTMDBMarkers(content string) error {
+	n.insertBlock(content)
+	return n.save()
+}
+
+// insertBlock adds a new content block at the configured placement. The
+// note isn't saved.
+func (n *Note) insertBlock(content string) {
+	block := n.markers.Start + "\n" + content + "\n" + n.markers.End + "\n"
+
+	var before, after string
+	switch n.placement {
+	case PlacementTop:
+		after = n.body
+	case PlacementAfterH1:
+		before, after = splitAfterHeading(n.body)
+	default:
+		before = n.body
+	}
+	before = strings.TrimRight(before, "\n")
+	after = strings.Trim(after, "\n")
+
 	var builder strings.Builder
-	body := strings.TrimRight(n.body, "\n")
-	if body != "" {
-		builder.WriteString(body)
+	if before != "" {
+		builder.WriteString(before)
 		builder.WriteString("\n\n")
 	}
-	builder.WriteString(startMarker)
-	builder.WriteString("\n")
-	builder.WriteString(content)
-	builder.WriteString("\n")
-	builder.WriteString(endMarker)
-	builder.WriteString("\n")
+	builder.WriteString(block)
+	if after != "" {
+		builder.WriteString("\n")
+		builder.WriteString(after)
+		builder.WriteString("\n")
+	}
 	n.body = builder.String()
-	return n.save()
+}
+
+// splitAfterHeading splits body after its first H1 line. Without an H1 the
+// whole body ends up after the split point.
+func splitAfterHeading(body string) (before, after string) {
+	offset := 0
+	for _, line := range strings.SplitAfter(body, "\n") {
+		offset += len(line)
+		if strings.HasPrefix(strings.TrimSpace(line), "# ") {
+			return body[:offset], body[offset:]
+		}
+	}
+	return "", body
 }
 
 // NeedsCover returns true if the note needs a cover image.
@@ -354,25 +1211,83 @@ func (n *Note) NeedsCover() bool {
 	if htmlColorPattern.MatchString(cover) {
 		return true
 	}
-	if strings.HasPrefix(cover, "http") {
+	if isExternalURL(cover) {
 		return true
 	}
 	return false
 }
 
+// isExternalURL reports whether a cover value points at a remote image rather
+// than a local path, wikilink, or bare file name.
+func isExternalURL(cover string) bool {
+	lower := strings.ToLower(cover)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
 // NeedsMetadata returns true if the note needs TMDB metadata.
 func (n *Note) NeedsMetadata() bool {
-	if _, ok := n.frontmatter["runtime"]; !ok {
+	// People have no runtime or genres to store
+	if tmdbType, ok := n.GetTMDBType(); ok && tmdbType == "person" {
+		return false
+	}
+
+	if _, _, ok := n.GetEpisode(); ok {
+		if !n.hasField(n.keys.EpisodeTitle) {
+			return true
+		}
+	}
+
+	if !n.hasField(n.keys.Runtime) && !n.hasField(n.keys.EpisodeRuntime) {
 		return true
 	}
 
 	// Check for existing genre tags
-	for _, tag := range n.getTags() {
-		if strings.HasPrefix(tag, "movie/") || strings.HasPrefix(tag, "tv/") {
-			return false
-		}
+	return !slices.ContainsFunc(n.getTags(), isGenreTag)
+}
+
+// isGenreTag reports whether tag is a genre tag written by the tool, e.g.
+// "movie/Action" or "tv/Drama".
+func isGenreTag(tag string) bool {
+	tag = strings.TrimPrefix(tag, "#")
+	return strings.HasPrefix(tag, "movie/") || strings.HasPrefix(tag, "tv/")
+}
+
+// isCompanyTag reports whether tag is a network or studio tag written by the
+// tool, e.g. "network/HBO" or "studio/A24".
+func isCompanyTag(tag string) bool {
+	tag = strings.TrimPrefix(tag, "#")
+	return strings.HasPrefix(tag, "network/") || strings.HasPrefix(tag, "studio/")
+}
+
+// GetEpisode returns the season and episode numbers of an episode note: a
+// note with tmdb_type tv (tmdb_id is the show's ID) and season and episode
+// frontmatter.
+func (n *Note) GetEpisode() (season, episode int, ok bool) {
+	if tmdbType, ok := n.GetTMDBType(); !ok || tmdbType != "tv" {
+		return 0, 0, false
+	}
+	season, okSeason := n.intValue(n.keys.Season)
+	episode, okEpisode := n.intValue(n.keys.Episode)
+	if !okSeason || !okEpisode || season < 0 || episode < 1 {
+		return 0, 0, false
+	}
+	return season, episode, true
+}
+
+// GetSeason returns the season number of a season note: a note with
+// tmdb_type tv (tmdb_id is the show's ID) and a season but no episode.
+func (n *Note) GetSeason() (int, bool) {
+	if tmdbType, ok := n.GetTMDBType(); !ok || tmdbType != "tv" {
+		return 0, false
+	}
+	if _, ok := n.intValue(n.keys.Episode); ok {
+		return 0, false
+	}
+	season, ok := n.intValue(n.keys.Season)
+	if !ok || season < 0 {
+		return 0, false
 	}
-	return true
+	return season, true
 }
 
 // NeedsTMDB returns true if the note needs TMDB ID and type stored.
@@ -1,9 +1,17 @@
 package note_test
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/lepinkainen/obsidian-tmdb-cover/internal/note"
 )
@@ -104,3 +112,942 @@ Some content here.
 		t.Fatalf("expected TMDB markers to be injected")
 	}
 }
+
+func TestUpdateMetadataPreservesTagOrderAndStyle(t *testing.T) {
+	tests := map[string]struct {
+		initial string
+		want    string
+	}{
+		"block list": {
+			initial: "---\ntitle: Test\ntags:\n  - zeta\n  - alpha\n---\n\nBody\n",
+			want:    "---\ntags:\n    - zeta\n    - alpha\n    - movie/Action\ntitle: Test\n---\nBody\n",
+		},
+		"flow list": {
+			initial: "---\ntitle: Test\ntags: [zeta, alpha]\n---\n\nBody\n",
+			want:    "---\ntags: [zeta, alpha, movie/Action]\ntitle: Test\n---\nBody\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(path, []byte(tc.initial), 0o644); err != nil {
+				t.Fatalf("failed to write note: %v", err)
+			}
+			n, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("failed to load note: %v", err)
+			}
+			meta := note.Metadata{GenreTags: []string{"alpha", "movie/Action"}}
+			if err := n.UpdateMetadata(meta); err != nil {
+				t.Fatalf("update metadata failed: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read note: %v", err)
+			}
+			if got := string(data); got != tc.want {
+				t.Fatalf("unexpected output:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdateMetadataReplaceGenreTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.md")
+	initial := "---\ntitle: Heat\ntags:\n  - favorite\n  - tv/Drama\n  - watched/2024\n  - movie/Comedy\n  - keyword/heist\n---\n"
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	meta := note.Metadata{GenreTags: []string{"movie/Crime", "movie/Drama"}, ReplaceGenreTags: true}
+	if err := n.UpdateMetadata(meta); err != nil {
+		t.Fatalf("update metadata failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read note: %v", err)
+	}
+	want := "---\ntags:\n    - favorite\n    - watched/2024\n    - keyword/heist\n    - movie/Crime\n    - movie/Drama\ntitle: Heat\n---\n"
+	if string(data) != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestUpdateMetadataStatus(t *testing.T) {
+	tests := map[string]struct {
+		status       string
+		inProduction bool
+		want         string
+	}{
+		"ongoing": {"Returning Series", true, "---\ntitle: Severance\ntmdb_in_production: true\ntmdb_status: Returning Series\n---\n"},
+		"ended":   {"Ended", false, "---\ntitle: Severance\ntmdb_in_production: false\ntmdb_status: Ended\n---\n"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(path, []byte("---\ntitle: Severance\ntmdb_status: Returning Series\ntmdb_in_production: true\n---\n"), 0o644); err != nil {
+				t.Fatalf("failed to write note: %v", err)
+			}
+			n, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("failed to load note: %v", err)
+			}
+			if err := n.UpdateMetadata(note.Metadata{Status: &tc.status, InProduction: &tc.inProduction}); err != nil {
+				t.Fatalf("update metadata failed: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read note: %v", err)
+			}
+			if got := string(data); got != tc.want {
+				t.Fatalf("unexpected output:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdateMetadataKeepsUserStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.md")
+	if err := os.WriteFile(path, []byte("---\ntitle: Severance\nstatus: watched\n---\n"), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	ended := "Ended"
+	if err := n.UpdateMetadata(note.Metadata{Status: &ended}); err != nil {
+		t.Fatalf("update metadata failed: %v", err)
+	}
+	if fm := n.Frontmatter(); fm["status"] != "watched" || fm["tmdb_status"] != "Ended" {
+		t.Fatalf("expected status to stay watched and tmdb_status to be set, got %v", fm)
+	}
+
+	keys := note.DefaultKeyMap()
+	keys.Status = "status"
+	tests := map[string]struct {
+		initial string
+		want    string
+	}{
+		"watch state": {"status: watched\n", "watched"},
+		"tmdb status": {"status: Returning Series\n", "Ended"},
+		"unset":       {"", "Ended"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(path, []byte("---\ntitle: Severance\n"+tc.initial+"---\n"), 0o644); err != nil {
+				t.Fatalf("failed to write note: %v", err)
+			}
+			n, err := note.LoadWithKeyMap(path, keys)
+			if err != nil {
+				t.Fatalf("failed to load note: %v", err)
+			}
+			status := "Ended"
+			if err := n.UpdateMetadata(note.Metadata{Status: &status}); err != nil {
+				t.Fatalf("update metadata failed: %v", err)
+			}
+			if got := n.Frontmatter()["status"]; got != tc.want {
+				t.Fatalf("status = %v, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDiffFrontmatter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.md")
+	initial := "---\ntitle: Heat\ntags: [drama]\nrating: PG\nbanner: old.jpg\n---\n"
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	before := n.Snapshot()
+	delete(n.Frontmatter(), "banner")
+	runtime := 170
+	if err := n.UpdateMetadata(note.Metadata{Runtime: &runtime, GenreTags: []string{"drama", "movie/Crime"}}); err != nil {
+		t.Fatalf("update metadata failed: %v", err)
+	}
+
+	var got []string
+	for _, change := range note.DiffFrontmatter(before, n.Frontmatter()) {
+		got = append(got, change.String())
+	}
+	want := []string{
+		"- banner: old.jpg",
+		"+ runtime: 170",
+		"~ tags: [drama] → [drama, movie/Crime]",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestReplaceCoverExtension(t *testing.T) {
+	tests := []struct {
+		cover any
+		want  any
+	}{
+		{"attachments/Test - cover.jpg", "attachments/Test - cover.png"},
+		{"[[Test - cover.jpeg|poster]]", "[[Test - cover.png|poster]]"},
+		{"![[attachments/Test - cover.jpg]]", "![[attachments/Test - cover.png]]"},
+		{"attachments/Test%20-%20cover.jpg", "attachments/Test%20-%20cover.png"},
+		{[]any{"Test - cover.jpg", "Other.jpg"}, []any{"Test - cover.png", "Other.jpg"}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "test.md")
+		n := note.New(path, map[string]any{"title": "Test", "cover": tt.cover})
+		if err := n.ReplaceCoverExtension(".png"); err != nil {
+			t.Fatalf("%v: ReplaceCoverExtension failed: %v", tt.cover, err)
+		}
+		reloaded, err := note.Load(path)
+		if err != nil {
+			t.Fatalf("failed to reload note: %v", err)
+		}
+		if got := reloaded.Frontmatter()["cover"]; !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%v: expected cover %#v, got %#v", tt.cover, tt.want, got)
+		}
+	}
+
+	n := note.New(filepath.Join(t.TempDir(), "test.md"), map[string]any{"title": "Test"})
+	if err := n.ReplaceCoverExtension(".png"); err == nil {
+		t.Fatalf("expected an error for a note without a cover")
+	}
+}
+
+func TestUpdateCoverFormats(t *testing.T) {
+	tests := map[note.CoverFormat]string{
+		note.CoverFormatPath:     "attachments/Test - cover.jpg",
+		note.CoverFormatWikilink: "[[attachments/Test - cover.jpg]]",
+		note.CoverFormatFilename: "Test - cover.jpg",
+	}
+
+	for format, want := range tests {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(path, []byte("---\ntitle: Test\ncover: https://example.com/poster.jpg\n---\n"), 0o644); err != nil {
+				t.Fatalf("failed to write note: %v", err)
+			}
+			n, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("failed to load note: %v", err)
+			}
+			if !n.NeedsCover() || !n.HasExternalCover() {
+				t.Fatalf("expected external cover to need download")
+			}
+			if err := n.UpdateCover("attachments/Test - cover.jpg", format, "/poster.jpg"); err != nil {
+				t.Fatalf("update cover failed: %v", err)
+			}
+
+			reloaded, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("failed to reload note: %v", err)
+			}
+			if got := reloaded.Frontmatter()["cover"]; got != want {
+				t.Fatalf("expected cover %q, got %#v", want, got)
+			}
+			if reloaded.NeedsCover() {
+				t.Fatalf("expected %s cover to be recognized as local", format)
+			}
+			if source, ok := reloaded.GetCoverSource(); !ok || source != "/poster.jpg" {
+				t.Fatalf("expected cover source to be stored, got %q", source)
+			}
+			if reloaded.HasExternalCover() {
+				t.Fatalf("expected %s cover not to be external", format)
+			}
+		})
+	}
+}
+
+func TestCoverEncoding(t *testing.T) {
+	tests := []struct {
+		encoding note.CoverEncoding
+		file     string
+		want     string
+	}{
+		{note.CoverEncodingPercent, "The Matrix - 603 - cover.jpg", "cover: attachments/The%20Matrix%20-%20603%20-%20cover.jpg\n"},
+		{note.CoverEncodingPercent, "Amélie - 194 - cover.jpg", "cover: attachments/Am%C3%A9lie%20-%20194%20-%20cover.jpg\n"},
+		{note.CoverEncodingQuoted, "The Matrix - 603 - cover.jpg", "cover: \"attachments/The Matrix - 603 - cover.jpg\"\n"},
+		{note.CoverEncodingQuoted, "Amélie - 194 - cover.jpg", "cover: \"attachments/Amélie - 194 - cover.jpg\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.encoding)+"/"+tt.file, func(t *testing.T) {
+			dir := t.TempDir()
+			cover := filepath.Join(dir, "attachments", tt.file)
+			if err := os.MkdirAll(filepath.Dir(cover), 0o755); err != nil {
+				t.Fatalf("failed to create attachments: %v", err)
+			}
+			if err := os.WriteFile(cover, []byte("jpg"), 0o644); err != nil {
+				t.Fatalf("failed to write cover: %v", err)
+			}
+			path := filepath.Join(dir, "note.md")
+			if err := os.WriteFile(path, []byte("---\ntitle: Test\n---\n"), 0o644); err != nil {
+				t.Fatalf("failed to write note: %v", err)
+			}
+			n, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("failed to load note: %v", err)
+			}
+			n.SetCoverEncoding(tt.encoding)
+			if err := n.UpdateCover("attachments/"+tt.file, note.CoverFormatPath, ""); err != nil {
+				t.Fatalf("update cover failed: %v", err)
+			}
+
+			// a later run without the option keeps the value as written
+			reloaded, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("failed to reload note: %v", err)
+			}
+			year := 1999
+			if err := reloaded.UpdateMetadata(note.Metadata{Year: &year}); err != nil {
+				t.Fatalf("update metadata failed: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read note: %v", err)
+			}
+			if !strings.Contains(string(data), tt.want) {
+				t.Fatalf("expected %q in:\n%s", tt.want, data)
+			}
+			if reloaded.NeedsCover() || reloaded.HasExternalCover() {
+				t.Fatalf("expected the encoded cover to count as a local cover")
+			}
+			if got, ok := reloaded.ResolveLocalCover(dir); !ok || got != cover {
+				t.Fatalf("expected the cover to resolve to %s, got %q (%v)", cover, got, ok)
+			}
+		})
+	}
+}
+
+func TestParseCoverFormat(t *testing.T) {
+	tests := map[string]note.CoverFormat{
+		"":         note.CoverFormatPath,
+		"path":     note.CoverFormatPath,
+		"WikiLink": note.CoverFormatWikilink,
+		"filename": note.CoverFormatFilename,
+	}
+	for input, want := range tests {
+		got, err := note.ParseCoverFormat(input)
+		if err != nil || got != want {
+			t.Fatalf("ParseCoverFormat(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := note.ParseCoverFormat("bogus"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestGetSearchQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Dune Notes.md")
+	initial := "---\ntitle: \"DUNE: PART TWO — my notes\"\ntmdb_query: Dune Part Two\n---\n"
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if got := n.GetSearchQuery(); got != "Dune Part Two" {
+		t.Fatalf("expected tmdb_query override, got %q", got)
+	}
+	if got := n.GetTitle(); got != "DUNE: PART TWO — my notes" {
+		t.Fatalf("expected title to be unaffected, got %q", got)
+	}
+}
+
+func TestHasHeadingIgnoresGeneratedBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.md")
+	initial := "---\ntitle: Dune\n---\n<!-- TMDB_DATA_START -->\n# Dune (2021)\n<!-- TMDB_DATA_END -->\n"
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if n.HasHeading() {
+		t.Fatalf("expected generated heading to be ignored")
+	}
+}
+
+func TestLoadMalformedFrontmatter(t *testing.T) {
+	cases := map[string]string{
+		"invalid yaml": "---\ntitle: [unclosed\n---\n\nBody\n",
+		"unterminated": "---\ntitle: Test\n\nBody without closing delimiter\n",
+	}
+	for name, initial := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+				t.Fatalf("failed to write note: %v", err)
+			}
+
+			if _, err := note.Load(path); !errors.Is(err, note.ErrMalformedFrontmatter) {
+				t.Fatalf("expected ErrMalformedFrontmatter, got %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read note: %v", err)
+			}
+			if string(data) != initial {
+				t.Fatalf("expected file to be untouched, got:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestLoadLeadingHorizontalRule(t *testing.T) {
+	cases := map[string]string{
+		"unterminated": "---\n\nA note that opens with a rule.\n",
+		"closed":       "---\nSome text between rules.\n---\n\nMore text.\n",
+	}
+	for name, initial := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "Heat.md")
+			if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+				t.Fatalf("failed to write note: %v", err)
+			}
+
+			n, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("expected the rule to be read as body, got %v", err)
+			}
+			if n.Body() != initial || len(n.Frontmatter()) != 0 {
+				t.Fatalf("expected the whole note as body, got frontmatter %v and body:\n%s", n.Frontmatter(), n.Body())
+			}
+			id, mediaType := 949, "movie"
+			if err := n.UpdateMetadata(note.Metadata{TMDBID: &id, TMDBType: &mediaType}); err != nil {
+				t.Fatalf("update failed: %v", err)
+			}
+			reloaded, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("failed to reload note: %v", err)
+			}
+			if id, ok := reloaded.GetTMDBID(); !ok || id != 949 || reloaded.Body() != initial {
+				t.Fatalf("expected frontmatter added above the original body, got ID %d and body:\n%s", id, reloaded.Body())
+			}
+		})
+	}
+}
+
+func TestCRLFLineEndingsPreserved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.md")
+	initial := "---\r\ntitle: Windows Movie\r\ntags:\r\n  - existing\r\n---\r\n\r\nMy notes\r\n"
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if got := n.GetTitle(); got != "Windows Movie" {
+		t.Fatalf("expected frontmatter title, got %q", got)
+	}
+
+	runtime := 100
+	if err := n.UpdateMetadata(note.Metadata{Runtime: &runtime}); err != nil {
+		t.Fatalf("update metadata failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read note: %v", err)
+	}
+	content := string(data)
+	if strings.Count(content, "\n") != strings.Count(content, "\r\n") {
+		t.Fatalf("expected only CRLF line endings, got %q", content)
+	}
+	if !strings.Contains(content, "runtime: 100\r\n") || !strings.Contains(content, "My notes\r\n") {
+		t.Fatalf("expected runtime and body to be kept, got %q", content)
+	}
+}
+
+func TestContentPlacementAfterH1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.md")
+	initial := "---\ntitle: Test\n---\n# Test\n\nMy thoughts\n"
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	n.SetPlacement(note.PlacementAfterH1)
+	if err := n.UpdateBodyContent("## Overview\n\nText"); err != nil {
+		t.Fatalf("update content failed: %v", err)
+	}
+
+	want := "# Test\n\n<!-- TMDB_DATA_START -->\n## Overview\n\nText\n<!-- TMDB_DATA_END -->\n\nMy thoughts\n"
+	if got := n.Body(); got != want {
+		t.Fatalf("unexpected body:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestUpdateBodyContentPreservesSurroundingText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	initial := "---\ntitle: Test\n---\nIntro  \n\n\n<!-- TMDB_DATA_START -->\nold\n<!-- TMDB_DATA_END -->\n\n\nMy thoughts  \n\n\n- list item\n"
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+
+	want := strings.Replace(initial, "\nold\n", "\n## Overview\n\nText\n", 1)
+	for run := 1; run <= 2; run++ {
+		n, err := note.Load(path)
+		if err != nil {
+			t.Fatalf("failed to load note: %v", err)
+		}
+		if err := n.UpdateBodyContent("## Overview\n\nText"); err != nil {
+			t.Fatalf("update content failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read note: %v", err)
+		}
+		if string(data) != want {
+			t.Fatalf("run %d changed the text around the block:\n%q\nwant:\n%q", run, data, want)
+		}
+	}
+}
+
+func TestInlineFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	if err := os.WriteFile(path, []byte("---\ntitle: Heat\n---\n# Heat\n"), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+	fields := note.InlineFields{Fields: []string{"runtime", "year"}, Only: true}
+
+	update := func(runtime int) {
+		t.Helper()
+		n, err := note.Load(path)
+		if err != nil {
+			t.Fatalf("failed to load note: %v", err)
+		}
+		n.SetInlineFields(fields)
+		year := 1995
+		if err := n.UpdateMetadata(note.Metadata{Runtime: &runtime, Year: &year, GenreTags: []string{"movie/Crime"}}); err != nil {
+			t.Fatalf("update metadata failed: %v", err)
+		}
+		if err := n.UpdateBodyContent("## Overview\n\nText"); err != nil {
+			t.Fatalf("update content failed: %v", err)
+		}
+	}
+	update(170)
+	update(171)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read note: %v", err)
+	}
+	want := "---\ntags:\n    - movie/Crime\ntitle: Heat\n---\n# Heat\n\n<!-- TMDB_DATA_START -->\nruntime:: 171\nyear:: 1995\n\n## Overview\n\nText\n<!-- TMDB_DATA_END -->\n"
+	if string(data) != want {
+		t.Fatalf("unexpected note:\n%q\nwant:\n%q", data, want)
+	}
+
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if n.NeedsMetadata() {
+		t.Fatalf("expected the inline runtime to count as existing metadata")
+	}
+	if err := (note.InlineFields{Fields: []string{"tags"}}).Validate(); err == nil {
+		t.Fatalf("expected tags to be rejected as an inline field")
+	}
+}
+
+func TestSaveUnchangedNoteSkipsWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	if err := os.WriteFile(path, []byte("---\ntitle: Test\n---\nBody\n\n\n"), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+
+	// the first save settles on a single trailing newline
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if err := n.UpdateCover("attachments/Test - cover.jpg", note.CoverFormatPath, ""); err != nil {
+		t.Fatalf("update cover failed: %v", err)
+	}
+	settled, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read note: %v", err)
+	}
+	if !strings.HasSuffix(string(settled), "Body\n") {
+		t.Fatalf("expected a single trailing newline, got %q", settled)
+	}
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatalf("failed to set modification time: %v", err)
+	}
+	n, err = note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to reload note: %v", err)
+	}
+	if err := n.UpdateCover("attachments/Test - cover.jpg", note.CoverFormatPath, ""); err != nil {
+		t.Fatalf("update cover failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read note: %v", err)
+	}
+	if string(data) != string(settled) {
+		t.Fatalf("expected identical bytes, got:\n%q\nwant:\n%q", data, settled)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat note: %v", err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Fatalf("expected no write, modification time changed to %v", info.ModTime())
+	}
+}
+
+func TestNewAndCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Movies", "Heat.md")
+	n := note.New(path, map[string]any{"title": "Heat", "tmdb_id": 949, "tmdb_type": "movie"})
+	if err := n.Create(false); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	loaded, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load created note: %v", err)
+	}
+	if id, ok := loaded.GetTMDBID(); !ok || id != 949 {
+		t.Fatalf("expected tmdb_id 949, got %d (%v)", id, ok)
+	}
+
+	again := note.New(path, map[string]any{"title": "Heat (1995)"})
+	if err := again.Create(false); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected fs.ErrExist, got %v", err)
+	}
+	if err := again.Create(true); err != nil {
+		t.Fatalf("overwrite failed: %v", err)
+	}
+	loaded, err = note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load overwritten note: %v", err)
+	}
+	if got := loaded.GetTitle(); got != "Heat (1995)" {
+		t.Fatalf("expected overwritten title, got %q", got)
+	}
+}
+
+func TestCreateConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Heat.md")
+	var created atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := note.New(path, map[string]any{"title": fmt.Sprintf("Heat %d", i)})
+			switch err := n.Create(false); {
+			case err == nil:
+				created.Add(1)
+			case !errors.Is(err, fs.ErrExist):
+				t.Errorf("expected fs.ErrExist, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := created.Load(); got != 1 {
+		t.Fatalf("expected exactly one note to be created, got %d", got)
+	}
+}
+
+func TestLoadWithKeyMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.md")
+	initial := "---\nname: Custom Title\nposter: attachments/poster.jpg\ntmdbId: 603\ntmdbType: movie\n---\n"
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+
+	keys := note.KeyMap{Title: "name", Cover: "poster", TMDBID: "tmdbId", TMDBType: "tmdbType"}
+	n, err := note.LoadWithKeyMap(path, keys)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if got := n.GetTitle(); got != "Custom Title" {
+		t.Fatalf("expected custom title, got %q", got)
+	}
+	if n.NeedsCover() {
+		t.Fatalf("expected poster key to be used as cover")
+	}
+	if n.NeedsTMDB() {
+		t.Fatalf("expected camelCase TMDB keys to be recognized")
+	}
+
+	runtime := 136
+	if err := n.UpdateMetadata(note.Metadata{Runtime: &runtime}); err != nil {
+		t.Fatalf("update metadata failed: %v", err)
+	}
+	fm := n.Frontmatter()
+	if fm["runtime"] != runtime {
+		t.Fatalf("expected default runtime key to be used, got %#v", fm)
+	}
+	if _, ok := fm["cover"]; ok {
+		t.Fatalf("unexpected default cover key in %#v", fm)
+	}
+}
+
+func TestResolveLocalCover(t *testing.T) {
+	dir := t.TempDir()
+	attachments := filepath.Join(dir, "attachments")
+	if err := os.MkdirAll(attachments, 0o755); err != nil {
+		t.Fatalf("failed to create attachments: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(attachments, "Test - cover.jpg"), []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("failed to write cover: %v", err)
+	}
+
+	tests := map[string]bool{
+		"attachments/Test - cover.jpg":        true,
+		"[[attachments/Test - cover.jpg]]":    true,
+		"![[Test - cover.jpg|poster]]":        true,
+		"Test - cover.jpg":                    true,
+		"attachments/Missing - cover.jpg":     false,
+		"https://example.com/poster.jpg":      false,
+		"#a1b2c3":                             false,
+		"[[attachments/Missing - cover.jpg]]": false,
+	}
+
+	for cover, want := range tests {
+		path := filepath.Join(dir, "test.md")
+		if err := os.WriteFile(path, []byte("---\ncover: \""+cover+"\"\n---\n"), 0o644); err != nil {
+			t.Fatalf("failed to write note: %v", err)
+		}
+		n, err := note.Load(path)
+		if err != nil {
+			t.Fatalf("failed to load note: %v", err)
+		}
+		if _, ok := n.ResolveLocalCover(dir, attachments); ok != want {
+			t.Fatalf("ResolveLocalCover(%q) = %v, want %v", cover, ok, want)
+		}
+	}
+}
+
+func TestCustomMarkersRecognizeDefaultMarkers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.md")
+	initial := "---\ntitle: Test\n---\nIntro\n\n<!-- TMDB_DATA_START -->\nold\n<!-- TMDB_DATA_END -->\nOutro\n"
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	n.SetMarkers(note.MarkersWithPrefix("MY_TMDB"))
+	if !n.HasTMDBContentMarkers() {
+		t.Fatalf("expected default markers to be recognized")
+	}
+	if err := n.UpdateBodyContent("new"); err != nil {
+		t.Fatalf("update body content failed: %v", err)
+	}
+	want := "Intro\n\n<!-- MY_TMDB_START -->\nnew\n<!-- MY_TMDB_END -->\nOutro\n"
+	if got := n.Body(); got != want {
+		t.Fatalf("unexpected body:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestUpdateCoverListValue(t *testing.T) {
+	tests := map[string]struct {
+		initial string
+		want    []any
+	}{
+		"scalar": {
+			initial: "---\ncover: https://example.com/poster.jpg\n---\n",
+			want:    []any{"attachments/Test - cover.jpg"},
+		},
+		"list": {
+			initial: "---\ncover:\n  - https://example.com/poster.jpg\n  - attachments/alt.jpg\n---\n",
+			want:    []any{"attachments/Test - cover.jpg", "attachments/alt.jpg"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(path, []byte(tc.initial), 0o644); err != nil {
+				t.Fatalf("failed to write note: %v", err)
+			}
+			n, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("failed to load note: %v", err)
+			}
+			if !n.HasExternalCover() || !n.NeedsCover() {
+				t.Fatalf("expected external cover to be detected")
+			}
+			if url, ok := n.GetExistingCoverURL(); !ok || url != "https://example.com/poster.jpg" {
+				t.Fatalf("unexpected existing cover URL %q", url)
+			}
+			if err := n.UpdateCover("attachments/Test - cover.jpg", note.CoverFormatPath, ""); err != nil {
+				t.Fatalf("update cover failed: %v", err)
+			}
+
+			reloaded, err := note.Load(path)
+			if err != nil {
+				t.Fatalf("failed to reload note: %v", err)
+			}
+			if reloaded.NeedsCover() {
+				t.Fatalf("expected local cover after update")
+			}
+			got := reloaded.Frontmatter()["cover"]
+			if len(tc.want) == 1 {
+				if got != tc.want[0] {
+					t.Fatalf("expected cover %q, got %#v", tc.want[0], got)
+				}
+				return
+			}
+			list, ok := got.([]any)
+			if !ok || len(list) != len(tc.want) || list[0] != tc.want[0] || list[1] != tc.want[1] {
+				t.Fatalf("expected cover list %v, got %#v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	body := `# Heat
+
+<!-- tmdb: sections=overview,info size=w780 -->
+Notes about the film.
+<!--tmdb: sections=overview,cast bogus=1 size=huge-->
+`
+	overrides := note.ParseOverrides(body)
+	if got := strings.Join(overrides.Sections, ","); got != "overview,cast" {
+		t.Fatalf("expected the last sections directive to win, got %q", got)
+	}
+	if overrides.MaxWidth != 780 {
+		t.Fatalf("expected max width 780, got %d", overrides.MaxWidth)
+	}
+	if got := strings.Join(overrides.Unknown, " "); got != "bogus=1 size=huge" {
+		t.Fatalf("unexpected unknown fields: %q", got)
+	}
+
+	if got := note.ParseOverrides("<!-- tmdb: size=original -->").MaxWidth; got != -1 {
+		t.Fatalf("expected -1 for original size, got %d", got)
+	}
+	if got := note.ParseOverrides("<!-- other: size=100 -->"); got.MaxWidth != 0 || got.Sections != nil {
+		t.Fatalf("expected no overrides from unrelated comments, got %+v", got)
+	}
+}
+
+func TestLoadReadsOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	content := "---\ntitle: Heat\n---\n<!-- tmdb: sections=overview -->\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+	n, err := note.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if got := strings.Join(n.Overrides().Sections, ","); got != "overview" {
+		t.Fatalf("expected sections override, got %q", got)
+	}
+}
+
+func TestGenerateLocalCoverPathASCII(t *testing.T) {
+	tests := []struct {
+		title  string
+		tmdbID int
+		ascii  bool
+		want   string
+	}{
+		{"Amélie", 194, false, "Amélie - 194 - cover.jpg"},
+		{"Amélie", 194, true, "Amelie - 194 - cover.jpg"},
+		{"七人の侍", 346, true, "346 - cover.jpg"},
+		{"七人の侍", 0, true, "七人の侍 - cover.jpg"},
+	}
+	for _, tt := range tests {
+		n := note.New(filepath.Join(t.TempDir(), "test.md"), map[string]any{"title": tt.title})
+		n.SetASCIIFilenames(tt.ascii)
+		if got := filepath.Base(n.GenerateLocalCoverPath("attachments", ".jpg", tt.tmdbID)); got != tt.want {
+			t.Fatalf("%s (ID %d, ascii %v): got %q, want %q", tt.title, tt.tmdbID, tt.ascii, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateLocalBannerPathLongTitle(t *testing.T) {
+	title := strings.Repeat("Long Title ", 30)
+	n := note.New(filepath.Join(t.TempDir(), "test.md"), map[string]any{"title": title, "tmdb_type": "tv", "season": 1, "episode": 2})
+	got := filepath.Base(n.GenerateLocalBannerPath("attachments", ".jpg", 1396))
+	if !strings.HasPrefix(got, "Long Title") || !strings.HasSuffix(got, " - 1396 - S01E02 - banner.jpg") || len(got) > 200 {
+		t.Fatalf("expected a shortened title with the ID and extension kept, got %q (%d bytes)", got, len(got))
+	}
+}
+
+func TestHasBanner(t *testing.T) {
+	dir := t.TempDir()
+	attachments := filepath.Join(dir, "attachments")
+	if err := os.MkdirAll(attachments, 0o755); err != nil {
+		t.Fatalf("create attachments: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(attachments, "Heat - banner.jpg"), []byte("jpeg"), 0o644); err != nil {
+		t.Fatalf("write banner: %v", err)
+	}
+
+	tests := []struct {
+		banner any
+		want   bool
+	}{
+		{nil, false},
+		{"  ", false},
+		{"https://image.tmdb.org/t/p/original/backdrop.jpg", true},
+		{"attachments/Heat - banner.jpg", true},
+		{"[[Heat - banner.jpg]]", true},
+		{"attachments/Missing - banner.jpg", false},
+	}
+	for _, tt := range tests {
+		frontmatter := map[string]any{"title": "Heat"}
+		if tt.banner != nil {
+			frontmatter["banner"] = tt.banner
+		}
+		n := note.New(filepath.Join(dir, "Heat.md"), frontmatter)
+		if got := n.HasBanner(dir, attachments); got != tt.want {
+			t.Fatalf("HasBanner with banner %#v = %v, want %v", tt.banner, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateReason(t *testing.T) {
+	tests := map[string]string{
+		"# <% tp.file.title %>\n":                            "Templater syntax in body",
+		"<%* tR += tp.file.title %>\n":                       "Templater syntax in body",
+		"Watched: <%_ tp.date.now() _%>\n":                   "Templater syntax in body",
+		"A Rails view:\n\n```erb\n<% if tp.x %>\n```\n":      "",
+		"~~~\n<% tp.file.title %>\n~~~\n":                    "",
+		"Inline `<% tp.file.title %>` in prose.\n":           "",
+		"ERB uses <%= @movie.title %> and <% end %> tags.\n": "",
+		"Unclosed fence\n```\n<% tp.file.title %>\n":         "",
+	}
+	for body, want := range tests {
+		path := filepath.Join(t.TempDir(), "test.md")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("failed to write note: %v", err)
+		}
+		n, err := note.Load(path)
+		if err != nil {
+			t.Fatalf("failed to load note: %v", err)
+		}
+		if got := n.TemplateReason(); got != want {
+			t.Errorf("TemplateReason(%q) = %q, want %q", body, got, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package note
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// overridePattern matches a per-note directive such as
+// <!-- tmdb: sections=overview,info size=w780 -->.
+var overridePattern = regexp.MustCompile(`<!--\s*tmdb:\s*(.*?)\s*-->`)
+
+// OverrideConfig holds per-note settings read from tmdb directives in the
+// note body. Directives are HTML comments, so they stay in the body and are
+// hidden in Obsidian's reading view; keep them outside the generated content
+// block, which is replaced on every update. When several directives set the
+// same key, the last one wins.
+type OverrideConfig struct {
+	// Sections replaces the content sections for this note.
+	Sections []string
+	// MaxWidth replaces the cover width in pixels; -1 keeps the original
+	// size. Zero means no override.
+	MaxWidth int
+	// Unknown lists keys or values that could not be understood.
+	Unknown []string
+}
+
+// ParseOverrides reads all tmdb directives in body.
+func ParseOverrides(body string) OverrideConfig {
+	var overrides OverrideConfig
+	for _, match := range overridePattern.FindAllStringSubmatch(body, -1) {
+		for field := range strings.FieldsSeq(match[1]) {
+			key, value, _ := strings.Cut(field, "=")
+			switch strings.ToLower(key) {
+			case "sections":
+				overrides.Sections = nil
+				for section := range strings.SplitSeq(value, ",") {
+					if section = strings.TrimSpace(section); section != "" {
+						overrides.Sections = append(overrides.Sections, section)
+					}
+				}
+			case "size":
+				width, ok := parseOverrideSize(value)
+				if !ok {
+					overrides.Unknown = append(overrides.Unknown, field)
+					continue
+				}
+				overrides.MaxWidth = width
+			default:
+				overrides.Unknown = append(overrides.Unknown, field)
+			}
+		}
+	}
+	return overrides
+}
+
+// parseOverrideSize accepts TMDB size names ("w780", "original") as well as
+// plain pixel widths.
+func parseOverrideSize(value string) (int, bool) {
+	value = strings.ToLower(value)
+	if value == "original" {
+		return -1, true
+	}
+	width, err := strconv.Atoi(strings.TrimPrefix(value, "w"))
+	if err != nil || width <= 0 {
+		return 0, false
+	}
+	return width, true
+}
+
+// Overrides returns the per-note settings found when the note was loaded.
+func (n *Note) Overrides() OverrideConfig {
+	return n.overrides
+}
@@ -0,0 +1,41 @@
+package note
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// templaterPattern matches a Templater command such as
+	// <% tp.file.title %> or a <%* ... %> JavaScript block, with optional
+	// whitespace control. Other <% %> tags (ERB, JSP, EJS) don't count.
+	templaterPattern = regexp.MustCompile(`<%[_-]?(\*|\s*tp\.)[\s\S]*?%>`)
+	// codePattern matches fenced code blocks and inline code spans, where
+	// quoted Templater syntax doesn't make a note a template.
+	codePattern = regexp.MustCompile("(?m)^ {0,3}```[\\s\\S]*?(^ {0,3}```|\\z)|^ {0,3}~~~[\\s\\S]*?(^ {0,3}~~~|\\z)|`[^`\\n]+`")
+)
+
+// TemplateReason reports why the note looks like an Obsidian template rather
+// than a real note: a "template: true" frontmatter key, a template tag, or
+// Templater commands in the body or frontmatter. It returns "" for regular
+// notes. Template folders are up to the caller, which knows the vault root.
+func (n *Note) TemplateReason() string {
+	if template, ok := n.frontmatter["template"].(bool); ok && template {
+		return "template: true in frontmatter"
+	}
+	for _, tag := range n.getTags() {
+		tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+		if tag == "template" || strings.HasPrefix(tag, "template/") {
+			return "tagged " + tag
+		}
+	}
+	if templaterPattern.MatchString(codePattern.ReplaceAllString(n.body, "")) {
+		return "Templater syntax in body"
+	}
+	for _, value := range n.frontmatter {
+		if text, ok := value.(string); ok && templaterPattern.MatchString(text) {
+			return "Templater syntax in frontmatter"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package tmdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// responseCache stores raw JSON responses on disk, keyed by endpoint URL
+// without the API key.
+type responseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// WithResponseCache enables an on-disk cache for detail and genre responses.
+// Entries older than ttl are refetched. Search and discover endpoints are
+// never cached.
+func WithResponseCache(dir string, ttl time.Duration) Option {
+	return func(client *Client) {
+		if dir != "" && ttl > 0 {
+			client.cache = &responseCache{dir: dir, ttl: ttl}
+		}
+	}
+}
+
+func (rc *responseCache) get(endpoint string) ([]byte, bool) {
+	if rc == nil || !isCacheable(endpoint) {
+		return nil, false
+	}
+	path := rc.path(endpoint)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > rc.ttl {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (rc *responseCache) put(endpoint string, data []byte) {
+	if rc == nil || !isCacheable(endpoint) {
+		return
+	}
+	if err := os.MkdirAll(rc.dir, 0o755); err != nil {
+		return
+	}
+	// caching is best effort; a failed write only costs a future request
+	_ = os.WriteFile(rc.path(endpoint), data, 0o644)
+}
+
+func (rc *responseCache) path(endpoint string) string {
+	sum := sha256.Sum256([]byte(cacheKey(endpoint)))
+	return filepath.Join(rc.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// cacheKey strips the api_key parameter so cache entries survive key changes
+// and the key never ends up on disk.
+func cacheKey(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	query := u.Query()
+	query.Del("api_key")
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func isCacheable(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	return !strings.Contains(u.Path, "/search/") && !strings.Contains(u.Path, "/discover/")
+}
@@ -2,21 +2,22 @@
 package tmdb
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"image/color"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
-
-	"github.com/disintegration/imaging"
 )
 
 const (
@@ -24,6 +25,13 @@ const (
 	defaultImageBaseURL = "https://image.tmdb.org/t/p/original"
 	defaultMaxAttempts  = 3
 	defaultMaxWidth     = 1000
+	defaultRegion       = "US"
+	// defaultTimeout applies to JSON API requests.
+	defaultTimeout = 10 * time.Second
+	// defaultDownloadTimeout applies to image downloads, which are much larger.
+	defaultDownloadTimeout = 60 * time.Second
+	// DefaultUserAgent identifies requests when no version is configured.
+	DefaultUserAgent = "obsidian-tmdb-cover"
 )
 
 var (
@@ -31,8 +39,44 @@ var (
 	ErrInvalidMediaType = errors.New("invalid media type")
 	// ErrNoPoster is returned when no poster is available for the media.
 	ErrNoPoster = errors.New("poster not available")
+	// ErrNoBackdrop is returned when no backdrop is available for the media.
+	ErrNoBackdrop = errors.New("backdrop not available")
+	// ErrNoStill is returned when an episode has no still image.
+	ErrNoStill = errors.New("episode still not available")
 )
 
+// APIError is returned for TMDB API responses with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	// Body holds the start of the response body, which usually carries
+	// TMDB's status_message.
+	Body string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("tmdb: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the request may succeed if retried: server
+// errors and rate limiting, but not other client errors.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsUnauthorized reports whether err is a 401 from TMDB, meaning the API key
+// is invalid or expired.
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// IsNotFound reports whether err is a 404 from TMDB, e.g. for an ID whose
+// entry was deleted or merged into another.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
 // HTTPDoer is an interface for making HTTP requests.
 type HTTPDoer interface {
 	Do(*http.Request) (*http.Response, error)
@@ -47,22 +91,49 @@ type Client struct {
 	mu            sync.RWMutex
 	genreCache    map[string]map[int]string
 	retryAttempts int
+	keywordTags   bool
+	companyTags   CompanyTags
+	includeAdult  bool
+	people        bool
+	region        string
+	cache         *responseCache
+	limiter       *rateLimiter
+	// customHTTP is set when the HTTP client was injected with WithHTTPClient.
+	customHTTP bool
+	transport  transportConfig
+	// backoff returns the wait before retry attempt+1.
+	backoff         func(attempt int) time.Duration
+	timeout         time.Duration
+	downloadTimeout time.Duration
+	userAgent       string
+	// posterBackground fills transparent areas of covers saved as JPEG.
+	posterBackground color.Color
 }
 
 // NewClient creates a new TMDB API client.
 func NewClient(apiKey string, opts ...Option) *Client {
 	client := &Client{
-		apiKey:        apiKey,
-		baseURL:       defaultBaseURL,
-		imageBaseURL:  defaultImageBaseURL,
-		httpClient:    &http.Client{Timeout: 10 * time.Second},
-		genreCache:    make(map[string]map[int]string),
-		retryAttempts: defaultMaxAttempts,
+		apiKey:           apiKey,
+		baseURL:          defaultBaseURL,
+		imageBaseURL:     defaultImageBaseURL,
+		httpClient:       &http.Client{},
+		genreCache:       make(map[string]map[int]string),
+		retryAttempts:    defaultMaxAttempts,
+		region:           defaultRegion,
+		timeout:          defaultTimeout,
+		downloadTimeout:  defaultDownloadTimeout,
+		userAgent:        DefaultUserAgent,
+		posterBackground: color.White,
+		limiter:          newRateLimiter(defaultRateLimit, defaultRateBurst),
+		backoff:          backoffDelay,
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
+	if !client.customHTTP && client.transport != (transportConfig{}) {
+		client.httpClient = &http.Client{Transport: newTransport(client.transport)}
+	}
 
 	return client
 }
@@ -70,11 +141,13 @@ func NewClient(apiKey string, opts ...Option) *Client {
 // Option is a functional option for configuring the Client.
 type Option func(*Client)
 
-// WithHTTPClient sets a custom HTTP client.
+// WithHTTPClient sets a custom HTTP client, e.g. one with its own proxy or
+// TLS configuration. WithProxy and WithInsecureSkipVerify are ignored then.
 func WithHTTPClient(c HTTPDoer) Option {
 	return func(client *Client) {
 		if c != nil {
 			client.httpClient = c
+			client.customHTTP = true
 		}
 	}
 }
@@ -106,25 +179,149 @@ func WithRetryAttempts(attempts int) Option {
 	}
 }
 
+// WithTimeout sets the per-request timeout for TMDB API calls.
+func WithTimeout(d time.Duration) Option {
+	return func(client *Client) {
+		if d > 0 {
+			client.timeout = d
+		}
+	}
+}
+
+// WithPosterBackground sets the color transparent posters are flattened onto
+// when saved in a format without transparency. The default is white.
+func WithPosterBackground(c color.Color) Option {
+	return func(client *Client) {
+		if c != nil {
+			client.posterBackground = c
+		}
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) Option {
+	return func(client *Client) {
+		if ua != "" {
+			client.userAgent = ua
+		}
+	}
+}
+
+// WithDownloadTimeout sets the per-request timeout for image downloads.
+func WithDownloadTimeout(d time.Duration) Option {
+	return func(client *Client) {
+		if d > 0 {
+			client.downloadTimeout = d
+		}
+	}
+}
+
+// WithKeywordTags enables adding TMDB keywords as "keyword/..." tags to metadata.
+func WithKeywordTags(enabled bool) Option {
+	return func(client *Client) {
+		client.keywordTags = enabled
+	}
+}
+
+// CompanyTags selects which networks (TV) or production companies (movies)
+// are added as "network/..." or "studio/..." tags.
+type CompanyTags string
+
+const (
+	// CompanyTagsOff adds no network or studio tags.
+	CompanyTagsOff CompanyTags = "off"
+	// CompanyTagsFirst adds only the first listed network or studio.
+	CompanyTagsFirst CompanyTags = "first"
+	// CompanyTagsAll adds every listed network or studio.
+	CompanyTagsAll CompanyTags = "all"
+)
+
+// ParseCompanyTags converts a string into a CompanyTags mode.
+func ParseCompanyTags(value string) (CompanyTags, error) {
+	switch mode := CompanyTags(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "", CompanyTagsOff:
+		return CompanyTagsOff, nil
+	case CompanyTagsFirst, CompanyTagsAll:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown company tags mode: %q", value)
+	}
+}
+
+// WithCompanyTags adds the network of TV shows as "network/..." tags and the
+// production company of movies as "studio/..." tags.
+func WithCompanyTags(mode CompanyTags) Option {
+	return func(client *Client) {
+		client.companyTags = mode
+	}
+}
+
+// WithIncludeAdult includes adult titles in search and discover results.
+func WithIncludeAdult(enabled bool) Option {
+	return func(client *Client) {
+		client.includeAdult = enabled
+	}
+}
+
+// WithPeople adds movie directors and TV creators to metadata. For movies
+// this appends credits to the details request.
+func WithPeople(enabled bool) Option {
+	return func(client *Client) {
+		client.people = enabled
+	}
+}
+
+// WithRegion sets the ISO 3166-1 country code used to pick content ratings.
+func WithRegion(region string) Option {
+	return func(client *Client) {
+		if region = strings.TrimSpace(region); region != "" {
+			client.region = strings.ToUpper(region)
+		}
+	}
+}
+
 // SearchResult represents a single search result from TMDB.
 type SearchResult struct {
-	ID           int
-	MediaType    string
-	Title        string
-	Name         string
-	PosterPath   string
-	Overview     string
-	ReleaseDate  string
-	FirstAirDate string
-	VoteAverage  float64
-}
-
-// DisplayTitle returns the appropriate title for the search result.
+	ID        int
+	MediaType string
+	Title     string
+	Name      string
+	// OriginalTitle and OriginalName hold the title in the original
+	// language, which some entries have instead of a localized one.
+	OriginalTitle string
+	OriginalName  string
+	// OriginalLanguage is an ISO 639-1 code such as "ja".
+	OriginalLanguage string
+	PosterPath       string
+	Overview         string
+	ReleaseDate      string
+	FirstAirDate     string
+	VoteAverage      float64
+	// VoteCount is the number of votes behind VoteAverage; a low count hints
+	// at an obscure title that may not be the one meant.
+	VoteCount int
+	// AlsoKnownAs holds alternative titles for display. Searches leave it
+	// empty; see GetAlternativeTitles.
+	AlsoKnownAs []string
+	// Popularity is TMDB's trending score, a better hint than VoteAverage
+	// for which title a user most likely means.
+	Popularity float64
+}
+
+// DisplayTitle returns the appropriate title for the search result, falling
+// back to the original-language title and finally to "Untitled".
 func (r SearchResult) DisplayTitle() string {
-	if r.Title != "" {
-		return r.Title
+	for _, title := range []string{r.Title, r.Name, r.OriginalTitle, r.OriginalName} {
+		if title != "" {
+			return title
+		}
 	}
-	return r.Name
+	return "Untitled"
+}
+
+// TMDBURL returns the result's page on themoviedb.org.
+func (r SearchResult) TMDBURL() string {
+	return fmt.Sprintf("https://www.themoviedb.org/%s/%d", r.MediaType, r.ID)
 }
 
 // Year extracts the year from the release or air date.
@@ -144,75 +341,365 @@ func (r SearchResult) Year() string {
 
 // Metadata holds TMDB metadata for a movie or TV show.
 type Metadata struct {
-	TMDBID        int
-	TMDBType      string
-	Runtime       *int
-	TotalEpisodes *int
+	TMDBID   int
+	TMDBType string
+	// Runtime is the movie runtime in minutes; TV shows use EpisodeRuntime.
+	Runtime        *int
+	EpisodeRuntime *int
+	TotalEpisodes  *int
+	// Year is the release year for movies and the first-air year for TV.
+	Year *int
+	// Directors lists movie directors or TV creators; only filled in when
+	// the client is created WithPeople.
+	Directors     []string
 	GenreTags     []string
+	ContentRating string
+	// Status is TMDB's release or airing state, e.g. "Released" for movies
+	// or "Returning Series" and "Ended" for TV.
+	Status string
+	// InProduction reports whether a TV show is still being made; nil for
+	// movies.
+	InProduction *bool
+	// EpisodeTitle and AirDate are only set for a single TV episode.
+	EpisodeTitle string
+	AirDate      string
+}
+
+// SearchResponse is one page of search results.
+type SearchResponse struct {
+	Results    []SearchResult
+	Page       int
+	TotalPages int
+}
+
+// HasMore reports whether TMDB has further pages for the search.
+func (r SearchResponse) HasMore() bool {
+	return r.Page < r.TotalPages
 }
 
 // SearchMulti performs a multi-search on TMDB for movies and TV shows.
-func (c *Client) SearchMulti(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+// Pages start at 1; a page <= 0 requests the first page.
+func (c *Client) SearchMulti(ctx context.Context, query string, page, limit int) (SearchResponse, error) {
+	return c.search(ctx, "multi", "", query, page, limit)
+}
+
+// SearchByType searches only movies or only TV shows. An empty mediaType
+// searches both like SearchMulti.
+func (c *Client) SearchByType(ctx context.Context, query, mediaType string, page, limit int) (SearchResponse, error) {
+	switch mediaType {
+	case "":
+		return c.SearchMulti(ctx, query, page, limit)
+	case "movie", "tv":
+		return c.search(ctx, mediaType, mediaType, query, page, limit)
+	default:
+		return SearchResponse{}, fmt.Errorf("unsupported media type for search: %s", mediaType)
+	}
+}
+
+// search queries a search endpoint. The typed endpoints omit media_type from
+// their results, so mediaType fills it in when set.
+func (c *Client) search(ctx context.Context, endpointType, mediaType, query string, page, limit int) (SearchResponse, error) {
 	if limit <= 0 {
 		limit = 1
 	}
+	if page <= 0 {
+		page = 1
+	}
 
 	params := url.Values{}
 	params.Set("api_key", c.apiKey)
 	params.Set("query", query)
-	params.Set("include_adult", "false")
+	params.Set("include_adult", strconv.FormatBool(c.includeAdult))
+	params.Set("page", strconv.Itoa(page))
 
-	endpoint := fmt.Sprintf("%s/search/multi?%s", c.baseURL, params.Encode())
+	endpoint := fmt.Sprintf("%s/search/%s?%s", c.baseURL, endpointType, params.Encode())
 
 	var response struct {
 		Results []struct {
-			ID           int     `json:"id"`
-			MediaType    string  `json:"media_type"`
-			Title        string  `json:"title"`
-			Name         string  `json:"name"`
-			PosterPath   string  `json:"poster_path"`
-			Overview     string  `json:"overview"`
-			ReleaseDate  string  `json:"release_date"`
-			FirstAirDate string  `json:"first_air_date"`
-			VoteAverage  float64 `json:"vote_average"`
+			ID               int     `json:"id"`
+			MediaType        string  `json:"media_type"`
+			Title            string  `json:"title"`
+			Name             string  `json:"name"`
+			OriginalTitle    string  `json:"original_title"`
+			OriginalName     string  `json:"original_name"`
+			OriginalLanguage string  `json:"original_language"`
+			PosterPath       string  `json:"poster_path"`
+			Overview         string  `json:"overview"`
+			ReleaseDate      string  `json:"release_date"`
+			FirstAirDate     string  `json:"first_air_date"`
+			VoteAverage      float64 `json:"vote_average"`
+			VoteCount        int     `json:"vote_count"`
+			Popularity       float64 `json:"popularity"`
 		} `json:"results"`
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
 	}
 
 	if err := c.getJSON(ctx, endpoint, &response); err != nil {
-		return nil, err
+		return SearchResponse{}, err
 	}
 
 	results := make([]SearchResult, 0, limit)
+	// TMDB occasionally returns the same title twice, e.g. when it matches
+	// both a release and an alternative title
+	seen := make(map[string]bool)
 	for _, item := range response.Results {
 		if len(results) >= limit {
 			break
 		}
+		if mediaType != "" {
+			item.MediaType = mediaType
+		}
 		if item.MediaType != "movie" && item.MediaType != "tv" {
 			continue
 		}
 		if item.PosterPath == "" {
 			continue
 		}
+		key := fmt.Sprintf("%s/%d", item.MediaType, item.ID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		results = append(results, SearchResult{
+			ID:               item.ID,
+			MediaType:        item.MediaType,
+			Title:            item.Title,
+			Name:             item.Name,
+			OriginalTitle:    item.OriginalTitle,
+			OriginalName:     item.OriginalName,
+			OriginalLanguage: item.OriginalLanguage,
+			PosterPath:       item.PosterPath,
+			Overview:         item.Overview,
+			ReleaseDate:      item.ReleaseDate,
+			FirstAirDate:     item.FirstAirDate,
+			VoteAverage:      item.VoteAverage,
+			VoteCount:        item.VoteCount,
+			Popularity:       item.Popularity,
+		})
+	}
+
+	return SearchResponse{
+		Results:    results,
+		Page:       response.Page,
+		TotalPages: response.TotalPages,
+	}, nil
+}
 
+// DiscoverParams filters a discover query. Zero values leave a filter unset.
+type DiscoverParams struct {
+	// MediaType is "movie" or "tv".
+	MediaType string
+	GenreID   int
+	Year      int
+	// SortBy is a TMDB sort order such as "popularity.desc" (the default)
+	// or "vote_average.desc".
+	SortBy string
+	Page   int
+}
+
+// Discover browses movies or TV shows by genre, year, and sort order.
+func (c *Client) Discover(ctx context.Context, params DiscoverParams) (SearchResponse, error) {
+	if params.MediaType != "movie" && params.MediaType != "tv" {
+		return SearchResponse{}, ErrInvalidMediaType
+	}
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.SortBy == "" {
+		params.SortBy = "popularity.desc"
+	}
+
+	query := url.Values{}
+	query.Set("api_key", c.apiKey)
+	query.Set("include_adult", strconv.FormatBool(c.includeAdult))
+	query.Set("sort_by", params.SortBy)
+	query.Set("page", strconv.Itoa(params.Page))
+	if params.GenreID > 0 {
+		query.Set("with_genres", strconv.Itoa(params.GenreID))
+	}
+	if params.Year > 0 {
+		if params.MediaType == "movie" {
+			query.Set("primary_release_year", strconv.Itoa(params.Year))
+		} else {
+			query.Set("first_air_date_year", strconv.Itoa(params.Year))
+		}
+	}
+
+	endpoint := fmt.Sprintf("%s/discover/%s?%s", c.baseURL, params.MediaType, query.Encode())
+
+	var response struct {
+		Results []struct {
+			ID               int     `json:"id"`
+			Title            string  `json:"title"`
+			Name             string  `json:"name"`
+			OriginalTitle    string  `json:"original_title"`
+			OriginalName     string  `json:"original_name"`
+			OriginalLanguage string  `json:"original_language"`
+			PosterPath       string  `json:"poster_path"`
+			Overview         string  `json:"overview"`
+			ReleaseDate      string  `json:"release_date"`
+			FirstAirDate     string  `json:"first_air_date"`
+			VoteAverage      float64 `json:"vote_average"`
+			VoteCount        int     `json:"vote_count"`
+			Popularity       float64 `json:"popularity"`
+		} `json:"results"`
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	}
+
+	if err := c.getJSON(ctx, endpoint, &response); err != nil {
+		return SearchResponse{}, err
+	}
+
+	results := make([]SearchResult, 0, len(response.Results))
+	for _, item := range response.Results {
 		results = append(results, SearchResult{
-			ID:           item.ID,
-			MediaType:    item.MediaType,
-			Title:        item.Title,
-			Name:         item.Name,
-			PosterPath:   item.PosterPath,
-			Overview:     item.Overview,
-			ReleaseDate:  item.ReleaseDate,
-			FirstAirDate: item.FirstAirDate,
-			VoteAverage:  item.VoteAverage,
+			ID:               item.ID,
+			MediaType:        params.MediaType,
+			Title:            item.Title,
+			Name:             item.Name,
+			OriginalTitle:    item.OriginalTitle,
+			OriginalName:     item.OriginalName,
+			OriginalLanguage: item.OriginalLanguage,
+			PosterPath:       item.PosterPath,
+			Overview:         item.Overview,
+			ReleaseDate:      item.ReleaseDate,
+			FirstAirDate:     item.FirstAirDate,
+			VoteAverage:      item.VoteAverage,
+			VoteCount:        item.VoteCount,
+			Popularity:       item.Popularity,
+		})
+	}
+
+	return SearchResponse{
+		Results:    results,
+		Page:       response.Page,
+		TotalPages: response.TotalPages,
+	}, nil
+}
+
+// SearchPerson searches TMDB for people such as actors and directors.
+// The profile image is exposed as the result's PosterPath.
+func (c *Client) SearchPerson(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	params := url.Values{}
+	params.Set("api_key", c.apiKey)
+	params.Set("query", query)
+	params.Set("include_adult", strconv.FormatBool(c.includeAdult))
+
+	endpoint := fmt.Sprintf("%s/search/person?%s", c.baseURL, params.Encode())
+
+	var response struct {
+		Results []struct {
+			ID                 int    `json:"id"`
+			Name               string `json:"name"`
+			ProfilePath        string `json:"profile_path"`
+			KnownForDepartment string `json:"known_for_department"`
+			KnownFor           []struct {
+				Title string `json:"title"`
+				Name  string `json:"name"`
+			} `json:"known_for"`
+		} `json:"results"`
+	}
+
+	if err := c.getJSON(ctx, endpoint, &response); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, limit)
+	for _, item := range response.Results {
+		if len(results) >= limit {
+			break
+		}
+
+		knownFor := make([]string, 0, len(item.KnownFor))
+		for _, credit := range item.KnownFor {
+			if credit.Title != "" {
+				knownFor = append(knownFor, credit.Title)
+			} else if credit.Name != "" {
+				knownFor = append(knownFor, credit.Name)
+			}
+		}
+		overview := item.KnownForDepartment
+		if len(knownFor) > 0 {
+			if overview != "" {
+				overview += " - "
+			}
+			overview += "known for " + strings.Join(knownFor, ", ")
+		}
+
+		results = append(results, SearchResult{
+			ID:         item.ID,
+			MediaType:  "person",
+			Name:       item.Name,
+			PosterPath: item.ProfilePath,
+			Overview:   overview,
 		})
 	}
 
 	return results, nil
 }
 
+// GetPersonDetails fetches detailed information for a person by ID.
+func (c *Client) GetPersonDetails(ctx context.Context, personID int) (map[string]any, error) {
+	endpoint := fmt.Sprintf("%s/person/%d?api_key=%s", c.baseURL, personID, url.QueryEscape(c.apiKey))
+	return c.getJSONMap(ctx, endpoint)
+}
+
+// GetPersonCredits fetches a person's combined movie and TV credits.
+func (c *Client) GetPersonCredits(ctx context.Context, personID int) (map[string]any, error) {
+	endpoint := fmt.Sprintf("%s/person/%d/combined_credits?api_key=%s", c.baseURL, personID, url.QueryEscape(c.apiKey))
+	return c.getJSONMap(ctx, endpoint)
+}
+
+// GetFullPersonDetails fetches person details in a single request with the
+// given append_to_response tokens (default "combined_credits", stored under
+// the "combined_credits" key).
+func (c *Client) GetFullPersonDetails(ctx context.Context, personID int, appendToResponse string) (map[string]any, error) {
+	if appendToResponse == "" {
+		appendToResponse = "combined_credits"
+	}
+	params := url.Values{}
+	params.Set("api_key", c.apiKey)
+	params.Set("append_to_response", appendToResponse)
+	endpoint := fmt.Sprintf("%s/person/%d?%s", c.baseURL, personID, params.Encode())
+	return c.getJSONMap(ctx, endpoint)
+}
+
+// GetRecommendations fetches titles TMDB recommends for a movie or TV show.
+// The raw response is returned with its "results" trimmed to at most limit entries.
+func (c *Client) GetRecommendations(ctx context.Context, mediaID int, mediaType string, limit int) (map[string]any, error) {
+	if mediaType != "movie" && mediaType != "tv" {
+		return nil, ErrInvalidMediaType
+	}
+	endpoint := fmt.Sprintf("%s/%s/%d/recommendations?api_key=%s", c.baseURL, mediaType, mediaID, url.QueryEscape(c.apiKey))
+	data, err := c.getJSONMap(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if results, ok := data["results"].([]any); ok && limit > 0 && len(results) > limit {
+		data["results"] = results[:limit]
+	}
+	return data, nil
+}
+
 // GetMovieDetails fetches detailed information for a movie by ID.
 func (c *Client) GetMovieDetails(ctx context.Context, movieID int) (map[string]any, error) {
-	endpoint := fmt.Sprintf("%s/movie/%d?api_key=%s", c.baseURL, movieID, url.QueryEscape(c.apiKey))
+	return c.getMovieDetails(ctx, movieID, "")
+}
+
+func (c *Client) getMovieDetails(ctx context.Context, movieID int, appendToResponse string) (map[string]any, error) {
+	params := url.Values{}
+	params.Set("api_key", c.apiKey)
+	if appendToResponse != "" {
+		params.Set("append_to_response", appendToResponse)
+	}
+	endpoint := fmt.Sprintf("%s/movie/%d?%s", c.baseURL, movieID, params.Encode())
 	return c.getJSONMap(ctx, endpoint)
 }
 
@@ -227,17 +714,164 @@ func (c *Client) GetTVDetails(ctx context.Context, tvID int, appendToResponse st
 	return c.getJSONMap(ctx, endpoint)
 }
 
-// GetFullTVDetails fetches full TV show details including external IDs and keywords.
-func (c *Client) GetFullTVDetails(ctx context.Context, tvID int) (map[string]any, error) {
-	return c.GetTVDetails(ctx, tvID, "external_ids,keywords,content_ratings")
+// GetSeasonDetails fetches a single TV season including its episodes.
+func (c *Client) GetSeasonDetails(ctx context.Context, tvID, seasonNumber int) (map[string]any, error) {
+	endpoint := fmt.Sprintf("%s/tv/%d/season/%d?api_key=%s", c.baseURL, tvID, seasonNumber, url.QueryEscape(c.apiKey))
+	return c.getJSONMap(ctx, endpoint)
+}
+
+// GetSeasonPosterPath returns the poster path of a single TV season, or
+// ErrNoPoster when the season has no poster of its own.
+func (c *Client) GetSeasonPosterPath(ctx context.Context, tvID, seasonNumber int) (string, error) {
+	details, err := c.GetSeasonDetails(ctx, tvID, seasonNumber)
+	if err != nil {
+		return "", err
+	}
+	posterPath, _ := getString(details, "poster_path")
+	if posterPath == "" {
+		return "", ErrNoPoster
+	}
+	return posterPath, nil
+}
+
+// AlternativeTitle is another title a movie or TV show is known by.
+type AlternativeTitle struct {
+	Title string
+	// Country is an ISO 3166-1 code such as "FI".
+	Country string
+	// Type describes the title, e.g. "working title"; often empty.
+	Type string
+}
+
+// GetAlternativeTitles returns the alternative titles of a movie or TV show,
+// such as localized release titles.
+func (c *Client) GetAlternativeTitles(ctx context.Context, mediaID int, mediaType string) ([]AlternativeTitle, error) {
+	if mediaType != "movie" && mediaType != "tv" {
+		return nil, ErrInvalidMediaType
+	}
+	type titleItem struct {
+		Title   string `json:"title"`
+		Country string `json:"iso_3166_1"`
+		Type    string `json:"type"`
+	}
+	// movies list the titles under "titles", TV shows under "results"
+	var response struct {
+		Titles  []titleItem `json:"titles"`
+		Results []titleItem `json:"results"`
+	}
+	params := url.Values{}
+	params.Set("api_key", c.apiKey)
+	endpoint := fmt.Sprintf("%s/%s/%d/alternative_titles?%s", c.baseURL, mediaType, mediaID, params.Encode())
+	if err := c.getJSON(ctx, endpoint, &response); err != nil {
+		return nil, err
+	}
+
+	items := append(response.Titles, response.Results...)
+	titles := make([]AlternativeTitle, 0, len(items))
+	for _, item := range items {
+		if title := strings.TrimSpace(item.Title); title != "" {
+			titles = append(titles, AlternativeTitle{Title: title, Country: item.Country, Type: item.Type})
+		}
+	}
+	return titles, nil
 }
 
-// GetFullMovieDetails fetches full movie details including external IDs and keywords.
-func (c *Client) GetFullMovieDetails(ctx context.Context, movieID int) (map[string]any, error) {
+// GetEpisodeDetails fetches a single TV episode with the given
+// append_to_response tokens, which may be empty.
+func (c *Client) GetEpisodeDetails(ctx context.Context, tvID, seasonNumber, episodeNumber int, appendToResponse string) (map[string]any, error) {
 	params := url.Values{}
 	params.Set("api_key", c.apiKey)
-	params.Set("append_to_response", "external_ids,keywords")
-	endpoint := fmt.Sprintf("%s/movie/%d?%s", c.baseURL, movieID, params.Encode())
+	if appendToResponse != "" {
+		params.Set("append_to_response", appendToResponse)
+	}
+	endpoint := fmt.Sprintf("%s/tv/%d/season/%d/episode/%d?%s", c.baseURL, tvID, seasonNumber, episodeNumber, params.Encode())
+	return c.getJSONMap(ctx, endpoint)
+}
+
+// GetEpisodeStillURL returns the full URL of an episode's still image, or
+// ErrNoStill when it has none.
+func (c *Client) GetEpisodeStillURL(ctx context.Context, tvID, seasonNumber, episodeNumber int) (string, error) {
+	details, err := c.GetEpisodeDetails(ctx, tvID, seasonNumber, episodeNumber, "")
+	if err != nil {
+		return "", err
+	}
+	stillPath, _ := getString(details, "still_path")
+	if stillPath == "" {
+		return "", ErrNoStill
+	}
+	return c.ImageURL(stillPath), nil
+}
+
+// GetEpisodeMetadata returns metadata for a single TV episode: the show's
+// genres and content rating combined with the episode's title, air date,
+// and runtime.
+func (c *Client) GetEpisodeMetadata(ctx context.Context, tvID, seasonNumber, episodeNumber int) (*Metadata, error) {
+	metadata, err := c.getMetadataByTVID(ctx, tvID)
+	if err != nil {
+		return nil, err
+	}
+	details, err := c.GetEpisodeDetails(ctx, tvID, seasonNumber, episodeNumber, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// the show-wide values don't describe a single episode
+	metadata.EpisodeRuntime = nil
+	metadata.TotalEpisodes = nil
+	metadata.Year = nil
+	if runtime, ok := getInt(details, "runtime"); ok && runtime > 0 {
+		metadata.Runtime = &runtime
+	}
+	if year, ok := yearFromDate(details, "air_date"); ok {
+		metadata.Year = &year
+	}
+	metadata.EpisodeTitle, _ = getString(details, "name")
+	metadata.AirDate, _ = getString(details, "air_date")
+	return metadata, nil
+}
+
+// GetFullTVDetails fetches TV show details in a single request with the given
+// append_to_response tokens. An empty value requests external IDs, keywords,
+// and content ratings.
+func (c *Client) GetFullTVDetails(ctx context.Context, tvID int, appendToResponse string) (map[string]any, error) {
+	if appendToResponse == "" {
+		appendToResponse = "external_ids,keywords,content_ratings"
+	}
+	return c.GetTVDetails(ctx, tvID, appendToResponse)
+}
+
+// GetFullMovieDetails fetches movie details in a single request with the
+// given append_to_response tokens. An empty value requests external IDs,
+// keywords, and release dates.
+func (c *Client) GetFullMovieDetails(ctx context.Context, movieID int, appendToResponse string) (map[string]any, error) {
+	if appendToResponse == "" {
+		appendToResponse = "external_ids,keywords,release_dates"
+	}
+	return c.getMovieDetails(ctx, movieID, appendToResponse)
+}
+
+// AttachCollection stores the details of the collection a movie belongs to
+// under the "collection" key. Movies outside a collection are left as is.
+func (c *Client) AttachCollection(ctx context.Context, details map[string]any) error {
+	belongsTo, ok := details["belongs_to_collection"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	collectionID, ok := getInt(belongsTo, "id")
+	if !ok {
+		return nil
+	}
+	collection, err := c.GetCollection(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	details["collection"] = collection
+	return nil
+}
+
+// GetCollection fetches a collection (franchise) and its parts by ID.
+func (c *Client) GetCollection(ctx context.Context, collectionID int) (map[string]any, error) {
+	endpoint := fmt.Sprintf("%s/collection/%d?api_key=%s", c.baseURL, collectionID, url.QueryEscape(c.apiKey))
 	return c.getJSONMap(ctx, endpoint)
 }
 
@@ -248,6 +882,8 @@ func (c *Client) GetMetadataByResult(ctx context.Context, result SearchResult) (
 		return c.getMetadataByMovieID(ctx, result.ID)
 	case "tv":
 		return c.getMetadataByTVID(ctx, result.ID)
+	case "person":
+		return &Metadata{TMDBID: result.ID, TMDBType: "person"}, nil
 	default:
 		return nil, ErrInvalidMediaType
 	}
@@ -260,13 +896,19 @@ func (c *Client) GetMetadataByID(ctx context.Context, mediaID int, mediaType str
 		return c.getMetadataByMovieID(ctx, mediaID)
 	case "tv":
 		return c.getMetadataByTVID(ctx, mediaID)
+	case "person":
+		return &Metadata{TMDBID: mediaID, TMDBType: "person"}, nil
 	default:
 		return nil, ErrInvalidMediaType
 	}
 }
 
 func (c *Client) getMetadataByMovieID(ctx context.Context, movieID int) (*Metadata, error) {
-	details, err := c.GetMovieDetails(ctx, movieID)
+	appendTo := c.metadataAppend("release_dates")
+	if c.people {
+		appendTo += ",credits"
+	}
+	details, err := c.getMovieDetails(ctx, movieID, appendTo)
 	if err != nil {
 		return nil, err
 	}
@@ -279,16 +921,28 @@ func (c *Client) getMetadataByMovieID(ctx context.Context, movieID int) (*Metada
 	if runtime, ok := getInt(details, "runtime"); ok {
 		metadata.Runtime = &runtime
 	}
+	if year, ok := yearFromDate(details, "release_date"); ok {
+		metadata.Year = &year
+	}
 
 	if tags, err := c.buildGenreTags(ctx, "movie", details); err == nil {
 		metadata.GenreTags = tags
 	}
+	if c.keywordTags {
+		metadata.GenreTags = append(metadata.GenreTags, buildKeywordTags(details)...)
+	}
+	metadata.GenreTags = append(metadata.GenreTags, buildCompanyTags(details, "production_companies", "studio", c.companyTags)...)
+	metadata.ContentRating = MovieCertification(details, c.region)
+	metadata.Status, _ = getString(details, "status")
+	if c.people {
+		metadata.Directors = movieDirectors(details)
+	}
 
 	return metadata, nil
 }
 
 func (c *Client) getMetadataByTVID(ctx context.Context, tvID int) (*Metadata, error) {
-	details, err := c.GetTVDetails(ctx, tvID, "")
+	details, err := c.GetTVDetails(ctx, tvID, c.metadataAppend("content_ratings"))
 	if err != nil {
 		return nil, err
 	}
@@ -298,16 +952,31 @@ func (c *Client) getMetadataByTVID(ctx context.Context, tvID int) (*Metadata, er
 		TMDBType: "tv",
 	}
 
-	if runtime, ok := getEpisodeRuntime(details); ok {
-		metadata.Runtime = &runtime
+	if runtime, ok := EpisodeRuntime(details); ok {
+		metadata.EpisodeRuntime = &runtime
 	}
 	if episodes, ok := getInt(details, "number_of_episodes"); ok {
 		metadata.TotalEpisodes = &episodes
 	}
+	if year, ok := yearFromDate(details, "first_air_date"); ok {
+		metadata.Year = &year
+	}
 
 	if tags, err := c.buildGenreTags(ctx, "tv", details); err == nil {
 		metadata.GenreTags = tags
 	}
+	if c.keywordTags {
+		metadata.GenreTags = append(metadata.GenreTags, buildKeywordTags(details)...)
+	}
+	metadata.GenreTags = append(metadata.GenreTags, buildCompanyTags(details, "networks", "network", c.companyTags)...)
+	metadata.ContentRating = TVContentRating(details, c.region)
+	metadata.Status, _ = getString(details, "status")
+	if inProduction, ok := details["in_production"].(bool); ok {
+		metadata.InProduction = &inProduction
+	}
+	if c.people {
+		metadata.Directors = tvCreators(details)
+	}
 
 	return metadata, nil
 }
@@ -316,12 +985,16 @@ func (c *Client) getMetadataByTVID(ctx context.Context, tvID int) (*Metadata, er
 func (c *Client) GetCoverURLByID(ctx context.Context, mediaID int, mediaType string) (string, error) {
 	var details map[string]any
 	var err error
+	imageKey := "poster_path"
 
 	switch mediaType {
 	case "movie":
 		details, err = c.GetMovieDetails(ctx, mediaID)
 	case "tv":
 		details, err = c.GetTVDetails(ctx, mediaID, "")
+	case "person":
+		details, err = c.GetPersonDetails(ctx, mediaID)
+		imageKey = "profile_path"
 	default:
 		return "", ErrInvalidMediaType
 	}
@@ -329,18 +1002,96 @@ func (c *Client) GetCoverURLByID(ctx context.Context, mediaID int, mediaType str
 		return "", err
 	}
 
-	posterPath, _ := getString(details, "poster_path")
+	posterPath, _ := getString(details, imageKey)
 	if posterPath == "" {
 		return "", ErrNoPoster
 	}
 	return c.ImageURL(posterPath), nil
 }
 
-// ImageURL constructs the full image URL from a poster path.
+// GetBackdropURLByID returns the full URL of a movie or TV show's wide
+// backdrop image, or ErrNoBackdrop when it has none.
+func (c *Client) GetBackdropURLByID(ctx context.Context, mediaID int, mediaType string) (string, error) {
+	var details map[string]any
+	var err error
+
+	switch mediaType {
+	case "movie":
+		details, err = c.GetMovieDetails(ctx, mediaID)
+	case "tv":
+		details, err = c.GetTVDetails(ctx, mediaID, "")
+	default:
+		return "", ErrInvalidMediaType
+	}
+	if err != nil {
+		return "", err
+	}
+
+	backdropPath, _ := getString(details, "backdrop_path")
+	if backdropPath == "" {
+		return "", ErrNoBackdrop
+	}
+	return c.ImageURL(backdropPath), nil
+}
+
+// ThumbnailURL returns the URL of a small (w92) version of a poster.
+func (c *Client) ThumbnailURL(posterPath string) string {
+	base := c.imageBaseURL
+	if trimmed, ok := strings.CutSuffix(base, "/original"); ok {
+		base = trimmed + "/w92"
+	}
+	return base + posterPath
+}
+
+// FetchImage downloads an image and returns its raw bytes.
+func (c *Client) FetchImage(ctx context.Context, imageURL string) ([]byte, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.downloadTimeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %d downloading image", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// ImageURL constructs the full image URL from a poster path. Paths that are
+// already absolute URLs are returned unchanged.
 func (c *Client) ImageURL(posterPath string) string {
+	if IsAbsoluteImageURL(posterPath) {
+		return posterPath
+	}
 	return c.imageBaseURL + posterPath
 }
 
+// IsAbsoluteImageURL reports whether path is a full http(s) URL rather than a
+// TMDB poster path such as "/abc.jpg".
+func IsAbsoluteImageURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// PosterPath returns the TMDB poster path for a URL built by ImageURL, or
+// imageURL unchanged when it points elsewhere.
+func (c *Client) PosterPath(imageURL string) string {
+	if path, ok := strings.CutPrefix(imageURL, c.imageBaseURL); ok && strings.HasPrefix(path, "/") {
+		return path
+	}
+	return imageURL
+}
+
 // GetCoverAndMetadataByID fetches both cover URL and metadata by ID.
 func (c *Client) GetCoverAndMetadataByID(ctx context.Context, mediaID int, mediaType string) (string, *Metadata, error) {
 	cover, err := c.GetCoverURLByID(ctx, mediaID, mediaType)
@@ -369,12 +1120,20 @@ func (c *Client) GetCoverAndMetadataByResult(ctx context.Context, result SearchR
 	return cover, meta, nil
 }
 
-// DownloadAndResizeImage downloads an image and resizes it to the specified width.
-func (c *Client) DownloadAndResizeImage(ctx context.Context, imageURL, savePath string, maxWidth int) error {
-	if maxWidth <= 0 {
-		maxWidth = defaultMaxWidth
+// DownloadAndResizeImage downloads an image, resizes it to opts.MaxWidth and
+// saves it in opts.Format regardless of savePath's extension.
+func (c *Client) DownloadAndResizeImage(ctx context.Context, imageURL, savePath string, opts ImageOptions) error {
+	if opts.Background == nil {
+		opts.Background = c.posterBackground
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	opts = opts.withDefaults()
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.downloadTimeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, imageURL)
 	if err != nil {
 		return err
 	}
@@ -389,21 +1148,31 @@ func (c *Client) DownloadAndResizeImage(ctx context.Context, imageURL, savePath
 		return fmt.Errorf("unexpected status %d downloading image", resp.StatusCode)
 	}
 
-	img, err := imaging.Decode(resp.Body, imaging.AutoOrientation(true))
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
+	return saveImage(ctx, data, savePath, opts)
+}
 
-	width := img.Bounds().Dx()
-	if width > maxWidth {
-		img = imaging.Resize(img, maxWidth, 0, imaging.Lanczos)
+// saveImage writes image data to savePath via ResizeImageReader.
+func saveImage(ctx context.Context, data []byte, savePath string, opts ImageOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-
 	if err := os.MkdirAll(filepath.Dir(savePath), 0o755); err != nil {
 		return err
 	}
 
-	return imaging.Save(img, savePath, imaging.JPEGQuality(85))
+	file, err := os.Create(savePath)
+	if err != nil {
+		return err
+	}
+	if err := ResizeImageReader(bytes.NewReader(data), file, opts); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
 }
 
 func (c *Client) buildGenreTags(ctx context.Context, mediaType string, details map[string]any) ([]string, error) {
@@ -437,6 +1206,164 @@ func (c *Client) buildGenreTags(ctx context.Context, mediaType string, details m
 	return tags, nil
 }
 
+// buildCompanyTags returns prefix/Name tags for the companies listed under
+// key, e.g. "network/HBO" from "networks". Names are sanitized like genres.
+func buildCompanyTags(details map[string]any, key, prefix string, mode CompanyTags) []string {
+	if mode != CompanyTagsFirst && mode != CompanyTagsAll {
+		return nil
+	}
+	raw, _ := details[key].([]any)
+	var tags []string
+	for _, item := range raw {
+		company, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := getString(company, "name")
+		if name = sanitizeGenreName(name); name == "" {
+			continue
+		}
+		if tag := prefix + "/" + name; !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+		if mode == CompanyTagsFirst {
+			break
+		}
+	}
+	return tags
+}
+
+// GenreIDByName resolves a genre name to its TMDB ID for a media type.
+// Matching is case-insensitive and accepts both TMDB names ("Science
+// Fiction") and tag-style names ("science-fiction", "movie/Science-Fiction").
+func (c *Client) GenreIDByName(ctx context.Context, mediaType, name string) (int, bool, error) {
+	if mediaType != "movie" && mediaType != "tv" {
+		return 0, false, ErrInvalidMediaType
+	}
+
+	genres, err := c.getGenres(ctx, mediaType)
+	if err != nil {
+		return 0, false, err
+	}
+
+	byName := make(map[string]int, len(genres))
+	for id, genreName := range genres {
+		byName[normalizeGenreName(genreName)] = id
+	}
+
+	name = strings.TrimPrefix(name, mediaType+"/")
+	id, ok := byName[normalizeGenreName(name)]
+	return id, ok, nil
+}
+
+func normalizeGenreName(name string) string {
+	return strings.ToLower(sanitizeGenreName(name))
+}
+
+// metadataAppend returns the append_to_response value needed for metadata
+// lookups, starting with the media type's ratings endpoint.
+func (c *Client) metadataAppend(ratings string) string {
+	if c.keywordTags {
+		return ratings + ",keywords"
+	}
+	return ratings
+}
+
+// MovieCertification returns the movie's certification for region from
+// appended release_dates, preferring theatrical releases.
+func MovieCertification(details map[string]any, region string) string {
+	raw, ok := details["release_dates"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	results, ok := raw["results"].([]any)
+	if !ok {
+		return ""
+	}
+	for _, entry := range results {
+		country, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		if code, _ := getString(country, "iso_3166_1"); !strings.EqualFold(code, region) {
+			continue
+		}
+		dates, _ := country["release_dates"].([]any)
+		fallback := ""
+		for _, d := range dates {
+			release, ok := d.(map[string]any)
+			if !ok {
+				continue
+			}
+			cert, _ := getString(release, "certification")
+			if cert = strings.TrimSpace(cert); cert == "" {
+				continue
+			}
+			// release type 3 is theatrical
+			if releaseType, _ := getInt(release, "type"); releaseType == 3 {
+				return cert
+			}
+			if fallback == "" {
+				fallback = cert
+			}
+		}
+		return fallback
+	}
+	return ""
+}
+
+// TVContentRating returns the show's content rating for region from appended content_ratings.
+func TVContentRating(details map[string]any, region string) string {
+	raw, ok := details["content_ratings"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	results, ok := raw["results"].([]any)
+	if !ok {
+		return ""
+	}
+	for _, entry := range results {
+		obj, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		if code, _ := getString(obj, "iso_3166_1"); strings.EqualFold(code, region) {
+			rating, _ := getString(obj, "rating")
+			return strings.TrimSpace(rating)
+		}
+	}
+	return ""
+}
+
+// buildKeywordTags converts appended keywords into "keyword/..." tags. Movies
+// list them under keywords.keywords and TV shows under keywords.results.
+func buildKeywordTags(details map[string]any) []string {
+	keywords, ok := details["keywords"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	raw, ok := keywords["keywords"].([]any)
+	if !ok {
+		raw, ok = keywords["results"].([]any)
+		if !ok {
+			return nil
+		}
+	}
+
+	tags := make([]string, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := getString(m, "name")
+		if name = sanitizeGenreName(name); name != "" {
+			tags = append(tags, "keyword/"+name)
+		}
+	}
+	return tags
+}
+
 func (c *Client) getGenres(ctx context.Context, mediaType string) (map[int]string, error) {
 	c.mu.RLock()
 	if genres, ok := c.genreCache[mediaType]; ok {
@@ -473,16 +1400,26 @@ func (c *Client) getGenres(ctx context.Context, mediaType string) (map[int]strin
 }
 
 func (c *Client) getJSON(ctx context.Context, endpoint string, target any) error {
+	if data, ok := c.cache.get(endpoint); ok {
+		if err := json.Unmarshal(data, target); err == nil {
+			return nil
+		}
+	}
+
 	var lastErr error
 	for attempt := 1; attempt <= c.retryAttempts; attempt++ {
-		if err := c.doJSONRequest(ctx, endpoint, target); err != nil {
+		data, err := c.doJSONRequest(ctx, endpoint, target)
+		if err != nil {
 			lastErr = err
 			if !isRetryable(err) || attempt == c.retryAttempts {
 				return err
 			}
-			time.Sleep(backoffDelay(attempt))
+			if err := sleepContext(ctx, c.backoff(attempt)); err != nil {
+				return err
+			}
 			continue
 		}
+		c.cache.put(endpoint, data)
 		return nil
 	}
 	return lastErr
@@ -496,27 +1433,56 @@ func (c *Client) getJSONMap(ctx context.Context, endpoint string) (map[string]an
 	return data, nil
 }
 
-func (c *Client) doJSONRequest(ctx context.Context, endpoint string, target any) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+// newRequest builds a GET request carrying the client's User-Agent.
+func (c *Client) newRequest(ctx context.Context, target string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	return req, nil
+}
+
+// doJSONRequest performs the request, decodes the body into target, and
+// returns the raw body for caching.
+func (c *Client) doJSONRequest(ctx context.Context, endpoint string, target any) ([]byte, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, endpoint)
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
-		return fmt.Errorf("tmdb: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
 	}
 
-	return json.NewDecoder(resp.Body).Decode(target)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
 func isRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
 	var urlErr *url.Error
 	if errors.As(err, &urlErr) {
 		if urlErr.Timeout() {
@@ -530,6 +1496,18 @@ func isRetryable(err error) bool {
 	return false
 }
 
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func backoffDelay(attempt int) time.Duration {
 	// exponential backoff capped at 10 seconds
 	delay := time.Duration(1<<uint(attempt-1)) * time.Second
@@ -578,7 +1556,64 @@ func getString(m map[string]any, key string) (string, bool) {
 	return s, ok
 }
 
-func getEpisodeRuntime(details map[string]any) (int, bool) {
+// movieDirectors returns the names of crew members with the Director job
+// from appended credits.
+func movieDirectors(details map[string]any) []string {
+	credits, ok := details["credits"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	crew, _ := credits["crew"].([]any)
+
+	var names []string
+	for _, raw := range crew {
+		member, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if job, _ := getString(member, "job"); job != "Director" {
+			continue
+		}
+		if name, _ := getString(member, "name"); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// tvCreators returns the names in a show's created_by list.
+func tvCreators(details map[string]any) []string {
+	creators, _ := details["created_by"].([]any)
+
+	var names []string
+	for _, raw := range creators {
+		creator, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if name, _ := getString(creator, "name"); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// yearFromDate returns the year of a YYYY-MM-DD date field.
+func yearFromDate(details map[string]any, key string) (int, bool) {
+	date, _ := getString(details, key)
+	if len(date) < 4 {
+		return 0, false
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil || year <= 0 {
+		return 0, false
+	}
+	return year, true
+}
+
+// EpisodeRuntime returns the typical episode length in minutes from a TV
+// show's episode_run_time list, which TMDB often leaves empty.
+func EpisodeRuntime(details map[string]any) (int, bool) {
 	val, ok := details["episode_run_time"]
 	if !ok {
 		return 0, false
@@ -1,6 +1,25 @@
 package tmdb
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
 
 func TestSanitizeGenreName(t *testing.T) {
 	tests := map[string]string{
@@ -18,3 +37,806 @@ func TestSanitizeGenreName(t *testing.T) {
 		}
 	}
 }
+
+func TestGenreIDByName(t *testing.T) {
+	client := NewClient("key")
+	client.genreCache["movie"] = map[int]string{878: "Science Fiction", 10749: "Romance"}
+
+	for _, name := range []string{"Science Fiction", "science-fiction", "movie/Science-Fiction"} {
+		id, ok, err := client.GenreIDByName(context.Background(), "movie", name)
+		if err != nil || !ok || id != 878 {
+			t.Fatalf("GenreIDByName(%q) = %d, %v, %v; want 878", name, id, ok, err)
+		}
+	}
+	if _, ok, _ := client.GenreIDByName(context.Background(), "movie", "Western"); ok {
+		t.Fatalf("expected unknown genre to be reported as missing")
+	}
+}
+
+func TestMetadataYear(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/movie/603":
+			_, _ = w.Write([]byte(`{"id": 603, "runtime": 136, "release_date": "1999-03-30"}`))
+		case "/tv/1396":
+			_, _ = w.Write([]byte(`{"id": 1396, "first_air_date": "2008-01-20", "number_of_episodes": 62}`))
+		case "/tv/1":
+			_, _ = w.Write([]byte(`{"id": 1, "first_air_date": ""}`))
+		default:
+			_, _ = w.Write([]byte(`{"genres": []}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("key", WithBaseURL(server.URL))
+	tests := []struct {
+		id        int
+		mediaType string
+		want      int
+	}{
+		{603, "movie", 1999},
+		{1396, "tv", 2008},
+		{1, "tv", 0},
+	}
+	for _, tc := range tests {
+		meta, err := client.GetMetadataByID(context.Background(), tc.id, tc.mediaType)
+		if err != nil {
+			t.Fatalf("%s %d: unexpected error: %v", tc.mediaType, tc.id, err)
+		}
+		switch {
+		case tc.want == 0 && meta.Year != nil:
+			t.Fatalf("%s %d: expected no year, got %d", tc.mediaType, tc.id, *meta.Year)
+		case tc.want != 0 && (meta.Year == nil || *meta.Year != tc.want):
+			t.Fatalf("%s %d: expected year %d, got %v", tc.mediaType, tc.id, tc.want, meta.Year)
+		}
+	}
+}
+
+func TestDirectorsAndCreators(t *testing.T) {
+	movie := map[string]any{
+		"credits": map[string]any{
+			"crew": []any{
+				map[string]any{"name": "Lana Wachowski", "job": "Director"},
+				map[string]any{"name": "Joel Silver", "job": "Producer"},
+				map[string]any{"name": "Lilly Wachowski", "job": "Director"},
+				map[string]any{"name": "Lana Wachowski", "job": "Director"},
+			},
+		},
+	}
+	if got := strings.Join(movieDirectors(movie), ","); got != "Lana Wachowski,Lilly Wachowski" {
+		t.Fatalf("movieDirectors() = %q", got)
+	}
+
+	tv := map[string]any{
+		"created_by": []any{map[string]any{"name": "Vince Gilligan"}},
+	}
+	if got := strings.Join(tvCreators(tv), ","); got != "Vince Gilligan" {
+		t.Fatalf("tvCreators() = %q", got)
+	}
+}
+
+func TestAPIErrorClassification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/movie/1":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status_code": 7, "status_message": "Invalid API key"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("key", WithBaseURL(server.URL))
+
+	_, err := client.GetMovieDetails(context.Background(), 1)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 APIError, got %v", err)
+	}
+	if !IsUnauthorized(err) || !strings.Contains(apiErr.Body, "Invalid API key") {
+		t.Fatalf("expected unauthorized error with body, got %v", err)
+	}
+
+	_, err = client.GetMovieDetails(context.Background(), 2)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || IsUnauthorized(err) {
+		t.Fatalf("expected 404 APIError, got %v", err)
+	}
+
+	retryable := map[int]bool{
+		http.StatusNotFound:            false,
+		http.StatusUnauthorized:        false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+	}
+	for status, want := range retryable {
+		if got := isRetryable(fmt.Errorf("wrapped: %w", &APIError{StatusCode: status})); got != want {
+			t.Fatalf("isRetryable(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestBuildKeywordTags(t *testing.T) {
+	movie := map[string]any{
+		"keywords": map[string]any{
+			"keywords": []any{
+				map[string]any{"id": 4565.0, "name": "dystopia"},
+				map[string]any{"id": 4379.0, "name": "time travel"},
+			},
+		},
+	}
+	tv := map[string]any{
+		"keywords": map[string]any{
+			"results": []any{
+				map[string]any{"id": 1.0, "name": "cat & mouse"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		details map[string]any
+		want    []string
+	}{
+		{"movie", movie, []string{"keyword/dystopia", "keyword/time-travel"}},
+		{"tv", tv, []string{"keyword/cat-and-mouse"}},
+		{"missing", map[string]any{}, nil},
+	}
+	for _, tc := range tests {
+		got := buildKeywordTags(tc.details)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: buildKeywordTags() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBuildCompanyTags(t *testing.T) {
+	movie := map[string]any{
+		"production_companies": []any{
+			map[string]any{"id": 41077.0, "name": "A24"},
+			map[string]any{"id": 1.0, "name": "Lucasfilm Ltd."},
+			map[string]any{"id": 2.0, "name": "A24"},
+		},
+	}
+	tv := map[string]any{
+		"networks": []any{map[string]any{"id": 49.0, "name": "HBO"}},
+	}
+
+	tests := []struct {
+		name    string
+		details map[string]any
+		key     string
+		prefix  string
+		mode    CompanyTags
+		want    []string
+	}{
+		{"off", movie, "production_companies", "studio", CompanyTagsOff, nil},
+		{"first", movie, "production_companies", "studio", CompanyTagsFirst, []string{"studio/A24"}},
+		{"all", movie, "production_companies", "studio", CompanyTagsAll, []string{"studio/A24", "studio/Lucasfilm-Ltd."}},
+		{"network", tv, "networks", "network", CompanyTagsFirst, []string{"network/HBO"}},
+		{"missing", map[string]any{}, "networks", "network", CompanyTagsAll, nil},
+	}
+	for _, tc := range tests {
+		got := buildCompanyTags(tc.details, tc.key, tc.prefix, tc.mode)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: buildCompanyTags() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestContentRatings(t *testing.T) {
+	movie := map[string]any{
+		"release_dates": map[string]any{
+			"results": []any{
+				map[string]any{
+					"iso_3166_1": "US",
+					"release_dates": []any{
+						map[string]any{"certification": "", "type": 1.0},
+						map[string]any{"certification": "NR", "type": 4.0},
+						map[string]any{"certification": "R", "type": 3.0},
+					},
+				},
+				map[string]any{
+					"iso_3166_1": "FI",
+					"release_dates": []any{
+						map[string]any{"certification": "K-16", "type": 3.0},
+					},
+				},
+			},
+		},
+	}
+	if got := MovieCertification(movie, "US"); got != "R" {
+		t.Fatalf("MovieCertification(US) = %q, want R", got)
+	}
+	if got := MovieCertification(movie, "fi"); got != "K-16" {
+		t.Fatalf("MovieCertification(FI) = %q, want K-16", got)
+	}
+	if got := MovieCertification(movie, "DE"); got != "" {
+		t.Fatalf("MovieCertification(DE) = %q, want empty", got)
+	}
+
+	tv := map[string]any{
+		"content_ratings": map[string]any{
+			"results": []any{
+				map[string]any{"iso_3166_1": "US", "rating": "TV-MA"},
+			},
+		},
+	}
+	if got := TVContentRating(tv, "US"); got != "TV-MA" {
+		t.Fatalf("TVContentRating(US) = %q, want TV-MA", got)
+	}
+}
+
+func TestParseImageFormat(t *testing.T) {
+	tests := map[string]string{
+		"":     ".jpg",
+		"jpg":  ".jpg",
+		"JPEG": ".jpg",
+		"png":  ".png",
+		"webp": ".jpg",
+	}
+	for input, wantExt := range tests {
+		format, err := ParseImageFormat(input)
+		if err != nil {
+			t.Fatalf("ParseImageFormat(%q) returned error: %v", input, err)
+		}
+		if got := format.Extension(); got != wantExt {
+			t.Fatalf("ParseImageFormat(%q).Extension() = %q, want %q", input, got, wantExt)
+		}
+	}
+	if _, err := ParseImageFormat("gif"); err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+}
+
+func TestSaveImageNeverUpscales(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 100, 150))); err != nil {
+		t.Fatalf("encode source: %v", err)
+	}
+
+	savePath := filepath.Join(t.TempDir(), "cover.jpg")
+	opts := ImageOptions{MaxWidth: 1000}.withDefaults()
+	if err := saveImage(context.Background(), buf.Bytes(), savePath, opts); err != nil {
+		t.Fatalf("saveImage failed: %v", err)
+	}
+
+	saved, err := imaging.Open(savePath)
+	if err != nil {
+		t.Fatalf("open saved cover: %v", err)
+	}
+	if got := saved.Bounds().Size(); got.X != 100 || got.Y != 150 {
+		t.Fatalf("expected 100x150 cover, got %dx%d", got.X, got.Y)
+	}
+}
+
+func TestSaveImageKeepsFittingJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 200, 300)), &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("encode source: %v", err)
+	}
+
+	savePath := filepath.Join(t.TempDir(), "cover.jpg")
+	opts := ImageOptions{MaxWidth: 1000}.withDefaults()
+	if err := saveImage(context.Background(), buf.Bytes(), savePath, opts); err != nil {
+		t.Fatalf("saveImage failed: %v", err)
+	}
+
+	saved, err := os.ReadFile(savePath)
+	if err != nil {
+		t.Fatalf("read saved cover: %v", err)
+	}
+	if !bytes.Equal(saved, buf.Bytes()) {
+		t.Fatalf("expected the original JPEG bytes to be kept")
+	}
+}
+
+func TestSaveImageFlattensTransparencyForJPEG(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 0, G: 0, B: 255, A: 128})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("encode source: %v", err)
+	}
+
+	dir := t.TempDir()
+	jpegPath := filepath.Join(dir, "cover.jpg")
+	opts := ImageOptions{Quality: 100}.withDefaults()
+	if err := saveImage(context.Background(), buf.Bytes(), jpegPath, opts); err != nil {
+		t.Fatalf("saveImage failed: %v", err)
+	}
+	saved, err := imaging.Open(jpegPath)
+	if err != nil {
+		t.Fatalf("open saved cover: %v", err)
+	}
+	// half-transparent blue over white is a light blue, not a dark one
+	r, g, b, _ := saved.At(5, 5).RGBA()
+	if r>>8 < 100 || g>>8 < 100 || b>>8 < 200 {
+		t.Fatalf("expected blue blended onto white, got rgb(%d, %d, %d)", r>>8, g>>8, b>>8)
+	}
+
+	pngPath := filepath.Join(dir, "cover.png")
+	opts.Format = ImageFormatPNG
+	if err := saveImage(context.Background(), buf.Bytes(), pngPath, opts); err != nil {
+		t.Fatalf("saveImage failed: %v", err)
+	}
+	saved, err = imaging.Open(pngPath)
+	if err != nil {
+		t.Fatalf("open saved cover: %v", err)
+	}
+	if _, _, _, a := saved.At(5, 5).RGBA(); a>>8 == 255 {
+		t.Fatalf("expected PNG output to keep transparency")
+	}
+}
+
+func TestRateLimiterWaitsOnceBurstIsSpent(t *testing.T) {
+	limiter := newRateLimiter(20, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		if err := limiter.wait(ctx); err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("expected the third request to wait, took %v", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := limiter.wait(cancelled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	if limiter := newRateLimiter(0, 10); limiter != nil {
+		t.Fatalf("expected no limiter for a zero rate")
+	}
+	var limiter *rateLimiter
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatalf("nil limiter should not block: %v", err)
+	}
+}
+
+func TestImageURL(t *testing.T) {
+	client := NewClient("key")
+	tests := map[string]string{
+		"/poster.jpg":                       defaultImageBaseURL + "/poster.jpg",
+		"https://example.com/cover.jpg":     "https://example.com/cover.jpg",
+		"HTTP://example.com/cover.png":      "HTTP://example.com/cover.png",
+		defaultImageBaseURL + "/poster.jpg": defaultImageBaseURL + "/poster.jpg",
+	}
+	for input, want := range tests {
+		if got := client.ImageURL(input); got != want {
+			t.Fatalf("ImageURL(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestResizeImageReader(t *testing.T) {
+	var src bytes.Buffer
+	if err := png.Encode(&src, image.NewNRGBA(image.Rect(0, 0, 400, 600))); err != nil {
+		t.Fatalf("encode source: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := ResizeImageReader(&src, &out, ImageOptions{MaxWidth: 100, Format: ImageFormatPNG}); err != nil {
+		t.Fatalf("ResizeImageReader failed: %v", err)
+	}
+	config, format, err := image.DecodeConfig(&out)
+	if err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if format != "png" || config.Width != 100 || config.Height != 150 {
+		t.Fatalf("expected 100x150 png, got %dx%d %s", config.Width, config.Height, format)
+	}
+}
+
+func TestSearchFallsBackToOriginalTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [
+			{"id": 1, "media_type": "movie", "original_title": "Le Samouraï", "poster_path": "/a.jpg", "release_date": "1967-10-25"},
+			{"id": 2, "media_type": "tv", "poster_path": "/b.jpg"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("key", WithBaseURL(server.URL))
+	response, err := client.SearchMulti(context.Background(), "samourai", 1, 10)
+	if err != nil {
+		t.Fatalf("SearchMulti failed: %v", err)
+	}
+	if len(response.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(response.Results))
+	}
+	if got := response.Results[0].DisplayTitle(); got != "Le Samouraï" {
+		t.Fatalf("expected original title, got %q", got)
+	}
+	if got := response.Results[1].DisplayTitle(); got != "Untitled" {
+		t.Fatalf("expected Untitled for a result without titles, got %q", got)
+	}
+}
+
+func TestSearchDeduplicatesResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"page": 1, "total_pages": 1, "results": [
+			{"id": 1, "media_type": "movie", "title": "Heat", "poster_path": "/a.jpg"},
+			{"id": 1, "media_type": "movie", "title": "Heat", "poster_path": "/a.jpg"},
+			{"id": 1, "media_type": "tv", "name": "Heat", "poster_path": "/b.jpg"},
+			{"id": 2, "media_type": "movie", "title": "Heat Wave", "poster_path": "/c.jpg"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("key", WithBaseURL(server.URL))
+	response, err := client.SearchMulti(context.Background(), "heat", 1, 3)
+	if err != nil {
+		t.Fatalf("SearchMulti failed: %v", err)
+	}
+	var got []string
+	for _, result := range response.Results {
+		got = append(got, fmt.Sprintf("%s/%d", result.MediaType, result.ID))
+	}
+	if want := "movie/1 tv/1 movie/2"; strings.Join(got, " ") != want {
+		t.Fatalf("expected %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+// stubResponse is a canned reply served by stubDoer.
+type stubResponse struct {
+	status int
+	body   string
+}
+
+// stubDoer is an HTTPDoer serving canned responses by URL path. Each path
+// serves its responses in order and repeats the last one; unknown paths get
+// a 404. Requested paths are recorded in requests and the time each request
+// had left before its context deadline in deadlines.
+type stubDoer struct {
+	responses map[string][]stubResponse
+	requests  []string
+	deadlines []time.Duration
+}
+
+func (s *stubDoer) Do(req *http.Request) (*http.Response, error) {
+	path := req.URL.Path
+	s.requests = append(s.requests, path)
+	var remaining time.Duration
+	if deadline, ok := req.Context().Deadline(); ok {
+		remaining = time.Until(deadline)
+	}
+	s.deadlines = append(s.deadlines, remaining)
+
+	reply := stubResponse{status: http.StatusNotFound, body: `{"status_code": 34}`}
+	if queue := s.responses[path]; len(queue) > 0 {
+		reply = queue[0]
+		if len(queue) > 1 {
+			s.responses[path] = queue[1:]
+		}
+	}
+	return &http.Response{
+		StatusCode: reply.status,
+		Body:       io.NopCloser(strings.NewReader(reply.body)),
+		Header:     make(http.Header),
+		Request:    req,
+	}, nil
+}
+
+// fixture reads a recorded TMDB response from testdata.
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return string(data)
+}
+
+// newStubClient returns a client backed by doer that retries without waiting.
+func newStubClient(doer *stubDoer) *Client {
+	client := NewClient("key", WithHTTPClient(doer), WithBaseURL("https://tmdb.test/3"), WithRateLimit(0, 0))
+	client.backoff = func(int) time.Duration { return 0 }
+	return client
+}
+
+func TestSearchMultiFixture(t *testing.T) {
+	doer := &stubDoer{responses: map[string][]stubResponse{
+		"/3/search/multi": {{http.StatusOK, fixture(t, "search_multi.json")}},
+	}}
+	response, err := newStubClient(doer).SearchMulti(context.Background(), "game of", 1, 10)
+	if err != nil {
+		t.Fatalf("SearchMulti failed: %v", err)
+	}
+
+	// the person and the result without a poster are dropped
+	if len(response.Results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", response.Results)
+	}
+	show, movie := response.Results[0], response.Results[1]
+	if show.ID != 1399 || show.MediaType != "tv" || show.Year() != "2011" {
+		t.Fatalf("unexpected first result: %+v", show)
+	}
+	if show.OriginalLanguage != "en" || show.Popularity != 369.6 || show.VoteCount != 24531 {
+		t.Fatalf("expected language, popularity, and vote count to be decoded, got %+v", show)
+	}
+	if movie.ID != 43914 || movie.DisplayTitle() != "Game of Death" {
+		t.Fatalf("unexpected second result: %+v", movie)
+	}
+	if !response.HasMore() {
+		t.Fatalf("expected more pages")
+	}
+}
+
+func TestTVMetadataFixture(t *testing.T) {
+	doer := &stubDoer{responses: map[string][]stubResponse{
+		"/3/tv/1399":       {{http.StatusOK, fixture(t, "tv_details.json")}},
+		"/3/genre/tv/list": {{http.StatusOK, `{"genres": [{"id": 10765, "name": "Sci-Fi & Fantasy"}, {"id": 18, "name": "Drama"}]}`}},
+	}}
+	meta, err := newStubClient(doer).GetMetadataByID(context.Background(), 1399, "tv")
+	if err != nil {
+		t.Fatalf("GetMetadataByID failed: %v", err)
+	}
+	if meta.EpisodeRuntime == nil || *meta.EpisodeRuntime != 60 {
+		t.Fatalf("expected episode runtime 60, got %v", meta.EpisodeRuntime)
+	}
+	if meta.TotalEpisodes == nil || *meta.TotalEpisodes != 73 {
+		t.Fatalf("expected 73 episodes, got %v", meta.TotalEpisodes)
+	}
+	if got := strings.Join(meta.GenreTags, " "); got != "tv/Sci-Fi-and-Fantasy tv/Drama" {
+		t.Fatalf("unexpected genre tags: %q", got)
+	}
+}
+
+func TestTVStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		details      string
+		status       string
+		inProduction bool
+	}{
+		{"ongoing", `{"id": 1, "status": "Returning Series", "in_production": true}`, "Returning Series", true},
+		{"ended", `{"id": 1, "status": "Ended", "in_production": false}`, "Ended", false},
+	}
+	for _, tc := range tests {
+		doer := &stubDoer{responses: map[string][]stubResponse{
+			"/3/tv/1":          {{http.StatusOK, tc.details}},
+			"/3/genre/tv/list": {{http.StatusOK, `{"genres": []}`}},
+		}}
+		meta, err := newStubClient(doer).GetMetadataByID(context.Background(), 1, "tv")
+		if err != nil {
+			t.Fatalf("%s: GetMetadataByID failed: %v", tc.name, err)
+		}
+		if meta.Status != tc.status {
+			t.Fatalf("%s: status = %q, want %q", tc.name, meta.Status, tc.status)
+		}
+		if meta.InProduction == nil || *meta.InProduction != tc.inProduction {
+			t.Fatalf("%s: in_production = %v, want %v", tc.name, meta.InProduction, tc.inProduction)
+		}
+	}
+}
+
+func TestEpisodeFixture(t *testing.T) {
+	doer := &stubDoer{responses: map[string][]stubResponse{
+		"/3/tv/1399":                    {{http.StatusOK, fixture(t, "tv_details.json")}},
+		"/3/tv/1399/season/1/episode/1": {{http.StatusOK, fixture(t, "tv_episode.json")}},
+		"/3/genre/tv/list":              {{http.StatusOK, `{"genres": [{"id": 18, "name": "Drama"}]}`}},
+	}}
+	client := newStubClient(doer)
+
+	still, err := client.GetEpisodeStillURL(context.Background(), 1399, 1, 1)
+	if err != nil {
+		t.Fatalf("GetEpisodeStillURL failed: %v", err)
+	}
+	if !strings.HasSuffix(still, "/9hGF3WUkBf7cSjMg0cdMDHJkByd.jpg") {
+		t.Fatalf("unexpected still URL %q", still)
+	}
+
+	meta, err := client.GetEpisodeMetadata(context.Background(), 1399, 1, 1)
+	if err != nil {
+		t.Fatalf("GetEpisodeMetadata failed: %v", err)
+	}
+	if meta.EpisodeTitle != "Winter Is Coming" || meta.AirDate != "2011-04-17" {
+		t.Fatalf("unexpected episode title or air date: %+v", meta)
+	}
+	if meta.Runtime == nil || *meta.Runtime != 62 || meta.EpisodeRuntime != nil || meta.TotalEpisodes != nil {
+		t.Fatalf("expected only the episode runtime, got %+v", meta)
+	}
+	if meta.TMDBID != 1399 || meta.TMDBType != "tv" || len(meta.GenreTags) == 0 {
+		t.Fatalf("expected the show's ID and genres, got %+v", meta)
+	}
+}
+
+func TestEpisodeRuntime(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  int
+		ok    bool
+	}{
+		{"decoded JSON", []any{float64(45), float64(50)}, 45, true},
+		{"ints", []any{30}, 30, true},
+		{"string", []any{"22"}, 22, true},
+		{"bad string", []any{"n/a"}, 0, false},
+		{"empty list", []any{}, 0, false},
+		{"int slice", []int{25}, 25, true},
+		{"scalar", float64(40), 0, false},
+	}
+	for _, tc := range tests {
+		got, ok := EpisodeRuntime(map[string]any{"episode_run_time": tc.value})
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("%s: got (%d, %v), want (%d, %v)", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+	if _, ok := EpisodeRuntime(map[string]any{}); ok {
+		t.Fatalf("expected no runtime when the key is missing")
+	}
+}
+
+func TestGetJSONRetries(t *testing.T) {
+	doer := &stubDoer{responses: map[string][]stubResponse{
+		"/3/movie/1": {
+			{http.StatusServiceUnavailable, `{}`},
+			{http.StatusTooManyRequests, `{}`},
+			{http.StatusOK, `{"id": 1, "title": "Retried"}`},
+		},
+		"/3/movie/2": {{http.StatusInternalServerError, `{}`}},
+	}}
+	client := newStubClient(doer)
+
+	details, err := client.GetMovieDetails(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+	if details["title"] != "Retried" || len(doer.requests) != 3 {
+		t.Fatalf("expected 3 requests and the final body, got %d requests and %v", len(doer.requests), details)
+	}
+
+	doer.requests = nil
+	if _, err := client.GetMovieDetails(context.Background(), 3); err == nil || len(doer.requests) != 1 {
+		t.Fatalf("expected a 404 to fail without retrying, got %v after %d requests", err, len(doer.requests))
+	}
+
+	doer.requests = nil
+	if _, err := client.GetMovieDetails(context.Background(), 2); err == nil || len(doer.requests) != defaultMaxAttempts {
+		t.Fatalf("expected %d attempts for a persistent 500, got %d", defaultMaxAttempts, len(doer.requests))
+	}
+
+	// a cancelled context stops the backoff wait
+	client.backoff = func(int) time.Duration { return time.Hour }
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GetMovieDetails(ctx, 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestIncludeAdult(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var got string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.URL.Query().Get("include_adult")
+			_, _ = w.Write([]byte(`{"results": []}`))
+		}))
+		client := NewClient("key", WithBaseURL(server.URL), WithIncludeAdult(enabled))
+		if _, err := client.SearchMulti(context.Background(), "query", 1, 10); err != nil {
+			t.Fatalf("SearchMulti failed: %v", err)
+		}
+		server.Close()
+		if want := fmt.Sprint(enabled); got != want {
+			t.Fatalf("include_adult = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestTransportOptions(t *testing.T) {
+	client := NewClient("key", WithProxy("http://proxy.test:3128"), WithInsecureSkipVerify(true))
+	httpClient, ok := client.httpClient.(*http.Client)
+	if !ok {
+		t.Fatalf("expected an *http.Client, got %T", client.httpClient)
+	}
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", httpClient.Transport)
+	}
+	req := httptest.NewRequest(http.MethodGet, "https://api.themoviedb.org/3/movie/1", nil)
+	proxyURL, err := transport.Proxy(req)
+	if err != nil || proxyURL.String() != "http://proxy.test:3128" {
+		t.Fatalf("unexpected proxy %v (%v)", proxyURL, err)
+	}
+	if !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatalf("expected certificate verification to be disabled")
+	}
+
+	if _, err := newTransport(transportConfig{proxy: "not a url"}).Proxy(req); err == nil {
+		t.Fatalf("expected an error for an invalid proxy URL")
+	}
+
+	// an injected client is left alone
+	doer := &stubDoer{}
+	if client := NewClient("key", WithHTTPClient(doer), WithProxy("http://proxy.test:3128")); client.httpClient != doer {
+		t.Fatalf("expected the injected client to be kept")
+	}
+}
+
+func TestGetAlternativeTitles(t *testing.T) {
+	doer := &stubDoer{responses: map[string][]stubResponse{
+		"/3/movie/949/alternative_titles": {{http.StatusOK, `{"id": 949, "titles": [{"iso_3166_1": "FI", "title": "Heat - Kuumana", "type": ""}, {"iso_3166_1": "US", "title": " "}]}`}},
+		"/3/tv/1399/alternative_titles":   {{http.StatusOK, `{"id": 1399, "results": [{"iso_3166_1": "FI", "title": "Valtaistuinpeli", "type": ""}]}`}},
+	}}
+	client := newStubClient(doer)
+
+	movie, err := client.GetAlternativeTitles(context.Background(), 949, "movie")
+	if err != nil {
+		t.Fatalf("GetAlternativeTitles failed: %v", err)
+	}
+	if len(movie) != 1 || movie[0].Title != "Heat - Kuumana" || movie[0].Country != "FI" {
+		t.Fatalf("unexpected movie titles: %+v", movie)
+	}
+
+	tv, err := client.GetAlternativeTitles(context.Background(), 1399, "tv")
+	if err != nil {
+		t.Fatalf("GetAlternativeTitles failed: %v", err)
+	}
+	if len(tv) != 1 || tv[0].Title != "Valtaistuinpeli" {
+		t.Fatalf("unexpected TV titles: %+v", tv)
+	}
+
+	if _, err := client.GetAlternativeTitles(context.Background(), 1, "person"); !errors.Is(err, ErrInvalidMediaType) {
+		t.Fatalf("expected ErrInvalidMediaType for people, got %v", err)
+	}
+}
+
+func TestIsCacheable(t *testing.T) {
+	tests := map[string]bool{
+		"https://api.themoviedb.org/3/movie/949?api_key=x":                   true,
+		"https://api.themoviedb.org/3/genre/movie/list?api_key=x":            true,
+		"https://api.themoviedb.org/3/search/multi?query=Heat&api_key=x":     false,
+		"https://api.themoviedb.org/3/discover/movie?with_genres=878&page=2": false,
+		"://bad": false,
+	}
+	for endpoint, want := range tests {
+		if got := isCacheable(endpoint); got != want {
+			t.Fatalf("isCacheable(%q) = %v, want %v", endpoint, got, want)
+		}
+	}
+}
+
+func TestRequestTimeouts(t *testing.T) {
+	doer := &stubDoer{responses: map[string][]stubResponse{
+		"/3/movie/949":        {{http.StatusOK, `{"id": 949}`}},
+		"/t/p/original/a.jpg": {{http.StatusOK, "image"}},
+	}}
+	client := NewClient("key", WithHTTPClient(doer), WithBaseURL("https://tmdb.test/3"),
+		WithImageBaseURL("https://image.tmdb.test/t/p/original"), WithRateLimit(0, 0),
+		WithTimeout(5*time.Second), WithDownloadTimeout(2*time.Minute))
+
+	if _, err := client.GetMovieDetails(context.Background(), 949); err != nil {
+		t.Fatalf("GetMovieDetails: %v", err)
+	}
+	if _, err := client.FetchImage(context.Background(), client.ImageURL("/a.jpg")); err != nil {
+		t.Fatalf("FetchImage: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		min, max time.Duration
+	}{
+		{"API call", 4 * time.Second, 5 * time.Second},
+		{"image download", time.Minute, 2 * time.Minute},
+	}
+	if len(doer.deadlines) != len(tests) {
+		t.Fatalf("expected %d requests, got %v", len(tests), doer.requests)
+	}
+	for i, tt := range tests {
+		if got := doer.deadlines[i]; got <= tt.min || got > tt.max {
+			t.Fatalf("%s: deadline in %v, want within %v", tt.name, got, tt.max)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package tmdb
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register JPEG for image.DecodeConfig
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+const defaultJPEGQuality = 85
+
+// NoResize as ImageOptions.MaxWidth keeps covers at their original size.
+const NoResize = -1
+
+// BackdropMaxWidth is the maximum width used for wide backdrop banners.
+const BackdropMaxWidth = 1920
+
+// ImageFormat is the file format used when saving downloaded covers.
+type ImageFormat string
+
+const (
+	// ImageFormatJPEG saves covers as JPEG.
+	ImageFormatJPEG ImageFormat = "jpeg"
+	// ImageFormatPNG saves covers as lossless PNG.
+	ImageFormatPNG ImageFormat = "png"
+	// ImageFormatWebP requests WebP output. No pure-Go WebP encoder is
+	// available, so covers fall back to JPEG (see Resolve).
+	ImageFormatWebP ImageFormat = "webp"
+)
+
+// ParseImageFormat converts a string such as "jpg" or "png" into an ImageFormat.
+func ParseImageFormat(value string) (ImageFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "jpg", "jpeg":
+		return ImageFormatJPEG, nil
+	case "png":
+		return ImageFormatPNG, nil
+	case "webp":
+		return ImageFormatWebP, nil
+	default:
+		return "", fmt.Errorf("unknown image format: %q", value)
+	}
+}
+
+// Resolve returns the format that will actually be written and whether it
+// differs from the requested one.
+func (f ImageFormat) Resolve() (ImageFormat, bool) {
+	switch f {
+	case ImageFormatPNG:
+		return ImageFormatPNG, false
+	case ImageFormatWebP:
+		return ImageFormatJPEG, true
+	default:
+		return ImageFormatJPEG, false
+	}
+}
+
+// Extension returns the file extension, including the dot, for the resolved format.
+func (f ImageFormat) Extension() string {
+	resolved, _ := f.Resolve()
+	if resolved == ImageFormatPNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// ImageOptions controls how covers are resized and saved.
+type ImageOptions struct {
+	// MaxWidth is the maximum width in pixels; wider images are scaled down
+	// and narrower ones are never enlarged. Zero uses the default width and
+	// NoResize keeps the original size.
+	MaxWidth int
+	// Format is the output file format.
+	Format ImageFormat
+	// Quality is the JPEG quality (1-100). It is ignored for PNG.
+	Quality int
+	// Background fills transparent areas when saving as JPEG. Nil uses
+	// white, or the client's poster background for downloads.
+	Background color.Color
+}
+
+func (o ImageOptions) withDefaults() ImageOptions {
+	if o.MaxWidth == 0 {
+		o.MaxWidth = defaultMaxWidth
+	}
+	if o.Format == "" {
+		o.Format = ImageFormatJPEG
+	}
+	if o.Quality <= 0 || o.Quality > 100 {
+		o.Quality = defaultJPEGQuality
+	}
+	if o.Background == nil {
+		o.Background = color.White
+	}
+	return o
+}
+
+// ResizeImageReader decodes an image from r, applies its EXIF orientation,
+// scales it down to opts.MaxWidth, and encodes it to w in opts.Format.
+// Images are never enlarged. A JPEG that already fits is copied as is, since
+// re-encoding it would only lose quality.
+//
+// Formats without transparency get transparent images composited onto
+// opts.Background first; otherwise JPEG encoding would flatten them onto black.
+func ResizeImageReader(r io.Reader, w io.Writer, opts ImageOptions) error {
+	opts = opts.withDefaults()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	config, sourceFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	fits := opts.MaxWidth <= 0 || config.Width <= opts.MaxWidth
+	resolved, _ := opts.Format.Resolve()
+	if fits && sourceFormat == "jpeg" && resolved == ImageFormatJPEG {
+		_, err := w.Write(data)
+		return err
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return err
+	}
+	if !fits {
+		img = imaging.Resize(img, opts.MaxWidth, 0, imaging.Lanczos)
+	}
+	if resolved == ImageFormatJPEG && !isOpaque(img) {
+		bounds := img.Bounds()
+		img = imaging.Overlay(imaging.New(bounds.Dx(), bounds.Dy(), opts.Background), img, image.Pt(0, 0), 1.0)
+	}
+
+	format, encodeOpts := opts.encodeOptions()
+	return imaging.Encode(w, img, format, encodeOpts...)
+}
+
+// isOpaque reports whether img has no transparent pixels.
+func isOpaque(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return opaque.Opaque()
+	}
+	return true
+}
+
+func (o ImageOptions) encodeOptions() (imaging.Format, []imaging.EncodeOption) {
+	resolved, _ := o.Format.Resolve()
+	if resolved == ImageFormatPNG {
+		return imaging.PNG, []imaging.EncodeOption{imaging.PNGCompressionLevel(png.BestCompression)}
+	}
+	return imaging.JPEG, []imaging.EncodeOption{imaging.JPEGQuality(o.Quality)}
+}
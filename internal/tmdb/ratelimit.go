@@ -0,0 +1,80 @@
+package tmdb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRateLimit follows TMDB's documented limit of roughly 50
+	// requests per 10 seconds.
+	defaultRateLimit = 5
+	defaultRateBurst = 10
+)
+
+// rateLimiter is a token bucket shared by every request a Client makes, so
+// concurrent workers stay under the limit together.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// WithRateLimit limits requests to rps per second with bursts of up to burst
+// requests. A non-positive rps disables rate limiting.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(client *Client) {
+		client.limiter = newRateLimiter(rps, burst)
+	}
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a request may be made or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// take the token up front so waiters are served in order
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the token back for the next caller
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
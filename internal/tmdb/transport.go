@@ -0,0 +1,58 @@
+package tmdb
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// transportConfig holds the network settings applied to the default HTTP
+// client. A client injected with WithHTTPClient is used as is; configure
+// its transport directly instead.
+type transportConfig struct {
+	proxy              string
+	insecureSkipVerify bool
+}
+
+// WithProxy sends all requests through the proxy at proxyURL, e.g.
+// "http://proxy.example.com:3128". Without it, the HTTP_PROXY, HTTPS_PROXY,
+// and NO_PROXY environment variables are honored.
+func WithProxy(proxyURL string) Option {
+	return func(client *Client) {
+		client.transport.proxy = proxyURL
+	}
+}
+
+// WithInsecureSkipVerify disables TLS certificate verification, for networks
+// that intercept TLS with their own certificates. Only use it when you trust
+// the network.
+func WithInsecureSkipVerify(enabled bool) Option {
+	return func(client *Client) {
+		client.transport.insecureSkipVerify = enabled
+	}
+}
+
+// newTransport builds an http.Transport from cfg, starting from the
+// defaults so environment proxies and timeouts keep working.
+func newTransport(cfg transportConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.proxy != "" {
+		proxyURL, err := url.Parse(cfg.proxy)
+		if err == nil && proxyURL.Host == "" {
+			err = fmt.Errorf("missing host")
+		}
+		if err != nil {
+			// surface the bad setting on every request rather than
+			// silently connecting directly
+			proxyErr := fmt.Errorf("invalid proxy URL %q: %w", cfg.proxy, err)
+			transport.Proxy = func(*http.Request) (*url.URL, error) { return nil, proxyErr }
+		} else {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	if cfg.insecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // explicitly requested by the user
+	}
+	return transport
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// posterCols and posterRows size the thumbnail in terminal cells.
+	posterCols = 8
+	posterRows = 6
+	// firstImageID keeps our image IDs clear of low IDs other programs may use.
+	firstImageID = 200
+	maxImageID   = 255
+	kittyChunk   = 4096
+	placeholder  = '\U0010EEEE'
+)
+
+// PosterFetcher downloads a poster thumbnail for a TMDB poster path.
+type PosterFetcher func(posterPath string) ([]byte, error)
+
+// thumbnailCache holds PNG-encoded thumbnails by poster path for the whole run.
+var thumbnailCache sync.Map
+
+// rowColumnDiacritics encode row and column numbers in Kitty Unicode placeholders.
+var rowColumnDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D,
+	0x033E, 0x033F, 0x0346, 0x034A, 0x034B, 0x034C,
+}
+
+// kittySupported reports whether the terminal understands the Kitty graphics
+// protocol with Unicode placeholders. Placeholders are ordinary text cells, so
+// they survive Bubble Tea's line-based renderer. Protocols that draw images
+// directly at the cursor (such as iTerm2's inline images) would be truncated
+// or overwritten by the renderer, so those terminals use the text-only view.
+func kittySupported() bool {
+	if os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("TERM") == "xterm-kitty" {
+		return true
+	}
+	return os.Getenv("TERM_PROGRAM") == "ghostty"
+}
+
+// posterLoadedMsg carries the thumbnail fetched for a poster path.
+type posterLoadedMsg struct {
+	posterPath string
+	data       []byte
+	err        error
+}
+
+// loadPoster fetches the thumbnail for posterPath in the background, so the
+// selector opens at once and only the posters the user highlights are
+// downloaded.
+func loadPoster(fetch PosterFetcher, posterPath string) tea.Cmd {
+	return func() tea.Msg {
+		data, err := thumbnail(fetch, posterPath)
+		return posterLoadedMsg{posterPath: posterPath, data: data, err: err}
+	}
+}
+
+func thumbnail(fetch PosterFetcher, posterPath string) ([]byte, error) {
+	if cached, ok := thumbnailCache.Load(posterPath); ok {
+		return cached.([]byte), nil
+	}
+	raw, err := fetch(posterPath)
+	if err != nil {
+		return nil, err
+	}
+	img, err := imaging.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	// the Kitty protocol accepts PNG directly but not JPEG
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	thumbnailCache.Store(posterPath, buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+// transmitKitty sends a PNG and creates a virtual placement for Unicode placeholders.
+func transmitKitty(w io.Writer, id int, pngData []byte) error {
+	payload := base64.StdEncoding.EncodeToString(pngData)
+	first := true
+	for len(payload) > 0 {
+		chunk := payload
+		if len(chunk) > kittyChunk {
+			chunk = chunk[:kittyChunk]
+		}
+		payload = payload[len(chunk):]
+		more := 0
+		if len(payload) > 0 {
+			more = 1
+		}
+
+		var err error
+		if first {
+			_, err = fmt.Fprintf(w, "\x1b_Ga=T,U=1,f=100,q=2,i=%d,c=%d,r=%d,m=%d;%s\x1b\\", id, posterCols, posterRows, more, chunk)
+			first = false
+		} else {
+			_, err = fmt.Fprintf(w, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteKittyImages frees the transmitted images.
+func deleteKittyImages(w io.Writer, ids map[string]int) {
+	for _, id := range ids {
+		_, _ = fmt.Fprintf(w, "\x1b_Ga=d,d=I,q=2,i=%d\x1b\\", id)
+	}
+}
+
+// renderPlaceholder draws the placeholder cells for an image. The foreground
+// color carries the image ID and the diacritics carry row and column.
+func renderPlaceholder(id int) string {
+	var builder strings.Builder
+	for row := 0; row < posterRows; row++ {
+		if row > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("\x1b[38;5;%dm", id))
+		for col := 0; col < posterCols; col++ {
+			builder.WriteRune(placeholder)
+			builder.WriteRune(rowColumnDiacritics[row])
+			builder.WriteRune(rowColumnDiacritics[col])
+		}
+		builder.WriteString("\x1b[39m")
+	}
+	return builder.String()
+}
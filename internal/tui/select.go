@@ -2,22 +2,42 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/util"
 )
 
 const (
 	defaultListWidth  = 72
 	defaultListHeight = 12
+	// MaxOverviewLines is the most lines WithOverviewLines accepts.
+	MaxOverviewLines = 6
 )
 
+// ErrNotInteractive is returned by Select when no terminal is attached.
+var ErrNotInteractive = errors.New("selector needs an interactive terminal")
+
+// isTerminal reports whether f is a terminal. Tests can replace it.
+var isTerminal = util.IsTerminal
+
+// Interactive reports whether stdin and stdout are both terminals, which
+// Select needs to run.
+func Interactive() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
 // SelectionAction represents the user's action in the selection UI.
 type SelectionAction int
 
@@ -59,7 +79,9 @@ func (i tmdbItem) Description() string {
 type itemStyles struct {
 	normal        lipgloss.Style
 	selected      lipgloss.Style
-	typeStyle     lipgloss.Style
+	movieBadge    lipgloss.Style
+	tvBadge       lipgloss.Style
+	otherBadge    lipgloss.Style
 	titleStyle    lipgloss.Style
 	ratingStyle   lipgloss.Style
 	overviewStyle lipgloss.Style
@@ -83,17 +105,22 @@ func newItemStyles() itemStyles {
 		Padding(0, 1).
 		Foreground(lipgloss.Color("252"))
 
-	selected := container.Copy().
+	selected := container.
 		BorderForeground(lipgloss.Color("214")).
 		Foreground(lipgloss.Color("230")).
 		Background(lipgloss.Color("237"))
 
+	badge := lipgloss.NewStyle().
+		Bold(true).
+		Padding(0, 1).
+		Foreground(lipgloss.Color("230"))
+
 	return itemStyles{
-		normal:   container,
-		selected: selected,
-		typeStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("110")),
+		normal:     container,
+		selected:   selected,
+		movieBadge: badge.Background(lipgloss.Color("25")),
+		tvBadge:    badge.Background(lipgloss.Color("29")),
+		otherBadge: badge.Background(lipgloss.Color("96")),
 		titleStyle: lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("254")),
@@ -106,13 +133,38 @@ func newItemStyles() itemStyles {
 
 type tmdbDelegate struct {
 	styles itemStyles
+	// overviewLines is how many lines the overview may wrap across.
+	overviewLines int
+	// aka reserves a row for alternative titles; set when any result has
+	// them.
+	aka bool
 }
 
-func newDelegate() tmdbDelegate {
-	return tmdbDelegate{styles: newItemStyles()}
+// badge renders a color-coded label so movies and TV shows with the same
+// name are easy to tell apart.
+func (d tmdbDelegate) badge(mediaType string) string {
+	switch mediaType {
+	case "movie":
+		return d.styles.movieBadge.Render("MOVIE")
+	case "tv":
+		return d.styles.tvBadge.Render("TV SERIES")
+	default:
+		return d.styles.otherBadge.Render(strings.ToUpper(mediaType))
+	}
+}
+
+func newDelegate(overviewLines int) tmdbDelegate {
+	return tmdbDelegate{styles: newItemStyles(), overviewLines: overviewLines}
+}
+
+func (d tmdbDelegate) Height() int {
+	height := 3 + d.overviewLines
+	if d.aka {
+		height++
+	}
+	return height
 }
 
-func (d tmdbDelegate) Height() int                         { return 4 }
 func (d tmdbDelegate) Spacing() int                        { return 1 }
 func (d tmdbDelegate) Update(tea.Msg, *list.Model) tea.Cmd { return nil }
 
@@ -122,21 +174,29 @@ func (d tmdbDelegate) Render(w io.Writer, m list.Model, idx int, item list.Item)
 		return
 	}
 
-	typeLabel := result.MediaType
 	title := result.DisplayTitle()
 	year := result.Year()
 	rating := result.VoteAverage
 	overview := result.Overview
 	if len(overview) > 0 {
-		overview = truncate(overview, m.Width()-4)
+		overview = wrap(overview, m.Width()-4, d.overviewLines)
 	}
 
-	typeLine := d.styles.typeStyle.Render(fmt.Sprintf("[%s]", strings.ToUpper(typeLabel)))
+	typeLine := d.badge(result.MediaType)
+	if result.OriginalLanguage != "" {
+		typeLine += " " + d.styles.ratingStyle.Render(strings.ToLower(result.OriginalLanguage))
+	}
 	titleLine := d.styles.titleStyle.Render(fmt.Sprintf("%s (%s)", strings.ToUpper(title), year))
-	ratingLine := d.styles.ratingStyle.Render(fmt.Sprintf("%.1f/10", rating))
+	ratingLine := d.styles.ratingStyle.Render(fmt.Sprintf("%.1f/10 (%d votes)", rating, result.VoteCount))
 	overviewLine := d.styles.overviewStyle.Render(overview)
 
-	content := lipgloss.JoinVertical(lipgloss.Left, typeLine, titleLine, ratingLine, overviewLine)
+	lines := []string{typeLine, titleLine}
+	if len(result.AlsoKnownAs) > 0 {
+		aka := truncate("aka "+strings.Join(result.AlsoKnownAs, ", "), m.Width()-4)
+		lines = append(lines, d.styles.overviewStyle.Render(aka))
+	}
+	lines = append(lines, ratingLine, overviewLine)
+	content := lipgloss.JoinVertical(lipgloss.Left, lines...)
 
 	container := d.styles.normal
 	if idx == m.Index() {
@@ -145,43 +205,353 @@ func (d tmdbDelegate) Render(w io.Writer, m list.Model, idx int, item list.Item)
 	_, _ = fmt.Fprint(w, container.Render(content))
 }
 
+// sortMode is the order results are listed in.
+type sortMode int
+
+const (
+	sortRelevance sortMode = iota
+	sortYear
+	sortRating
+	sortPopularity
+	// sortModeCount is the number of sort modes "r" cycles through.
+	sortModeCount
+)
+
+func (s sortMode) String() string {
+	switch s {
+	case sortYear:
+		return "year"
+	case sortRating:
+		return "rating"
+	case sortPopularity:
+		return "popularity"
+	default:
+		return "relevance"
+	}
+}
+
 type model struct {
 	list        list.Model
 	searchTitle string
+	header      string
 	result      SelectionResult
+	status      string
+	// fetchPoster is nil unless posters are shown. Thumbnails are fetched
+	// for the highlighted result only and transmitted to graphics; posterIDs
+	// holds the Kitty image ID of each one sent, requested every poster
+	// path fetched so far, including failures.
+	fetchPoster PosterFetcher
+	graphics    io.Writer
+	posterIDs   map[string]int
+	requested   map[string]bool
+	// all holds the full result set in TMDB order; the list shows a
+	// filtered and sorted view of it.
+	all        []tmdbItem
+	typeFilter string
+	sort       sortMode
+	page       int
+	totalPages int
+	loadPage   PageLoader
+	loading    bool
+	// listHeight is the list height on terminals tall enough for it;
+	// itemHeight is the height of one result.
+	listHeight int
+	itemHeight int
+	delegate   tmdbDelegate
+}
+
+// Option configures Select.
+type Option func(*selectOptions)
+
+// PageLoader fetches a further page of search results.
+type PageLoader func(page int) (tmdb.SearchResponse, error)
+
+type selectOptions struct {
+	header      string
+	fetchPoster PosterFetcher
+	page        int
+	totalPages  int
+	loadPage    PageLoader
+	preferType  string
+	// overviewLines is zero when the option isn't given.
+	overviewLines int
 }
 
-func newModel(title string, items []tmdbItem) *model {
+// WithHeader replaces the default "Multiple results found" header.
+func WithHeader(header string) Option {
+	return func(o *selectOptions) {
+		o.header = header
+	}
+}
+
+// WithPagination lets the user append the next page of results with "n".
+// page and totalPages describe the results passed to Select.
+func WithPagination(page, totalPages int, load PageLoader) Option {
+	return func(o *selectOptions) {
+		o.page = page
+		o.totalPages = totalPages
+		o.loadPage = load
+	}
+}
+
+// WithPreferredType highlights the first result of mediaType initially,
+// e.g. the type the user picked last. Enter still has to confirm it.
+func WithPreferredType(mediaType string) Option {
+	return func(o *selectOptions) {
+		o.preferType = mediaType
+	}
+}
+
+// WithOverviewLines lets each result's overview wrap across up to lines
+// lines (1-MaxOverviewLines) instead of being cut off after one. Taller
+// items mean fewer results per page.
+func WithOverviewLines(lines int) Option {
+	return func(o *selectOptions) {
+		o.overviewLines = lines
+	}
+}
+
+// WithPosters shows the highlighted result's poster next to the list on
+// terminals that support the Kitty graphics protocol. Other terminals keep
+// the text-only view.
+func WithPosters(fetch PosterFetcher) Option {
+	return func(o *selectOptions) {
+		o.fetchPoster = fetch
+	}
+}
+
+// browserOpenedMsg reports the outcome of opening a result in the browser.
+type browserOpenedMsg struct {
+	url string
+	err error
+}
+
+// pageLoadedMsg carries the results of a further search page.
+type pageLoadedMsg struct {
+	response tmdb.SearchResponse
+	err      error
+}
+
+func loadNextPage(load PageLoader, page int) tea.Cmd {
+	return func() tea.Msg {
+		response, err := load(page)
+		return pageLoadedMsg{response: response, err: err}
+	}
+}
+
+func openInBrowser(url string) tea.Cmd {
+	return func() tea.Msg {
+		return browserOpenedMsg{url: url, err: util.OpenBrowser(url)}
+	}
+}
+
+func newModel(title string, items []tmdbItem, overviewLines int) *model {
 	listItems := make([]list.Item, len(items))
 	for i, item := range items {
 		listItems[i] = item
 	}
 
-	delegate := newDelegate()
-	l := list.New(listItems, delegate, defaultListWidth, defaultListHeight)
+	overviewLines = max(1, min(overviewLines, MaxOverviewLines))
+	delegate := newDelegate(overviewLines)
+	// grow the list with the items so as many results fit on a page as
+	// with one-line overviews
+	listHeight := defaultListHeight + 2*(overviewLines-1)
+	l := list.New(listItems, delegate, defaultListWidth, listHeight)
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
 	l.SetShowHelp(false)
 	l.SetShowTitle(false)
 	l.SetShowPagination(false)
 	l.DisableQuitKeybindings()
+	// page and jump keys reach the list through Update; bind them to the
+	// named keys only, so the list's letter aliases can't shadow the
+	// selector's own single-letter commands
+	l.KeyMap.PrevPage = key.NewBinding(key.WithKeys("pgup", "left"), key.WithHelp("pgup", "prev page"))
+	l.KeyMap.NextPage = key.NewBinding(key.WithKeys("pgdown", "right"), key.WithHelp("pgdn", "next page"))
+	l.KeyMap.GoToStart = key.NewBinding(key.WithKeys("home"), key.WithHelp("home", "go to start"))
+	l.KeyMap.GoToEnd = key.NewBinding(key.WithKeys("end"), key.WithHelp("end", "go to end"))
 	l.Styles.NoItems = lipgloss.NewStyle()
 
-	return &model{
+	m := &model{
 		list:        l,
 		searchTitle: title,
 		result: SelectionResult{
 			Action: ActionNone,
 		},
+		all:        items,
+		listHeight: listHeight,
+		itemHeight: delegate.Height(),
+		delegate:   delegate,
 	}
+	m.fitAlternativeTitles()
+	return m
 }
 
-func (m *model) Init() tea.Cmd { return nil }
+// fitAlternativeTitles reserves the aka row in every item once any result
+// has alternative titles, so pages don't overflow the list.
+func (m *model) fitAlternativeTitles() {
+	aka := slices.ContainsFunc(m.all, func(item tmdbItem) bool { return len(item.AlsoKnownAs) > 0 })
+	if aka == m.delegate.aka {
+		return
+	}
+	m.delegate.aka = aka
+	m.list.SetDelegate(m.delegate)
+	m.itemHeight = m.delegate.Height()
+}
+
+// enablePosters shows the highlighted result's poster, transmitting the
+// thumbnails to w.
+func (m *model) enablePosters(w io.Writer, fetch PosterFetcher) {
+	m.fetchPoster = fetch
+	m.graphics = w
+	m.posterIDs = make(map[string]int)
+	m.requested = make(map[string]bool)
+}
+
+// posterCmd starts fetching the highlighted result's poster unless it was
+// requested before or no image IDs are left.
+func (m *model) posterCmd() tea.Cmd {
+	if m.fetchPoster == nil || firstImageID+len(m.posterIDs) > maxImageID {
+		return nil
+	}
+	selected, ok := m.list.SelectedItem().(tmdbItem)
+	if !ok || selected.PosterPath == "" || m.requested[selected.PosterPath] {
+		return nil
+	}
+	m.requested[selected.PosterPath] = true
+	return loadPoster(m.fetchPoster, selected.PosterPath)
+}
+
+// showPoster transmits a fetched thumbnail so the next View can place it.
+func (m *model) showPoster(msg posterLoadedMsg) {
+	id := firstImageID + len(m.posterIDs)
+	if msg.err != nil || id > maxImageID {
+		return
+	}
+	if err := transmitKitty(m.graphics, id, msg.data); err != nil {
+		return
+	}
+	m.posterIDs[msg.posterPath] = id
+}
+
+// preselect highlights the first listed result of mediaType, if any.
+func (m *model) preselect(mediaType string) {
+	for i, item := range m.list.Items() {
+		if result, ok := item.(tmdbItem); ok && result.MediaType == mediaType {
+			m.list.Select(i)
+			return
+		}
+	}
+}
+
+// applyView rebuilds the list items from the full result set using the
+// current type filter and sort order.
+func (m *model) applyView() tea.Cmd {
+	visible := make([]tmdbItem, 0, len(m.all))
+	for _, item := range m.all {
+		if m.typeFilter == "" || item.MediaType == m.typeFilter {
+			visible = append(visible, item)
+		}
+	}
+
+	switch m.sort {
+	case sortYear:
+		sort.SliceStable(visible, func(i, j int) bool {
+			return visible[i].Year() > visible[j].Year()
+		})
+	case sortRating:
+		sort.SliceStable(visible, func(i, j int) bool {
+			return visible[i].VoteAverage > visible[j].VoteAverage
+		})
+	case sortPopularity:
+		sort.SliceStable(visible, func(i, j int) bool {
+			return visible[i].Popularity > visible[j].Popularity
+		})
+	}
+
+	listItems := make([]list.Item, len(visible))
+	for i, item := range visible {
+		listItems[i] = item
+	}
+	m.list.ResetSelected()
+
+	filter := "all"
+	if m.typeFilter != "" {
+		filter = m.typeFilter
+	}
+	m.status = fmt.Sprintf("Showing %s, sorted by %s (%d/%d)", filter, m.sort, len(visible), len(m.all))
+	return m.list.SetItems(listItems)
+}
+
+func (m *model) Init() tea.Cmd { return m.posterCmd() }
 
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(posterLoadedMsg); ok {
+		m.showPoster(msg)
+		return m, nil
+	}
+	// any message may change the highlighted result
+	cmd := m.update(msg)
+	return m, tea.Batch(cmd, m.posterCmd())
+}
+
+func (m *model) update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
+	case browserOpenedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Could not open browser: %v", msg.err)
+		} else {
+			m.status = fmt.Sprintf("Opened %s", msg.url)
+		}
+		return nil
+	case pageLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Could not load page %d: %v", m.page+1, msg.err)
+			return nil
+		}
+		m.page = msg.response.Page
+		m.totalPages = msg.response.TotalPages
+		for _, result := range msg.response.Results {
+			m.all = append(m.all, tmdbItem{SearchResult: result})
+		}
+		m.fitAlternativeTitles()
+		cmd := m.applyView()
+		m.status = fmt.Sprintf("Loaded page %d of %d (%d results)", m.page, m.totalPages, len(m.all))
+		return cmd
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "n":
+			if m.loadPage == nil || m.loading {
+				return nil
+			}
+			if m.page >= m.totalPages {
+				m.status = "No more results"
+				return nil
+			}
+			m.loading = true
+			m.status = fmt.Sprintf("Loading page %d...", m.page+1)
+			return loadNextPage(m.loadPage, m.page+1)
+		case "m":
+			m.typeFilter = "movie"
+			return m.applyView()
+		case "t":
+			m.typeFilter = "tv"
+			return m.applyView()
+		case "r":
+			m.sort = (m.sort + 1) % sortModeCount
+			return m.applyView()
+		case "a":
+			m.typeFilter = ""
+			m.sort = sortRelevance
+			cmd := m.applyView()
+			m.status = ""
+			return cmd
+		case "o":
+			if selected, ok := m.list.SelectedItem().(tmdbItem); ok {
+				return openInBrowser(selected.TMDBURL())
+			}
+			return nil
 		case "enter":
 			if selected, ok := m.list.SelectedItem().(tmdbItem); ok {
 				result := selected.SearchResult
@@ -189,39 +559,56 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Action:    ActionSelected,
 					Selection: &result,
 				}
-				return m, tea.Quit
+				return tea.Quit
 			}
 		case "s":
 			m.result = SelectionResult{Action: ActionSkipped}
-			return m, tea.Quit
+			return tea.Quit
 		case "ctrl+c", "q":
 			m.result = SelectionResult{Action: ActionStopped}
-			return m, tea.Quit
+			return tea.Quit
 		case "esc":
 			m.result = SelectionResult{Action: ActionSkipped}
-			return m, tea.Quit
+			return tea.Quit
 		}
 	case tea.WindowSizeMsg:
 		width := clamp(defaultListWidth, msg.Width-4, 40)
-		height := clamp(defaultListHeight, msg.Height-6, 5)
+		// never shrink below one item, or the list would show none
+		height := clamp(m.listHeight, msg.Height-6, max(5, m.itemHeight))
+		// resizing changes the items per page, so keep the highlighted
+		// result rather than its position on the page
+		index := m.list.Index()
 		m.list.SetSize(width, height)
+		m.list.Select(index)
 	}
 
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
-	return m, cmd
+	return cmd
 }
 
 func (m *model) View() string {
-	header := headerStyle.Render(fmt.Sprintf("Multiple results found for: %s", m.searchTitle))
+	headerText := m.header
+	if headerText == "" {
+		headerText = fmt.Sprintf("Multiple results found for: %s", m.searchTitle)
+	}
+	header := headerStyle.Render(headerText)
 	listView := m.list.View()
+	if selected, ok := m.list.SelectedItem().(tmdbItem); ok {
+		if id, ok := m.posterIDs[selected.PosterPath]; ok {
+			listView = lipgloss.JoinHorizontal(lipgloss.Top, listView, "  ", renderPlaceholder(id))
+		}
+	}
 	buttons := lipgloss.JoinHorizontal(
 		lipgloss.Left,
 		skipButtonStyle.Render(" Skip "),
 		lipgloss.NewStyle().Padding(0, 2).Render(""),
 		stopButtonStyle.Render(" Stop Processing "),
 	)
-	help := helpStyle.Render("Up/Down navigate | Enter select | s skip | q stop")
+	help := helpStyle.Render("Up/Down navigate | PgUp/PgDn page | Home/End first/last | Enter select | o open in browser | m movies | t TV | r sort | a reset | n more results | s skip | q stop")
+	if m.status != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, header, listView, buttons, help, statusStyle.Render(m.status))
+	}
 	return lipgloss.JoinVertical(lipgloss.Left, header, listView, buttons, help)
 }
 
@@ -248,15 +635,38 @@ var (
 	helpStyle = lipgloss.NewStyle().
 			MarginTop(1).
 			Foreground(lipgloss.Color("244"))
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("110"))
 )
 
 // Select presents an interactive selection UI for TMDB search results.
-func Select(title string, results []tmdb.SearchResult) (SelectionResult, error) {
+func Select(title string, results []tmdb.SearchResult, opts ...Option) (SelectionResult, error) {
+	if !Interactive() {
+		return SelectionResult{}, ErrNotInteractive
+	}
+
+	var options selectOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	items := make([]tmdbItem, len(results))
 	for i, result := range results {
 		items[i] = tmdbItem{SearchResult: result}
 	}
-	m := newModel(title, items)
+	m := newModel(title, items, options.overviewLines)
+	m.header = options.header
+	m.page = options.page
+	m.totalPages = options.totalPages
+	m.loadPage = options.loadPage
+	if options.preferType != "" {
+		m.preselect(options.preferType)
+	}
+	if options.fetchPoster != nil && kittySupported() {
+		m.enablePosters(os.Stdout, options.fetchPoster)
+		defer func() { deleteKittyImages(os.Stdout, m.posterIDs) }()
+	}
 	program := tea.NewProgram(m)
 
 	finalModel, err := program.Run()
@@ -282,6 +692,39 @@ func truncate(value string, width int) string {
 	return value[:width-3] + "..."
 }
 
+// wrap word-wraps value to width across at most maxLines lines; the last
+// line is truncated when the text doesn't fit.
+func wrap(value string, width, maxLines int) string {
+	if width <= 0 {
+		return truncate(value, width)
+	}
+	words := strings.Fields(value)
+	var (
+		lines []string
+		line  string
+	)
+	for i, word := range words {
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, truncate(line, width))
+			line = word
+		}
+		if len(lines) == maxLines-1 {
+			// the last line takes the rest
+			rest := strings.Join(append([]string{line}, words[i+1:]...), " ")
+			return strings.Join(append(lines, truncate(rest, width)), "\n")
+		}
+	}
+	if line != "" {
+		lines = append(lines, truncate(line, width))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func clamp(defaultValue, available, minimum int) int {
 	width := defaultValue
 	if available > 0 && available < defaultValue {
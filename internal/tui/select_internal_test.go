@@ -0,0 +1,300 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/tmdb"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		width    int
+		maxLines int
+		want     string
+	}{
+		{"one line", "A thief plans  one last\nheist", 12, 1, "A thief p..."},
+		{"fits", "A thief plans one last heist", 30, 3, "A thief plans one last heist"},
+		{"wraps", "A thief plans one last heist", 14, 3, "A thief plans\none last heist"},
+		{"truncates last line", "A thief plans one last heist in Los Angeles", 14, 2, "A thief plans\none last he..."},
+		{"long word", "Supercalifragilistic fun", 10, 3, "Superca...\nfun"},
+	}
+	for _, tc := range tests {
+		if got := wrap(tc.value, tc.width, tc.maxLines); got != tc.want {
+			t.Fatalf("%s: wrap() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestOverviewLinesGrowList(t *testing.T) {
+	m := newModel("Heat", nil, 3)
+	if m.itemHeight != 6 {
+		t.Fatalf("expected items 6 lines high, got %d", m.itemHeight)
+	}
+	perPage := func(m *model) int { return m.list.Height() / (m.itemHeight + 1) }
+	if got, want := perPage(m), perPage(newModel("Heat", nil, 1)); got != want {
+		t.Fatalf("expected %d results per page with wrapped overviews, got %d", want, got)
+	}
+}
+
+func TestPageAndJumpKeys(t *testing.T) {
+	items := make([]tmdbItem, 7)
+	for i := range items {
+		items[i] = tmdbItem{tmdb.SearchResult{ID: i + 1, Title: fmt.Sprintf("Result %d", i+1)}}
+	}
+	m := newModel("Heat", items, 1)
+	perPage := m.list.Paginator.PerPage
+
+	steps := []struct {
+		key  tea.KeyType
+		want int
+	}{
+		{tea.KeyPgDown, perPage},
+		{tea.KeyPgUp, 0},
+		{tea.KeyEnd, len(items) - 1},
+		{tea.KeyHome, 0},
+	}
+	for _, step := range steps {
+		m.Update(tea.KeyMsg{Type: step.key})
+		if got := m.list.Index(); got != step.want {
+			t.Fatalf("after %s: index = %d, want %d", step.key, got, step.want)
+		}
+		if m.result.Action != ActionNone {
+			t.Fatalf("after %s: expected no action, got %v", step.key, m.result.Action)
+		}
+	}
+}
+
+func TestAlternativeTitlesReserveRow(t *testing.T) {
+	plain := tmdbItem{tmdb.SearchResult{ID: 1, Title: "Heat"}}
+	aka := tmdbItem{tmdb.SearchResult{ID: 2, Title: "The Godfather", AlsoKnownAs: []string{"Der Pate"}}}
+
+	m := newModel("Heat", []tmdbItem{plain}, 1)
+	if m.itemHeight != 4 || m.delegate.Height() != 4 {
+		t.Fatalf("expected items 4 lines high without alternative titles, got %d", m.itemHeight)
+	}
+	if got := newModel("Heat", []tmdbItem{plain, aka}, 1).itemHeight; got != 5 {
+		t.Fatalf("expected items 5 lines high with alternative titles, got %d", got)
+	}
+
+	m.Update(pageLoadedMsg{response: tmdb.SearchResponse{Results: []tmdb.SearchResult{aka.SearchResult}, Page: 2, TotalPages: 2}})
+	if m.itemHeight != 5 {
+		t.Fatalf("expected a loaded page with alternative titles to grow the items, got %d", m.itemHeight)
+	}
+	if got, want := m.list.Paginator.PerPage, m.list.Height()/(m.itemHeight+1); got != want {
+		t.Fatalf("expected %d results per page, got %d", want, got)
+	}
+}
+
+func TestItemStylesDontShareRules(t *testing.T) {
+	styles := newItemStyles()
+	backgrounds := []lipgloss.TerminalColor{
+		styles.movieBadge.GetBackground(),
+		styles.tvBadge.GetBackground(),
+		styles.otherBadge.GetBackground(),
+	}
+	if backgrounds[0] == backgrounds[1] || backgrounds[1] == backgrounds[2] {
+		t.Fatalf("expected a distinct background per badge, got %v", backgrounds)
+	}
+	if styles.normal.GetBorderTopForeground() == styles.selected.GetBorderTopForeground() {
+		t.Fatalf("expected the selected style not to change the normal border")
+	}
+}
+
+func TestSelectNeedsTerminal(t *testing.T) {
+	original := isTerminal
+	t.Cleanup(func() { isTerminal = original })
+
+	results := []tmdb.SearchResult{{ID: 949, Title: "Heat"}}
+	tests := []struct {
+		name     string
+		terminal func(*os.File) bool
+		want     bool
+	}{
+		{"no terminal", func(*os.File) bool { return false }, false},
+		{"output piped", func(f *os.File) bool { return f == os.Stdin }, false},
+		{"input piped", func(f *os.File) bool { return f == os.Stdout }, false},
+		{"terminal", func(*os.File) bool { return true }, true},
+	}
+	for _, tt := range tests {
+		isTerminal = tt.terminal
+		if got := Interactive(); got != tt.want {
+			t.Fatalf("%s: Interactive() = %v, want %v", tt.name, got, tt.want)
+		}
+		if tt.want {
+			continue
+		}
+		if _, err := Select("Heat", results); !errors.Is(err, ErrNotInteractive) {
+			t.Fatalf("%s: expected ErrNotInteractive, got %v", tt.name, err)
+		}
+	}
+}
+
+// runCmd runs cmd and feeds the poster messages it produces back to m.
+func runCmd(m *model, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			runCmd(m, cmd)
+		}
+	case posterLoadedMsg:
+		m.Update(msg)
+	}
+}
+
+func TestPostersLoadLazily(t *testing.T) {
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, image.NewRGBA(image.Rect(0, 0, 2, 3))); err != nil {
+		t.Fatalf("encode poster: %v", err)
+	}
+	var fetched []string
+	fetch := func(posterPath string) ([]byte, error) {
+		fetched = append(fetched, posterPath)
+		return poster.Bytes(), nil
+	}
+	items := []tmdbItem{
+		{tmdb.SearchResult{ID: 1, Title: "Heat", PosterPath: "/lazy-heat.png"}},
+		{tmdb.SearchResult{ID: 2, Title: "Ronin", PosterPath: "/lazy-ronin.png"}},
+		{tmdb.SearchResult{ID: 3, Title: "Thief", PosterPath: "/lazy-thief.png"}},
+	}
+	m := newModel("Heat", items, 1)
+	var graphics bytes.Buffer
+	m.enablePosters(&graphics, fetch)
+
+	runCmd(m, m.Init())
+	if !slices.Equal(fetched, []string{"/lazy-heat.png"}) {
+		t.Fatalf("expected only the highlighted poster to be fetched, got %v", fetched)
+	}
+	firstRow, _, _ := strings.Cut(renderPlaceholder(firstImageID), "\n")
+	if graphics.Len() == 0 || !strings.Contains(m.View(), firstRow) {
+		t.Fatalf("expected the highlighted poster to be transmitted and placed")
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	runCmd(m, cmd)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	runCmd(m, cmd)
+	if !slices.Equal(fetched, []string{"/lazy-heat.png", "/lazy-ronin.png"}) {
+		t.Fatalf("expected each highlighted poster to be fetched once, got %v", fetched)
+	}
+	if m.posterIDs["/lazy-ronin.png"] != firstImageID+1 {
+		t.Fatalf("expected the second poster to get the next image ID, got %v", m.posterIDs)
+	}
+}
+
+func TestPreselect(t *testing.T) {
+	items := []tmdbItem{
+		{tmdb.SearchResult{ID: 1, Title: "Heat", MediaType: "movie"}},
+		{tmdb.SearchResult{ID: 2, Name: "Heat", MediaType: "tv"}},
+		{tmdb.SearchResult{ID: 3, Name: "Heat Wave", MediaType: "tv"}},
+	}
+	tests := map[string]int{
+		"tv":     1,
+		"movie":  0,
+		"person": 0,
+	}
+	for mediaType, want := range tests {
+		m := newModel("Heat", items, 1)
+		m.preselect(mediaType)
+		if got := m.list.Index(); got != want {
+			t.Fatalf("preselect(%q): index = %d, want %d", mediaType, got, want)
+		}
+		if m.result.Action != ActionNone {
+			t.Fatalf("preselect(%q): expected no selection until Enter, got %v", mediaType, m.result.Action)
+		}
+	}
+}
+
+// listedIDs returns the IDs of the listed results in order.
+func listedIDs(m *model) []int {
+	var ids []int
+	for _, item := range m.list.Items() {
+		ids = append(ids, item.(tmdbItem).ID)
+	}
+	return ids
+}
+
+func TestTypeFilter(t *testing.T) {
+	items := []tmdbItem{
+		{tmdb.SearchResult{ID: 1, Title: "Heat", MediaType: "movie"}},
+		{tmdb.SearchResult{ID: 2, Name: "Heat", MediaType: "tv"}},
+		{tmdb.SearchResult{ID: 3, Title: "Heat Wave", MediaType: "movie"}},
+	}
+	m := newModel("Heat", items, 1)
+
+	steps := []struct {
+		key  string
+		want []int
+	}{
+		{"m", []int{1, 3}},
+		{"t", []int{2}},
+		{"a", []int{1, 2, 3}},
+	}
+	for _, step := range steps {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(step.key)})
+		if got := listedIDs(m); !slices.Equal(got, step.want) {
+			t.Fatalf("after %q: listed %v, want %v", step.key, got, step.want)
+		}
+		if m.list.Index() != 0 {
+			t.Fatalf("after %q: expected the first result highlighted, got %d", step.key, m.list.Index())
+		}
+	}
+	if m.status != "" {
+		t.Fatalf("expected reset to clear the status, got %q", m.status)
+	}
+}
+
+func TestSortCycle(t *testing.T) {
+	items := []tmdbItem{
+		{tmdb.SearchResult{ID: 1, Title: "Heat", MediaType: "movie", ReleaseDate: "1995-12-15", VoteAverage: 7.0, Popularity: 50}},
+		{tmdb.SearchResult{ID: 2, Name: "Heat", MediaType: "tv", FirstAirDate: "2021-03-01", VoteAverage: 9.0, Popularity: 80}},
+		{tmdb.SearchResult{ID: 3, Title: "Heat", MediaType: "movie", ReleaseDate: "1986-03-14", VoteAverage: 8.4, Popularity: 5}},
+		{tmdb.SearchResult{ID: 4, Title: "Heat", MediaType: "movie", ReleaseDate: "2010-01-01", VoteAverage: 5.0, Popularity: 20}},
+	}
+	m := newModel("Heat", items, 1)
+	m.typeFilter = "movie"
+
+	steps := []struct {
+		sort sortMode
+		want []int
+	}{
+		{sortYear, []int{4, 1, 3}},
+		{sortRating, []int{3, 1, 4}},
+		{sortPopularity, []int{1, 4, 3}},
+		{sortRelevance, []int{1, 3, 4}},
+	}
+	for _, step := range steps {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+		if m.sort != step.sort {
+			t.Fatalf("expected sort %s, got %s", step.sort, m.sort)
+		}
+		if got := listedIDs(m); !slices.Equal(got, step.want) {
+			t.Fatalf("sorted by %s (movies only): listed %v, want %v", step.sort, got, step.want)
+		}
+	}
+
+	m.typeFilter = ""
+	m.sort = sortPopularity
+	m.applyView()
+	if got, want := listedIDs(m), []int{2, 1, 4, 3}; !slices.Equal(got, want) {
+		t.Fatalf("sorted by popularity: listed %v, want %v", got, want)
+	}
+	if want := "Showing all, sorted by popularity (4/4)"; m.status != want {
+		t.Fatalf("expected status %q, got %q", want, m.status)
+	}
+}
@@ -5,23 +5,96 @@ package util
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
+// maxFilenameBytes keeps file names well below the 255-byte limit of common
+// filesystems.
+const maxFilenameBytes = 200
+
 // SanitizeFilename removes invalid characters from a filename.
 func SanitizeFilename(name string) string {
+	return truncateFilename(strings.Trim(replaceInvalid(name), ". "), maxFilenameBytes)
+}
+
+// SanitizeFilenameWithSuffix is like SanitizeFilename(name + suffix), but
+// only name is shortened when the result is too long, so a suffix such as
+// " - 949 - cover.jpg" is always kept whole.
+func SanitizeFilenameWithSuffix(name, suffix string) string {
+	suffix = replaceInvalid(suffix)
+	name = truncateFilename(strings.Trim(replaceInvalid(name), ". "), maxFilenameBytes-len(suffix))
+	return strings.TrimLeft(strings.TrimRight(name, ". ")+suffix, ". ")
+}
+
+func replaceInvalid(name string) string {
 	invalid := []string{`<`, `>`, `:`, `"`, `/`, `\`, `|`, `?`, `*`}
 	for _, ch := range invalid {
 		name = strings.ReplaceAll(name, ch, "_")
 	}
-	name = strings.Trim(name, ". ")
-	if len(name) > 200 {
-		return name[:200]
-	}
 	return name
 }
 
+// truncateFilename cuts name to at most maxBytes on a rune boundary so
+// multibyte characters stay intact.
+func truncateFilename(name string, maxBytes int) string {
+	if len(name) <= maxBytes {
+		return name
+	}
+	cut := max(maxBytes, 0)
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	return name[:cut]
+}
+
+// asciiReplacements spells out letters that don't decompose into an ASCII
+// letter plus accents.
+var asciiReplacements = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D", 'ł': "l", 'Ł': "L", 'þ': "th", 'Þ': "Th", 'ð': "d", 'Ð': "D",
+	'‘': "'", '’': "'", '“': "'", '”': "'", '–': "-", '—': "-", '…': "...",
+}
+
+// ASCIIFilename is like SanitizeFilename but also transliterates the name to
+// ASCII for filesystems and sync tools that choke on anything else: accents
+// are stripped ("Amélie" becomes "Amelie"), a few letters are spelled out
+// ("ß" becomes "ss"), and characters without an ASCII equivalent, such as
+// those of non-Latin scripts, are dropped. When no letter or digit is left,
+// fallback is returned instead, sanitized.
+func ASCIIFilename(name, fallback string) string {
+	stripAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	stripped, _, err := transform.String(stripAccents, name)
+	if err != nil {
+		stripped = name
+	}
+
+	var builder strings.Builder
+	for _, r := range stripped {
+		if replacement, ok := asciiReplacements[r]; ok {
+			builder.WriteString(replacement)
+			continue
+		}
+		if r < utf8.RuneSelf {
+			builder.WriteRune(r)
+		}
+	}
+	// dropped characters can leave stray spaces and dashes behind
+	ascii := strings.Trim(strings.Join(strings.Fields(builder.String()), " "), " -")
+	if !strings.ContainsFunc(ascii, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		return SanitizeFilename(fallback)
+	}
+	return SanitizeFilename(ascii)
+}
+
 // EnsureDir creates a directory and all necessary parent directories.
 func EnsureDir(path string) error {
 	return os.MkdirAll(path, 0o755)
@@ -35,3 +108,31 @@ func RelativeTo(base, target string) (string, error) {
 	}
 	return filepath.ToSlash(rel), nil
 }
+
+// IsTerminal reports whether f is attached to a terminal rather than a pipe or file.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// OpenBrowser opens url in the system's default browser without waiting for it to exit.
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// reap the launcher process in the background
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
@@ -0,0 +1,57 @@
+package util_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/util"
+)
+
+func TestSanitizeFilenameTruncatesOnRuneBoundary(t *testing.T) {
+	name := util.SanitizeFilename("a" + strings.Repeat("é", 150))
+	if !utf8.ValidString(name) {
+		t.Fatalf("truncation split a rune: %q", name)
+	}
+	if len(name) > 200 {
+		t.Fatalf("expected at most 200 bytes, got %d", len(name))
+	}
+}
+
+func TestSanitizeFilenameWithSuffix(t *testing.T) {
+	tests := []struct {
+		name, suffix, want string
+	}{
+		{"Heat", " - 949 - cover.jpg", "Heat - 949 - cover.jpg"},
+		{"Face/Off.", " - 754 - cover.jpg", "Face_Off - 754 - cover.jpg"},
+		{"", " - cover.jpg", "- cover.jpg"},
+	}
+	for _, tt := range tests {
+		if got := util.SanitizeFilenameWithSuffix(tt.name, tt.suffix); got != tt.want {
+			t.Errorf("SanitizeFilenameWithSuffix(%q, %q) = %q, want %q", tt.name, tt.suffix, got, tt.want)
+		}
+	}
+
+	suffix := " - 949 - S01E02 - banner.jpg"
+	got := util.SanitizeFilenameWithSuffix(strings.Repeat("é", 150), suffix)
+	if !strings.HasSuffix(got, suffix) || !utf8.ValidString(got) || len(got) > 200 {
+		t.Fatalf("expected a valid name of at most 200 bytes ending in %q, got %q (%d bytes)", suffix, got, len(got))
+	}
+}
+
+func TestASCIIFilename(t *testing.T) {
+	tests := map[string]string{
+		"Amélie - cover.jpg":         "Amelie - cover.jpg",
+		"Das Boot: Straße":           "Das Boot_ Strasse",
+		"Crème brûlée – Ørsted":      "Creme brulee - Orsted",
+		"Léon: The Professional?.md": "Leon_ The Professional_.md",
+		"Amélie 2 – アメリ":             "Amelie 2",
+		"七人の侍":                       "346",
+		"—…":                         "346",
+	}
+	for input, want := range tests {
+		if got := util.ASCIIFilename(input, "346"); got != want {
+			t.Errorf("ASCIIFilename(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrKeyringUnsupported is returned by KeyringSecret when the system has no
+// supported keyring tool.
+var ErrKeyringUnsupported = errors.New("no supported keyring tool found (macOS security or secret-tool)")
+
+// ReadSecretFile returns the first line of the file at path with surrounding
+// whitespace removed, so a trailing newline or comment lines after the
+// secret don't matter.
+func ReadSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	secret := strings.TrimSpace(line)
+	if secret == "" {
+		return "", fmt.Errorf("%s: first line is empty", path)
+	}
+	return secret, nil
+}
+
+// KeyringSecret looks up the secret stored for service and account in the
+// OS keyring, using the Keychain's security tool on macOS and secret-tool
+// (Secret Service, e.g. GNOME Keyring or KWallet) elsewhere.
+func KeyringSecret(service, account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "windows":
+		return "", ErrKeyringUnsupported
+	default:
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	}
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", ErrKeyringUnsupported
+	}
+	if err != nil {
+		return "", fmt.Errorf("keyring lookup for %s/%s failed: %w", service, account, err)
+	}
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return "", fmt.Errorf("no keyring entry for %s/%s", service, account)
+	}
+	return secret, nil
+}
@@ -0,0 +1,37 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/util"
+)
+
+func TestReadSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{"trailing newline", "  abc123  \n", "abc123", false},
+		{"first line only", "abc123\n# TMDB v3 key\n", "abc123", false},
+		{"crlf", "abc123\r\n", "abc123", false},
+		{"empty", "\nabc123\n", "", true},
+	}
+	for _, tc := range tests {
+		path := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+			t.Fatalf("write key file: %v", err)
+		}
+		got, err := util.ReadSecretFile(path)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Fatalf("%s: ReadSecretFile() = %q, %v; want %q (error: %v)", tc.name, got, err, tc.want, tc.wantErr)
+		}
+	}
+	if _, err := util.ReadSecretFile(filepath.Join(dir, "missing")); err == nil {
+		t.Fatalf("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,68 @@
+// Package version reports which build of obsidian-tmdb-cover is running.
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Version, Commit, and Date are set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/lepinkainen/obsidian-tmdb-cover/internal/version.Version=v1.2.0"
+//
+// Builds without them fall back to the Go build info (see Get).
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes a build.
+type Info struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// Get returns the build's version, commit, and date. Values not injected
+// with -ldflags come from the Go build info where available: the module
+// version for `go install ...@v1.2.0` and the VCS revision and time for
+// builds from a checkout.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+	for _, setting := range build.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = setting.Value
+		case setting.Key == "vcs.time" && info.Date == "":
+			info.Date = setting.Value
+		}
+	}
+	return info
+}
+
+// String renders the build on one line, e.g.
+// "v1.2.0 (commit 1a2b3c4d5e6f, built 2026-10-17T09:30:00Z)".
+func (i Info) String() string {
+	commit := i.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	switch {
+	case commit != "" && i.Date != "":
+		return fmt.Sprintf("%s (commit %s, built %s)", i.Version, commit, i.Date)
+	case commit != "":
+		return fmt.Sprintf("%s (commit %s)", i.Version, commit)
+	case i.Date != "":
+		return fmt.Sprintf("%s (built %s)", i.Version, i.Date)
+	default:
+		return i.Version
+	}
+}
@@ -0,0 +1,29 @@
+package version_test
+
+import (
+	"testing"
+
+	"github.com/lepinkainen/obsidian-tmdb-cover/internal/version"
+)
+
+func TestInfoString(t *testing.T) {
+	tests := []struct {
+		name string
+		info version.Info
+		want string
+	}{
+		{"version only", version.Info{Version: "dev"}, "dev"},
+		{"commit", version.Info{Version: "v1.2.0", Commit: "1a2b3c4"}, "v1.2.0 (commit 1a2b3c4)"},
+		{"date", version.Info{Version: "v1.2.0", Date: "2026-10-17T09:30:00Z"}, "v1.2.0 (built 2026-10-17T09:30:00Z)"},
+		{
+			"commit and date",
+			version.Info{Version: "v1.2.0", Commit: "1a2b3c4d5e6f7a8b9c0d", Date: "2026-10-17T09:30:00Z"},
+			"v1.2.0 (commit 1a2b3c4d5e6f, built 2026-10-17T09:30:00Z)",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.info.String(); got != tt.want {
+			t.Fatalf("%s: String() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}